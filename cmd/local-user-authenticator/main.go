@@ -306,6 +306,8 @@ func startControllers(
 				aVeryLongTime,
 				"local-user-authenticator CA",
 				serviceName,
+				nil,
+				nil,
 			),
 			singletonWorker,
 		).
@@ -165,7 +165,7 @@ func startControllers(
 		).
 		WithController(
 			generator.NewFederationDomainSecretsController(
-				generator.NewSymmetricSecretHelper(
+				generator.NewCachingSecretHelper(generator.NewSymmetricSecretHelper(
 					"pinniped-oidc-provider-hmac-key-",
 					cfg.Labels,
 					rand.Reader,
@@ -174,7 +174,7 @@ func startControllers(
 						plog.Debug("setting hmac secret", "issuer", federationDomainIssuer)
 						secretCache.SetTokenHMACKey(federationDomainIssuer, symmetricKey)
 					},
-				),
+				)),
 				func(fd *configv1alpha1.FederationDomainStatus) *corev1.LocalObjectReference {
 					return &fd.Secrets.TokenSigningKey
 				},
@@ -188,7 +188,7 @@ func startControllers(
 		).
 		WithController(
 			generator.NewFederationDomainSecretsController(
-				generator.NewSymmetricSecretHelper(
+				generator.NewCachingSecretHelper(generator.NewSymmetricSecretHelper(
 					"pinniped-oidc-provider-upstream-state-signature-key-",
 					cfg.Labels,
 					rand.Reader,
@@ -197,7 +197,7 @@ func startControllers(
 						plog.Debug("setting state signature key", "issuer", federationDomainIssuer)
 						secretCache.SetStateEncoderHashKey(federationDomainIssuer, symmetricKey)
 					},
-				),
+				)),
 				func(fd *configv1alpha1.FederationDomainStatus) *corev1.LocalObjectReference {
 					return &fd.Secrets.StateSigningKey
 				},
@@ -211,7 +211,7 @@ func startControllers(
 		).
 		WithController(
 			generator.NewFederationDomainSecretsController(
-				generator.NewSymmetricSecretHelper(
+				generator.NewCachingSecretHelper(generator.NewSymmetricSecretHelper(
 					"pinniped-oidc-provider-upstream-state-encryption-key-",
 					cfg.Labels,
 					rand.Reader,
@@ -220,7 +220,7 @@ func startControllers(
 						plog.Debug("setting state encryption key", "issuer", federationDomainIssuer)
 						secretCache.SetStateEncoderBlockKey(federationDomainIssuer, symmetricKey)
 					},
-				),
+				)),
 				func(fd *configv1alpha1.FederationDomainStatus) *corev1.LocalObjectReference {
 					return &fd.Secrets.StateEncryptionKey
 				},
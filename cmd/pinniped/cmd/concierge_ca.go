@@ -0,0 +1,91 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go.pinniped.dev/internal/groupsuffix"
+)
+
+//nolint: gochecknoinits
+func init() {
+	getCmd.AddCommand(conciergeCACommand(DefaultKubeconfigDeps()))
+}
+
+func conciergeCACommand(deps KubeconfigDeps) *cobra.Command {
+	var (
+		cmd = &cobra.Command{
+			Args:         cobra.NoArgs,
+			Use:          "concierge-ca",
+			Short:        "Print the autodiscovered Concierge certificate authority bundle (PEM format)",
+			SilenceUsage: true,
+		}
+		flags KubeconfigParams
+	)
+
+	f := cmd.Flags()
+	f.StringVar(&flags.concierge.credentialIssuer, "concierge-credential-issuer", "", "Concierge CredentialIssuer object to use for autodiscovery (default: autodiscover)")
+	f.StringVar(&flags.concierge.apiGroupSuffix, "concierge-api-group-suffix", groupsuffix.PinnipedDefaultSuffix, "Concierge API group suffix")
+	f.BoolVar(&flags.concierge.skipWait, "concierge-skip-wait", false, "Skip waiting for any pending Concierge strategies to become ready (default: false)")
+	f.Var(&flags.concierge.mode, "concierge-mode", "Concierge mode of operation")
+	f.StringVar(&flags.kubeconfigPath, "kubeconfig", deps.getenv("KUBECONFIG"), "Path to kubeconfig file")
+	f.StringVar(&flags.kubeconfigContextOverride, "kubeconfig-context", "", "Kubeconfig context name (default: current active context)")
+	f.DurationVar(&flags.timeout, "timeout", 10*time.Minute, "Timeout for autodiscovery")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return runGetConciergeCA(cmd.Context(), cmd.OutOrStdout(), deps, flags)
+	}
+	return cmd
+}
+
+func runGetConciergeCA(ctx context.Context, out io.Writer, deps KubeconfigDeps, flags KubeconfigParams) error {
+	ctx, cancel := context.WithTimeout(ctx, flags.timeout)
+	defer cancel()
+
+	// Validate api group suffix and immediately return an error if it is invalid.
+	if err := groupsuffix.Validate(flags.concierge.apiGroupSuffix); err != nil {
+		return fmt.Errorf("invalid API group suffix: %w", err)
+	}
+
+	clientConfig := newClientConfig(flags.kubeconfigPath, flags.kubeconfigContextOverride)
+	currentKubeConfig, err := clientConfig.RawConfig()
+	if err != nil {
+		return fmt.Errorf("could not load --kubeconfig: %w", err)
+	}
+	cluster, err := copyCurrentClusterFromExistingKubeConfig(currentKubeConfig, flags.kubeconfigContextOverride)
+	if err != nil {
+		return fmt.Errorf("could not load --kubeconfig/--kubeconfig-context: %w", err)
+	}
+	clientset, err := deps.getClientset(clientConfig, flags.concierge.apiGroupSuffix)
+	if err != nil {
+		return fmt.Errorf("could not configure Kubernetes client: %w", err)
+	}
+
+	credentialIssuer, err := waitForCredentialIssuer(ctx, clientset, flags, deps)
+	if err != nil {
+		return err
+	}
+	if err := discoverConciergeParams(credentialIssuer, &flags, cluster, deps.log); err != nil {
+		return err
+	}
+	if len(flags.concierge.caBundle) == 0 {
+		return fmt.Errorf("could not autodiscover a Concierge CA bundle")
+	}
+
+	bundle := []byte(flags.concierge.caBundle)
+	if !bytes.HasSuffix(bundle, []byte("\n")) {
+		bundle = append(bundle, '\n')
+	}
+	if _, err := out.Write(bundle); err != nil {
+		return fmt.Errorf("could not write output: %w", err)
+	}
+	return nil
+}
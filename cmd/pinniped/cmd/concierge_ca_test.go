@@ -0,0 +1,216 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+
+	configv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
+	conciergeclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
+	fakeconciergeclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned/fake"
+	"go.pinniped.dev/internal/certauthority"
+	"go.pinniped.dev/internal/here"
+	"go.pinniped.dev/internal/testutil/testlogger"
+)
+
+func TestGetConciergeCA(t *testing.T) {
+	testConciergeCA, err := certauthority.New("Test Concierge CA", 1*time.Hour)
+	require.NoError(t, err)
+
+	tokenCredentialRequestAPIIssuer := &configv1alpha1.CredentialIssuer{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+		Status: configv1alpha1.CredentialIssuerStatus{
+			Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+				Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+				Status: configv1alpha1.SuccessStrategyStatus,
+				Reason: configv1alpha1.FetchedKeyStrategyReason,
+				Frontend: &configv1alpha1.CredentialIssuerFrontend{
+					Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+					TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+						Server:                   "https://concierge-endpoint.example.com",
+						CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+					},
+				},
+			}},
+		},
+	}
+
+	impersonationProxyIssuer := &configv1alpha1.CredentialIssuer{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+		Status: configv1alpha1.CredentialIssuerStatus{
+			Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+				Type:   configv1alpha1.ImpersonationProxyStrategyType,
+				Status: configv1alpha1.SuccessStrategyStatus,
+				Reason: configv1alpha1.ListeningStrategyReason,
+				Frontend: &configv1alpha1.CredentialIssuerFrontend{
+					Type: configv1alpha1.ImpersonationProxyFrontendType,
+					ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+						Endpoint:                 "https://impersonation-proxy-endpoint.example.com",
+						CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+					},
+				},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		args             []string
+		getClientsetErr  error
+		conciergeObjects []runtime.Object
+		wantLogs         []string
+		wantError        bool
+		wantStdout       string
+		wantStderr       string
+	}{
+		{
+			name: "help flag passed",
+			args: []string{"--help"},
+			wantStdout: here.Doc(`
+				Print the autodiscovered Concierge certificate authority bundle (PEM format)
+
+				Usage:
+				  concierge-ca [flags]
+
+				Flags:
+				      --concierge-api-group-suffix string    Concierge API group suffix (default "pinniped.dev")
+				      --concierge-credential-issuer string   Concierge CredentialIssuer object to use for autodiscovery (default: autodiscover)
+				      --concierge-mode mode                  Concierge mode of operation (default TokenCredentialRequestAPI)
+				      --concierge-skip-wait                  Skip waiting for any pending Concierge strategies to become ready (default: false)
+				  -h, --help                                 help for concierge-ca
+				      --kubeconfig string                    Path to kubeconfig file
+				      --kubeconfig-context string            Kubeconfig context name (default: current active context)
+				      --timeout duration                     Timeout for autodiscovery (default 10m0s)
+			`),
+		},
+		{
+			name: "invalid API group suffix",
+			args: []string{
+				"--concierge-api-group-suffix", ".starts.with.dot",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: invalid API group suffix: a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')
+			`),
+		},
+		{
+			name: "invalid kubeconfig context",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--kubeconfig-context", "invalid",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: could not load --kubeconfig/--kubeconfig-context: no such context "invalid", did you mean "kind-kind"? (available contexts: kind-kind, some-other-context)
+			`),
+		},
+		{
+			name: "clientset creation failure",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+			},
+			getClientsetErr: fmt.Errorf("some kube error"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: could not configure Kubernetes client: some kube error
+			`),
+		},
+		{
+			name: "no credentialissuers",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: no CredentialIssuers were found
+			`),
+		},
+		{
+			name: "no strategy matching --concierge-mode",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-mode", "ImpersonationProxy",
+			},
+			conciergeObjects: []runtime.Object{tokenCredentialRequestAPIIssuer},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="found CredentialIssuer strategy"  "message"="" "reason"="FetchedKey" "status"="Success" "type"="KubeClusterSigningCertificate"`,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: could not find successful Concierge strategy matching --concierge-mode=ImpersonationProxy
+			`),
+		},
+		{
+			name: "TokenCredentialRequest API mode",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+			},
+			conciergeObjects: []runtime.Object{tokenCredentialRequestAPIIssuer},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+			},
+			wantStdout: "fake-certificate-authority-data-value\n",
+		},
+		{
+			name: "impersonation proxy mode",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+			},
+			conciergeObjects: []runtime.Object{impersonationProxyIssuer},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in impersonation proxy mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://impersonation-proxy-endpoint.example.com"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=1`,
+			},
+			wantStdout: string(testConciergeCA.Bundle()),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			testLog := testlogger.New(t)
+			cmd := conciergeCACommand(KubeconfigDeps{
+				getClientset: func(clientConfig clientcmd.ClientConfig, apiGroupSuffix string) (conciergeclientset.Interface, error) {
+					require.Equal(t, "pinniped.dev", apiGroupSuffix)
+					if tt.getClientsetErr != nil {
+						return nil, tt.getClientsetErr
+					}
+					return fakeconciergeclientset.NewSimpleClientset(tt.conciergeObjects...), nil
+				},
+				getenv: func(string) string { return "" },
+				log:    testLog,
+			})
+			require.NotNil(t, cmd)
+
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(tt.args)
+			err := cmd.Execute()
+			if tt.wantError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			testLog.Expect(tt.wantLogs)
+			require.Equal(t, tt.wantStdout, stdout.String(), "unexpected stdout")
+			require.Equal(t, tt.wantStderr, stderr.String(), "unexpected stderr")
+		})
+	}
+}
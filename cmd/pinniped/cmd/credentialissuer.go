@@ -0,0 +1,177 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/stdr"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	configv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
+	"go.pinniped.dev/internal/groupsuffix"
+)
+
+//nolint: gochecknoinits
+func init() {
+	getCmd.AddCommand(newGetCredentialIssuerCommand(getCredentialIssuerRealDeps()))
+}
+
+type getCredentialIssuerDeps struct {
+	getClientset getConciergeClientsetFunc
+	getenv       func(string) string
+	log          logr.Logger
+}
+
+func getCredentialIssuerRealDeps() getCredentialIssuerDeps {
+	return getCredentialIssuerDeps{
+		getClientset: getRealConciergeClientset,
+		getenv:       os.Getenv,
+		log:          stdr.New(log.New(os.Stderr, "", 0)),
+	}
+}
+
+type getCredentialIssuerFlags struct {
+	outputFormat string // e.g., table, json, yaml
+
+	kubeconfigPath            string
+	kubeconfigContextOverride string
+
+	apiGroupSuffix string
+
+	timeout time.Duration
+}
+
+func newGetCredentialIssuerCommand(deps getCredentialIssuerDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Args:         cobra.MaximumNArgs(1),
+		Use:          "credentialissuer [NAME]",
+		Short:        "Print the strategies of a Concierge CredentialIssuer",
+		Long:         "Print the strategies of a Concierge CredentialIssuer (default: autodiscover)",
+		SilenceUsage: true,
+	}
+	flags := &getCredentialIssuerFlags{}
+
+	// flags
+	f := cmd.Flags()
+	f.StringVarP(&flags.outputFormat, "output", "o", "table", "Output format (e.g., 'table', 'json', 'yaml')")
+	f.StringVar(&flags.kubeconfigPath, "kubeconfig", deps.getenv("KUBECONFIG"), "Path to kubeconfig file")
+	f.StringVar(&flags.kubeconfigContextOverride, "kubeconfig-context", "", "Kubeconfig context name (default: current active context)")
+	f.StringVar(&flags.apiGroupSuffix, "concierge-api-group-suffix", groupsuffix.PinnipedDefaultSuffix, "Concierge API group suffix")
+	f.DurationVar(&flags.timeout, "timeout", time.Minute, "Timeout for looking up the CredentialIssuer")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		name := ""
+		if len(args) == 1 {
+			name = args[0]
+		}
+		ctx, cancel := context.WithTimeout(cmd.Context(), flags.timeout)
+		defer cancel()
+		return runGetCredentialIssuer(ctx, cmd.OutOrStdout(), deps, flags, name)
+	}
+
+	return cmd
+}
+
+func runGetCredentialIssuer(ctx context.Context, output io.Writer, deps getCredentialIssuerDeps, flags *getCredentialIssuerFlags, name string) error {
+	// Fail fast on a bad --output, before talking to the cluster.
+	write, err := credentialIssuerStrategiesWriter(flags.outputFormat)
+	if err != nil {
+		return err
+	}
+
+	clientConfig := newClientConfig(flags.kubeconfigPath, flags.kubeconfigContextOverride)
+	clientset, err := deps.getClientset(clientConfig, flags.apiGroupSuffix)
+	if err != nil {
+		return fmt.Errorf("could not configure Kubernetes client: %w", err)
+	}
+
+	credentialIssuer, err := lookupCredentialIssuer(ctx, clientset, name, nil, deps.log)
+	if err != nil {
+		return err
+	}
+
+	if err := write(output, credentialIssuer); err != nil {
+		return fmt.Errorf("could not write output: %w", err)
+	}
+	return nil
+}
+
+type credentialIssuerStrategiesWriterFunc func(output io.Writer, credentialIssuer *configv1alpha1.CredentialIssuer) error
+
+func credentialIssuerStrategiesWriter(outputFormat string) (credentialIssuerStrategiesWriterFunc, error) {
+	switch outputFormat {
+	case "table":
+		return writeCredentialIssuerStrategiesTable, nil
+	case "json":
+		return writeCredentialIssuerStrategiesJSON, nil
+	case "yaml":
+		return writeCredentialIssuerStrategiesYAML, nil
+	default:
+		return nil, fmt.Errorf("unknown output format: %q", outputFormat)
+	}
+}
+
+func writeCredentialIssuerStrategiesTable(output io.Writer, credentialIssuer *configv1alpha1.CredentialIssuer) error {
+	if len(credentialIssuer.Status.Strategies) == 0 {
+		_, err := fmt.Fprintf(output, "CredentialIssuer %s has no strategies\n", credentialIssuer.Name)
+		return err
+	}
+
+	w := tabwriter.NewWriter(output, 0, 8, 3, ' ', 0)
+	if _, err := fmt.Fprintln(w, "TYPE\tSTATUS\tREASON\tFRONTEND\tLAST UPDATED\tMESSAGE"); err != nil {
+		return err
+	}
+	for _, strategy := range credentialIssuer.Status.Strategies {
+		frontend := "<none>"
+		if strategy.Frontend != nil {
+			frontend = string(strategy.Frontend.Type)
+		}
+		lastUpdated := "<unknown>"
+		if !strategy.LastUpdateTime.IsZero() {
+			lastUpdated = strategy.LastUpdateTime.UTC().Format(time.RFC3339)
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			strategy.Type, strategy.Status, strategy.Reason, frontend, lastUpdated, strategy.Message); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func writeCredentialIssuerStrategiesJSON(output io.Writer, credentialIssuer *configv1alpha1.CredentialIssuer) error {
+	data, err := json.MarshalIndent(credentialIssuerStrategies(credentialIssuer), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(output, string(data))
+	return err
+}
+
+func writeCredentialIssuerStrategiesYAML(output io.Writer, credentialIssuer *configv1alpha1.CredentialIssuer) error {
+	data, err := yaml.Marshal(credentialIssuerStrategies(credentialIssuer))
+	if err != nil {
+		return err
+	}
+	_, err = output.Write(data)
+	return err
+}
+
+// credentialIssuerStrategies returns the strategies of the CredentialIssuer, or an empty slice so that the JSON
+// and YAML output is always a list.
+func credentialIssuerStrategies(credentialIssuer *configv1alpha1.CredentialIssuer) []configv1alpha1.CredentialIssuerStrategy {
+	if credentialIssuer.Status.Strategies == nil {
+		return []configv1alpha1.CredentialIssuerStrategy{}
+	}
+	return credentialIssuer.Status.Strategies
+}
@@ -0,0 +1,265 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+
+	configv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
+	conciergeclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
+	fakeconciergeclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned/fake"
+	"go.pinniped.dev/internal/constable"
+	"go.pinniped.dev/internal/here"
+	"go.pinniped.dev/internal/testutil/testlogger"
+)
+
+func TestGetCredentialIssuer(t *testing.T) {
+	lastUpdate := metav1.NewTime(time.Date(2021, 4, 5, 6, 7, 8, 0, time.UTC))
+	credentialIssuer := func(name string, strategies ...configv1alpha1.CredentialIssuerStrategy) *configv1alpha1.CredentialIssuer {
+		return &configv1alpha1.CredentialIssuer{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     configv1alpha1.CredentialIssuerStatus{Strategies: strategies},
+		}
+	}
+	successStrategy := configv1alpha1.CredentialIssuerStrategy{
+		Type:           configv1alpha1.KubeClusterSigningCertificateStrategyType,
+		Status:         configv1alpha1.SuccessStrategyStatus,
+		Reason:         configv1alpha1.FetchedKeyStrategyReason,
+		Message:        "Successfully fetched key",
+		LastUpdateTime: lastUpdate,
+		Frontend: &configv1alpha1.CredentialIssuerFrontend{
+			Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+			TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+				Server:                   "https://concierge-endpoint.example.com",
+				CertificateAuthorityData: "ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==",
+			},
+		},
+	}
+	errorStrategy := configv1alpha1.CredentialIssuerStrategy{
+		Type:           configv1alpha1.ImpersonationProxyStrategyType,
+		Status:         configv1alpha1.ErrorStrategyStatus,
+		Reason:         configv1alpha1.ErrorDuringSetupStrategyReason,
+		Message:        "some error",
+		LastUpdateTime: lastUpdate,
+	}
+
+	tests := []struct {
+		name                string
+		args                []string
+		objects             []runtime.Object
+		gettingClientsetErr error
+		wantError           bool
+		wantLogs            []string
+		wantStdout          string
+		wantStderr          string
+	}{
+		{
+			name: "help flag",
+			args: []string{"--help"},
+			wantStdout: here.Doc(`
+				Print the strategies of a Concierge CredentialIssuer (default: autodiscover)
+
+				Usage:
+				  credentialissuer [NAME] [flags]
+
+				Flags:
+				      --concierge-api-group-suffix string   Concierge API group suffix (default "pinniped.dev")
+				  -h, --help                                help for credentialissuer
+				      --kubeconfig string                   Path to kubeconfig file (default "testdata/kubeconfig.yaml")
+				      --kubeconfig-context string           Kubeconfig context name (default: current active context)
+				  -o, --output string                       Output format (e.g., 'table', 'json', 'yaml') (default "table")
+				      --timeout duration                    Timeout for looking up the CredentialIssuer (default 1m0s)
+			`),
+		},
+		{
+			name:      "too many positional args",
+			args:      []string{"a", "b"},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: accepts at most 1 arg(s), received 2
+			`),
+		},
+		{
+			name:      "invalid output format",
+			args:      []string{"--output", "xml"},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: unknown output format: "xml"
+			`),
+		},
+		{
+			name:                "getting clientset fails",
+			gettingClientsetErr: constable.Error("some get clientset error"),
+			wantError:           true,
+			wantStderr: here.Doc(`
+				Error: could not configure Kubernetes client: some get clientset error
+			`),
+		},
+		{
+			name:      "no CredentialIssuers",
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: no CredentialIssuers were found
+			`),
+		},
+		{
+			name:      "multiple CredentialIssuers without a name",
+			objects:   []runtime.Object{credentialIssuer("test-credential-issuer-1"), credentialIssuer("test-credential-issuer-2")},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: multiple CredentialIssuers were found, so the --concierge-credential-issuer flag must be specified
+			`),
+		},
+		{
+			name:      "named CredentialIssuer does not exist",
+			args:      []string{"does-not-exist"},
+			objects:   []runtime.Object{credentialIssuer("test-credential-issuer")},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: credentialissuers.config.concierge.pinniped.dev "does-not-exist" not found
+			`),
+		},
+		{
+			name:     "autodiscovered CredentialIssuer as a table",
+			objects:  []runtime.Object{credentialIssuer("test-credential-issuer", successStrategy, errorStrategy)},
+			wantLogs: []string{`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`},
+			wantStdout: here.Doc(`
+				TYPE                            STATUS    REASON             FRONTEND                    LAST UPDATED           MESSAGE
+				KubeClusterSigningCertificate   Success   FetchedKey         TokenCredentialRequestAPI   2021-04-05T06:07:08Z   Successfully fetched key
+				ImpersonationProxy              Error     ErrorDuringSetup   <none>                      2021-04-05T06:07:08Z   some error
+			`),
+		},
+		{
+			name: "named CredentialIssuer as a table",
+			args: []string{"test-credential-issuer-2", "--output", "table"},
+			objects: []runtime.Object{
+				credentialIssuer("test-credential-issuer-1", successStrategy),
+				credentialIssuer("test-credential-issuer-2", errorStrategy),
+			},
+			wantStdout: here.Doc(`
+				TYPE                 STATUS   REASON             FRONTEND   LAST UPDATED           MESSAGE
+				ImpersonationProxy   Error    ErrorDuringSetup   <none>     2021-04-05T06:07:08Z   some error
+			`),
+		},
+		{
+			name:     "CredentialIssuer without strategies as a table",
+			objects:  []runtime.Object{credentialIssuer("test-credential-issuer")},
+			wantLogs: []string{`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`},
+			wantStdout: here.Doc(`
+				CredentialIssuer test-credential-issuer has no strategies
+			`),
+		},
+		{
+			name:     "CredentialIssuer as json",
+			args:     []string{"-o", "json"},
+			objects:  []runtime.Object{credentialIssuer("test-credential-issuer", successStrategy, errorStrategy)},
+			wantLogs: []string{`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`},
+			wantStdout: here.Doc(`
+				[
+				  {
+				    "type": "KubeClusterSigningCertificate",
+				    "status": "Success",
+				    "reason": "FetchedKey",
+				    "message": "Successfully fetched key",
+				    "lastUpdateTime": "2021-04-05T06:07:08Z",
+				    "frontend": {
+				      "type": "TokenCredentialRequestAPI",
+				      "tokenCredentialRequestInfo": {
+				        "server": "https://concierge-endpoint.example.com",
+				        "certificateAuthorityData": "ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ=="
+				      }
+				    }
+				  },
+				  {
+				    "type": "ImpersonationProxy",
+				    "status": "Error",
+				    "reason": "ErrorDuringSetup",
+				    "message": "some error",
+				    "lastUpdateTime": "2021-04-05T06:07:08Z"
+				  }
+				]
+			`),
+		},
+		{
+			name:     "CredentialIssuer without strategies as json",
+			args:     []string{"-o", "json"},
+			objects:  []runtime.Object{credentialIssuer("test-credential-issuer")},
+			wantLogs: []string{`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`},
+			wantStdout: here.Doc(`
+				[]
+			`),
+		},
+		{
+			name:    "CredentialIssuer as yaml",
+			args:    []string{"test-credential-issuer", "-o", "yaml"},
+			objects: []runtime.Object{credentialIssuer("test-credential-issuer", errorStrategy)},
+			wantStdout: here.Doc(`
+				- lastUpdateTime: "2021-04-05T06:07:08Z"
+				  message: some error
+				  reason: ErrorDuringSetup
+				  status: Error
+				  type: ImpersonationProxy
+			`),
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			testLog := testlogger.New(t)
+			getClientset := func(clientConfig clientcmd.ClientConfig, apiGroupSuffix string) (conciergeclientset.Interface, error) {
+				require.Equal(t, "testdata/kubeconfig.yaml", clientConfig.ConfigAccess().GetExplicitFile())
+				if test.gettingClientsetErr != nil {
+					return nil, test.gettingClientsetErr
+				}
+				return fakeconciergeclientset.NewSimpleClientset(test.objects...), nil
+			}
+			getenv := func(key string) string {
+				if key == "KUBECONFIG" {
+					return "testdata/kubeconfig.yaml"
+				}
+				return ""
+			}
+			cmd := newGetCredentialIssuerCommand(getCredentialIssuerDeps{getClientset: getClientset, getenv: getenv, log: testLog})
+			stdout, stderr := bytes.NewBuffer([]byte{}), bytes.NewBuffer([]byte{})
+			cmd.SetOut(stdout)
+			cmd.SetErr(stderr)
+			cmd.SetArgs(test.args)
+			err := cmd.Execute()
+			if test.wantError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			testLog.Expect(test.wantLogs)
+			require.Equal(t, test.wantStdout, stdout.String())
+			require.Equal(t, test.wantStderr, stderr.String())
+		})
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, constable.Error("some write error")
+}
+
+func TestWriteCredentialIssuerStrategiesTableWriteError(t *testing.T) {
+	credentialIssuer := &configv1alpha1.CredentialIssuer{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+		Status: configv1alpha1.CredentialIssuerStatus{Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+			Type:    configv1alpha1.ImpersonationProxyStrategyType,
+			Status:  configv1alpha1.ErrorStrategyStatus,
+			Reason:  configv1alpha1.ErrorDuringSetupStrategyReason,
+			Message: "some error",
+		}}},
+	}
+	require.EqualError(t, writeCredentialIssuerStrategiesTable(failingWriter{}, credentialIssuer), "some write error")
+}
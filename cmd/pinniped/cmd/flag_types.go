@@ -4,7 +4,6 @@
 package cmd
 
 import (
-	"bytes"
 	"crypto/x509"
 	"flag"
 	"fmt"
@@ -14,6 +13,7 @@ import (
 	"github.com/spf13/pflag"
 
 	configv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
+	"go.pinniped.dev/internal/pemutil"
 )
 
 // conciergeModeFlag represents the method by which we should connect to the Concierge on a cluster during login.
@@ -84,20 +84,22 @@ func (f *caBundleFlag) String() string {
 	return string(*f)
 }
 
+// Set appends the certificates from the PEM file at the given path to the bundle. Certificates which are
+// already present in the bundle are skipped.
 func (f *caBundleFlag) Set(path string) error {
-	pem, err := ioutil.ReadFile(path)
+	pemData, err := ioutil.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("could not read CA bundle path: %w", err)
 	}
 	pool := x509.NewCertPool()
-	if !pool.AppendCertsFromPEM(pem) {
+	if !pool.AppendCertsFromPEM(pemData) {
 		return fmt.Errorf("failed to load any CA certificates from %q", path)
 	}
-	if len(*f) == 0 {
-		*f = pem
-		return nil
+	merged, err := pemutil.MergePEM(*f, pemData)
+	if err != nil {
+		return fmt.Errorf("invalid CA bundle %q: %w", path, err)
 	}
-	*f = bytes.Join([][]byte{*f, pem}, []byte("\n"))
+	*f = merged
 	return nil
 }
 
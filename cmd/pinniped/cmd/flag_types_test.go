@@ -47,6 +47,18 @@ func TestConciergeModeFlag(t *testing.T) {
 	require.NoError(t, f.Set("impersonationproxy"))
 	require.Equal(t, modeImpersonationProxy, f)
 	require.Equal(t, "ImpersonationProxy", f.String())
+
+	require.NoError(t, f.Set("TOKENCREDENTIALREQUESTAPI"))
+	require.Equal(t, modeTokenCredentialRequestAPI, f)
+	require.Equal(t, "TokenCredentialRequestAPI", f.String())
+
+	require.NoError(t, f.Set("impersonationProxy"))
+	require.Equal(t, modeImpersonationProxy, f)
+	require.Equal(t, "ImpersonationProxy", f.String())
+
+	// Unknown modes are still rejected, and leave the previous value alone.
+	require.EqualError(t, f.Set("impersonation"), `invalid mode "impersonation", valid modes are TokenCredentialRequestAPI and ImpersonationProxy`)
+	require.Equal(t, modeImpersonationProxy, f)
 }
 
 func TestCABundleFlag(t *testing.T) {
@@ -59,6 +71,11 @@ func TestCABundleFlag(t *testing.T) {
 	testCAPath := filepath.Join(tmpdir, "testca.pem")
 	require.NoError(t, ioutil.WriteFile(testCAPath, testCA.Bundle(), 0600))
 
+	testOtherCA, err := certauthority.New("Test Other CA", 1*time.Hour)
+	require.NoError(t, err)
+	testCAOverlappingPath := filepath.Join(tmpdir, "testca-overlapping.pem")
+	require.NoError(t, ioutil.WriteFile(testCAOverlappingPath, append(testOtherCA.Bundle(), testCA.Bundle()...), 0600))
+
 	f := caBundleFlag{}
 	require.Equal(t, "path", f.Type())
 	require.Equal(t, "", f.String())
@@ -68,6 +85,20 @@ func TestCABundleFlag(t *testing.T) {
 	require.NoError(t, f.Set(testCAPath))
 	require.Equal(t, 1, bytes.Count(f, []byte("BEGIN CERTIFICATE")))
 
+	// Setting the same path again does not duplicate the certificate.
 	require.NoError(t, f.Set(testCAPath))
+	require.Equal(t, 1, bytes.Count(f, []byte("BEGIN CERTIFICATE")))
+
+	// Setting a path with one old and one new certificate only appends the new one.
+	require.NoError(t, f.Set(testCAOverlappingPath))
 	require.Equal(t, 2, bytes.Count(f, []byte("BEGIN CERTIFICATE")))
+	require.Equal(t, string(testCA.Bundle())+string(testOtherCA.Bundle()), f.String())
+
+	// A file which contains anything other than certificates is rejected without changing the bundle.
+	testCAKey, err := testCA.PrivateKeyToPEM()
+	require.NoError(t, err)
+	testCAWithKeyPath := filepath.Join(tmpdir, "testca-with-key.pem")
+	require.NoError(t, ioutil.WriteFile(testCAWithKeyPath, append(testCA.Bundle(), testCAKey...), 0600))
+	require.EqualError(t, f.Set(testCAWithKeyPath), fmt.Sprintf(`invalid CA bundle %q: unexpected PEM block type "EC PRIVATE KEY"`, testCAWithKeyPath))
+	require.Equal(t, string(testCA.Bundle())+string(testOtherCA.Bundle()), f.String())
 }
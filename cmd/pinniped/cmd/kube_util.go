@@ -4,9 +4,14 @@
 package cmd
 
 import (
+	"path/filepath"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 
 	conciergeclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
+	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
 	"go.pinniped.dev/internal/groupsuffix"
 	"go.pinniped.dev/internal/kubeclient"
 )
@@ -31,13 +36,76 @@ func getRealConciergeClientset(clientConfig clientcmd.ClientConfig, apiGroupSuff
 	return client.PinnipedConcierge, nil
 }
 
+// getSupervisorClientsetFunc is a function that can return a clientset for the Supervisor API given a
+// clientConfig and the apiGroupSuffix with which the API is running.
+type getSupervisorClientsetFunc func(clientConfig clientcmd.ClientConfig, apiGroupSuffix string) (supervisorclientset.Interface, error)
+
+// getRealSupervisorClientset returns a real implementation of a supervisorclientset.Interface.
+func getRealSupervisorClientset(clientConfig clientcmd.ClientConfig, apiGroupSuffix string) (supervisorclientset.Interface, error) {
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubeclient.New(
+		kubeclient.WithConfig(restConfig),
+		kubeclient.WithMiddleware(groupsuffix.New(apiGroupSuffix)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return client.PinnipedSupervisor, nil
+}
+
+// getKubeClientsetFunc is a function that can return a clientset for the core Kubernetes API given a clientConfig.
+type getKubeClientsetFunc func(clientConfig clientcmd.ClientConfig) (kubernetes.Interface, error)
+
+// getRealKubeClientset returns a real implementation of a kubernetes.Interface.
+func getRealKubeClientset(clientConfig clientcmd.ClientConfig) (kubernetes.Interface, error) {
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubeclient.New(kubeclient.WithConfig(restConfig))
+	if err != nil {
+		return nil, err
+	}
+	return client.Kubernetes, nil
+}
+
 // newClientConfig returns a clientcmd.ClientConfig given an optional kubeconfig path override and
-// an optional context override.
+// an optional context override. Just like the KUBECONFIG environment variable, the path override may be a list
+// of paths (separated by ":" on Linux and macOS, or ";" on Windows) whose kubeconfigs are merged.
 func newClientConfig(kubeconfigPathOverride string, currentContextName string) clientcmd.ClientConfig {
+	return newClientConfigWithRequestTimeout(kubeconfigPathOverride, currentContextName, 0)
+}
+
+// newClientConfigWithRequestTimeout is like newClientConfig, but the resulting rest.Config also has the given
+// timeout for each individual API request. A zero requestTimeout leaves the timeout from the kubeconfig alone.
+func newClientConfigWithRequestTimeout(kubeconfigPathOverride string, currentContextName string, requestTimeout time.Duration) clientcmd.ClientConfig {
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	loadingRules.ExplicitPath = kubeconfigPathOverride
-	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{
+	if paths := splitKubeconfigPaths(kubeconfigPathOverride); len(paths) > 1 {
+		// Merge the files in the same way as a KUBECONFIG list, so the first file to set a value wins.
+		loadingRules.Precedence = paths
+	} else {
+		loadingRules.ExplicitPath = kubeconfigPathOverride
+	}
+	overrides := &clientcmd.ConfigOverrides{
 		CurrentContext: currentContextName,
-	})
-	return clientConfig
+	}
+	if requestTimeout > 0 {
+		overrides.Timeout = requestTimeout.String()
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+}
+
+// splitKubeconfigPaths splits a list of kubeconfig paths in the format of the KUBECONFIG environment variable,
+// skipping any empty entries.
+func splitKubeconfigPaths(kubeconfigPaths string) []string {
+	var paths []string
+	for _, path := range filepath.SplitList(kubeconfigPaths) {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
 }
@@ -8,12 +8,20 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
-	"strconv"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -21,9 +29,20 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/go-logr/stdr"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
 	clientauthenticationv1beta1 "k8s.io/client-go/pkg/apis/clientauthentication/v1beta1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth" // Adds handlers for various dynamic auth plugins in client-go
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
@@ -31,64 +50,199 @@ import (
 	configv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
 	conciergeclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
 	"go.pinniped.dev/internal/groupsuffix"
+	"go.pinniped.dev/internal/here"
+	"go.pinniped.dev/internal/pemutil"
 )
 
-type kubeconfigDeps struct {
-	getPathToSelf func() (string, error)
-	getClientset  getConciergeClientsetFunc
-	log           logr.Logger
+// KubeconfigDeps are the external dependencies of GenerateKubeConfig. Use DefaultKubeconfigDeps to get the real ones.
+type KubeconfigDeps struct {
+	selfPath         SelfPathResolver
+	getClientset     getConciergeClientsetFunc
+	getKubeClientset getKubeClientsetFunc
+	// getSupervisorClientset is only used for --supervisor-federationdomain.
+	getSupervisorClientset getSupervisorClientsetFunc
+	getenv                 func(string) string
+	log                    logr.Logger
+	audit                  AuditFunc
 }
 
-func kubeconfigRealDeps() kubeconfigDeps {
-	return kubeconfigDeps{
-		getPathToSelf: os.Executable,
-		getClientset:  getRealConciergeClientset,
-		log:           stdr.New(log.New(os.Stderr, "", 0)),
+// DefaultKubeconfigDeps returns the KubeconfigDeps of `pinniped get kubeconfig`, which talk to real clusters, log to
+// stderr, and do not audit.
+func DefaultKubeconfigDeps() KubeconfigDeps {
+	return KubeconfigDeps{
+		selfPath:               processSelfPathResolver,
+		getClientset:           getRealConciergeClientset,
+		getKubeClientset:       getRealKubeClientset,
+		getSupervisorClientset: getRealSupervisorClientset,
+		getenv:                 os.Getenv,
+		log:                    stdr.New(log.New(os.Stderr, "", 0)),
+		audit:                  noopAudit,
 	}
 }
 
+// WithLogger returns a copy of the deps which logs to log.
+func (d KubeconfigDeps) WithLogger(log logr.Logger) KubeconfigDeps {
+	d.log = log
+	return d
+}
+
+// WithAudit returns a copy of the deps which passes an AuditRecord to audit for each generated kubeconfig.
+func (d KubeconfigDeps) WithAudit(audit AuditFunc) KubeconfigDeps {
+	d.audit = audit
+	return d
+}
+
 //nolint: gochecknoinits
 func init() {
-	getCmd.AddCommand(kubeconfigCommand(kubeconfigRealDeps()))
+	getCmd.AddCommand(kubeconfigCommand(DefaultKubeconfigDeps()))
 }
 
 type getKubeconfigOIDCParams struct {
-	issuer            string
-	clientID          string
-	listenPort        uint16
-	scopes            []string
-	skipBrowser       bool
-	sessionCachePath  string
-	debugSessionCache bool
-	caBundle          caBundleFlag
-	requestAudience   string
+	issuer             string
+	issuerNoNormalize  bool
+	clientID           string
+	listenPort         uint16
+	redirectURIPath    string
+	upstreamIDPName    string
+	upstreamIDPType    string
+	scopes             []string
+	skipBrowser        bool
+	browserCommand     string
+	sessionCachePath   string
+	sessionCacheCreate bool
+	debugSessionCache  bool
+	caBundle           caBundleFlag
+	requestAudience    string
+	usernameClaim      string
+	groupsClaim        string
 }
 
 type getKubeconfigConciergeParams struct {
-	disabled          bool
-	credentialIssuer  string
-	authenticatorName string
-	authenticatorType string
-	apiGroupSuffix    string
-	caBundle          caBundleFlag
-	endpoint          string
-	mode              conciergeModeFlag
-	skipWait          bool
-}
-
-type getKubeconfigParams struct {
-	kubeconfigPath            string
-	kubeconfigContextOverride string
-	skipValidate              bool
-	timeout                   time.Duration
-	outputPath                string
-	staticToken               string
-	staticTokenEnvName        string
-	oidc                      getKubeconfigOIDCParams
-	concierge                 getKubeconfigConciergeParams
-}
-
-func kubeconfigCommand(deps kubeconfigDeps) *cobra.Command {
+	disabled              bool
+	credentialIssuer      string
+	credentialIssuerRegex string
+	authenticatorName     string
+	authenticatorPrefix   string
+	authenticatorType     string
+	preferredAuthType     string
+	authenticatorAudience string
+	apiGroupSuffix        string
+	caBundle              caBundleFlag
+	caBundleData          string
+	endpoint              string
+	impersonationEndpoint string
+	maxStrategyAge        time.Duration
+	mode                  conciergeModeFlag
+	frontendType          string
+	skipWait              bool
+	failOnEmptyCA         bool
+	packedConfig          bool
+}
+
+// KubeconfigParams holds the already-parsed settings which control how GenerateKubeConfig builds a kubeconfig.
+// Use NewKubeconfigParams to create them outside of this package.
+type KubeconfigParams struct {
+	kubeconfigPath             string
+	kubeconfigContextOverride  string
+	validateContexts           []string
+	skipValidate               bool
+	validationDiagnostics      bool
+	validateAsUser             string
+	validateAsGroups           []string
+	writeDiscoveryAnnotations  bool
+	purge                      bool
+	verbosity                  int
+	timeout                    time.Duration
+	requestTimeout             time.Duration
+	outputPath                 string
+	outputSecret               string
+	supervisorFederationDomain string
+	outputSecretLabels         []string
+	staticToken                string
+	staticTokenEnvName         string
+	staticTokenInline          bool
+	execEnv                    []string
+	clusterExtensions          []string
+	clusterProxyURL            string
+	clusterCABundle            caBundleFlag
+	caBundle                   caBundleFlag
+	oidc                       getKubeconfigOIDCParams
+	concierge                  getKubeconfigConciergeParams
+}
+
+// KubeconfigOption sets one of the KubeconfigParams, see NewKubeconfigParams.
+type KubeconfigOption func(f *pflag.FlagSet) error
+
+// NewKubeconfigParams returns KubeconfigParams with the same defaults as the flags of `pinniped get kubeconfig`
+// (including reading $KUBECONFIG), and then applies each of the opts in order.
+func NewKubeconfigParams(opts ...KubeconfigOption) (KubeconfigParams, error) {
+	var params KubeconfigParams
+	f := pflag.NewFlagSet("kubeconfig", pflag.ContinueOnError)
+	addKubeconfigFlags(f, &params, os.Getenv)
+	for _, opt := range opts {
+		if err := opt(f); err != nil {
+			return KubeconfigParams{}, err
+		}
+	}
+	return params, nil
+}
+
+// WithKubeconfigFlag sets the param of the `pinniped get kubeconfig` flag with the given name (without the leading
+// dashes) exactly as if the flag had been passed on the command line, so it may be repeated for repeatable flags.
+func WithKubeconfigFlag(name, value string) KubeconfigOption {
+	return func(f *pflag.FlagSet) error {
+		return f.Set(name, value)
+	}
+}
+
+// WithKubeconfigPath sets the path of the kubeconfig used to discover the cluster, like --kubeconfig.
+func WithKubeconfigPath(path string) KubeconfigOption {
+	return WithKubeconfigFlag("kubeconfig", path)
+}
+
+// WithKubeconfigContext sets the context of the kubeconfig used to discover the cluster, like --kubeconfig-context.
+func WithKubeconfigContext(name string) KubeconfigOption {
+	return WithKubeconfigFlag("kubeconfig-context", name)
+}
+
+// WithTimeout sets the overall timeout for autodiscovery and validation, like --timeout.
+func WithTimeout(timeout time.Duration) KubeconfigOption {
+	return WithKubeconfigFlag("timeout", timeout.String())
+}
+
+// GenerationResult describes what GenerateKubeConfig discovered or was told about the cluster, so that callers
+// can inspect those settings without parsing the generated kubeconfig.
+type GenerationResult struct {
+	// Issuer and Audience are empty when the kubeconfig uses `pinniped login static`.
+	Issuer   string
+	Audience string
+
+	// These are all empty when the kubeconfig does not use the Concierge.
+	ConciergeEndpoint string
+	ConciergeMode     string
+	AuthenticatorType string
+	AuthenticatorName string
+}
+
+// AuditRecord describes a single kubeconfig which was generated by GenerateKubeConfig, so that tools which embed
+// it can log generations centrally.
+type AuditRecord struct {
+	GenerationResult
+
+	// ClusterServer is the server URL of the cluster entry in the generated kubeconfig.
+	ClusterServer string
+
+	// Timestamp is when the kubeconfig was generated.
+	Timestamp time.Time
+}
+
+// AuditFunc receives an AuditRecord for each kubeconfig which is successfully generated.
+type AuditFunc func(record AuditRecord)
+
+// noopAudit is the default AuditFunc, which discards every record.
+func noopAudit(AuditRecord) {}
+
+func kubeconfigCommand(deps KubeconfigDeps) *cobra.Command {
 	var (
 		cmd = &cobra.Command{
 			Args:         cobra.NoArgs,
@@ -96,190 +250,651 @@ func kubeconfigCommand(deps kubeconfigDeps) *cobra.Command {
 			Short:        "Generate a Pinniped-based kubeconfig for a cluster",
 			SilenceUsage: true,
 		}
-		flags     getKubeconfigParams
-		namespace string // unused now
+		flags KubeconfigParams
 	)
+	addKubeconfigFlags(cmd.Flags(), &flags, deps.getenv)
+
+	mustMarkHidden(cmd, "oidc-debug-session-cache")
+
+	mustMarkDeprecated(cmd, "concierge-namespace", "not needed anymore")
+	mustMarkHidden(cmd, "concierge-namespace")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		// Cancel autodiscovery and validation as soon as the user hits Ctrl-C, instead of waiting for --timeout.
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		// Raise the verbosity of only this command's logger, rather than the global stdr threshold.
+		runDeps := deps
+		if flags.verbosity > 0 {
+			runDeps = deps.WithLogger(newVerbosityLogger(deps.log, flags.verbosity))
+		}
+
+		err := runKubeconfigCommand(ctx, cmd, runDeps, flags)
+		if err != nil && ctx.Err() != nil {
+			return fmt.Errorf("interrupted before the kubeconfig could be generated")
+		}
+		return err
+	}
+	return cmd
+}
+
+// verbosityLogger wraps a logr.Logger so that its V(level) loggers are also enabled for any level up to verbosity,
+// without changing the threshold of the wrapped logger (which, for stdr, is global). Messages which are only enabled
+// by verbosity are written to the wrapped logger at its own level.
+type verbosityLogger struct {
+	logr.Logger
+	verbosity int
+	level     int
+}
+
+func newVerbosityLogger(log logr.Logger, verbosity int) logr.Logger {
+	return verbosityLogger{Logger: log, verbosity: verbosity}
+}
+
+func (l verbosityLogger) Enabled() bool {
+	return l.Logger.V(l.level).Enabled() || (l.level <= l.verbosity && l.Logger.Enabled())
+}
+
+func (l verbosityLogger) Info(msg string, keysAndValues ...interface{}) {
+	switch {
+	case l.Logger.V(l.level).Enabled():
+		l.Logger.V(l.level).Info(msg, keysAndValues...)
+	case l.level <= l.verbosity:
+		l.Logger.Info(msg, keysAndValues...)
+	}
+}
+
+func (l verbosityLogger) V(level int) logr.Logger {
+	l.level += level
+	return l
+}
+
+func (l verbosityLogger) WithValues(keysAndValues ...interface{}) logr.Logger {
+	l.Logger = l.Logger.WithValues(keysAndValues...)
+	return l
+}
+
+func (l verbosityLogger) WithName(name string) logr.Logger {
+	l.Logger = l.Logger.WithName(name)
+	return l
+}
 
-	f := cmd.Flags()
+// addKubeconfigFlags registers the flags of `pinniped get kubeconfig`, which set the fields of flags.
+func addKubeconfigFlags(f *pflag.FlagSet, flags *KubeconfigParams, getenv func(string) string) {
+	var namespace string // unused now
 	f.StringVar(&flags.staticToken, "static-token", "", "Instead of doing an OIDC-based login, specify a static token")
 	f.StringVar(&flags.staticTokenEnvName, "static-token-env", "", "Instead of doing an OIDC-based login, read a static token from the environment")
+	f.BoolVar(&flags.staticTokenInline, "static-token-inline", false, "Write the --static-token directly into the generated kubeconfig instead of running a Pinniped login (requires --no-concierge)")
 
 	f.BoolVar(&flags.concierge.disabled, "no-concierge", false, "Generate a configuration which does not use the Concierge, but sends the credential to the cluster directly")
 	f.StringVar(&namespace, "concierge-namespace", "pinniped-concierge", "Namespace in which the Concierge was installed")
 	f.StringVar(&flags.concierge.credentialIssuer, "concierge-credential-issuer", "", "Concierge CredentialIssuer object to use for autodiscovery (default: autodiscover)")
+	f.StringVar(&flags.concierge.credentialIssuerRegex, "concierge-credential-issuer-pattern", "", "Regular expression which must match the name of exactly one Concierge CredentialIssuer object to use for autodiscovery")
 	f.StringVar(&flags.concierge.authenticatorType, "concierge-authenticator-type", "", "Concierge authenticator type (e.g., 'webhook', 'jwt') (default: autodiscover)")
 	f.StringVar(&flags.concierge.authenticatorName, "concierge-authenticator-name", "", "Concierge authenticator name (default: autodiscover)")
+	f.StringVar(&flags.concierge.authenticatorPrefix, "concierge-authenticator-name-prefix", "", "Only autodiscover Concierge authenticators whose names start with this prefix")
+	f.StringVar(&flags.concierge.preferredAuthType, "concierge-prefer-authenticator-type", "", "When autodiscovery finds exactly one JWTAuthenticator and one WebhookAuthenticator, use the one of this type (e.g., 'webhook', 'jwt') instead of failing")
+	f.StringVar(&flags.concierge.authenticatorAudience, "concierge-authenticator-audience", "", "Audience to request for a JWTAuthenticator using RFC8693 token exchange, instead of the authenticator's spec.audience (default: autodiscover)")
 	f.StringVar(&flags.concierge.apiGroupSuffix, "concierge-api-group-suffix", groupsuffix.PinnipedDefaultSuffix, "Concierge API group suffix")
 	f.BoolVar(&flags.concierge.skipWait, "concierge-skip-wait", false, "Skip waiting for any pending Concierge strategies to become ready (default: false)")
+	f.BoolVar(&flags.concierge.failOnEmptyCA, "fail-on-empty-ca", false, "Fail if the autodiscovered Concierge CA bundle is missing or does not contain any certificates, instead of only warning (default: false)")
+	f.BoolVar(&flags.concierge.packedConfig, "concierge-packed-config", false, "Pass the Concierge settings to the login command as a single base64 encoded JSON --concierge-config arg, instead of one arg per setting (default: false)")
 
+	f.Var(&flags.caBundle, "ca-bundle", "Path to TLS certificate authority bundle (PEM format, optional, can be repeated) to use for both the OpenID Connect issuer and the Concierge, unless overridden by --oidc-ca-bundle or --concierge-ca-bundle")
 	f.Var(&flags.concierge.caBundle, "concierge-ca-bundle", "Path to TLS certificate authority bundle (PEM format, optional, can be repeated) to use when connecting to the Concierge")
+	f.StringVar(&flags.concierge.caBundleData, "concierge-ca-bundle-data", "", "Base64 encoded TLS certificate authority bundle (base64 encoded PEM format, optional) to use when connecting to the Concierge, instead of --concierge-ca-bundle")
 	f.StringVar(&flags.concierge.endpoint, "concierge-endpoint", "", "API base for the Concierge endpoint")
+	f.StringVar(&flags.concierge.impersonationEndpoint, "concierge-impersonation-endpoint", "", "When the Concierge advertises multiple impersonation proxy endpoints, use the one with this URL (default: the first one)")
 	f.Var(&flags.concierge.mode, "concierge-mode", "Concierge mode of operation")
+	f.StringVar(&flags.concierge.frontendType, "concierge-frontend-type", "", "Use the first successful CredentialIssuer strategy with this frontend type (e.g., 'TokenCredentialRequestAPI', 'ImpersonationProxy'), as an alternative to --concierge-mode")
+	f.DurationVar(&flags.concierge.maxStrategyAge, "concierge-max-strategy-age", 0, "Ignore Concierge strategies which were last updated longer ago than this duration during autodiscovery (default: no limit)")
 
 	f.StringVar(&flags.oidc.issuer, "oidc-issuer", "", "OpenID Connect issuer URL (default: autodiscover)")
+	f.BoolVar(&flags.oidc.issuerNoNormalize, "oidc-issuer-no-normalize", false, "Do not trim a trailing slash from the OpenID Connect issuer URL (default: false)")
+	f.StringVar(&flags.supervisorFederationDomain, "supervisor-federationdomain", "", "NAMESPACE/NAME of a Supervisor FederationDomain in the --kubeconfig cluster whose issuer is used as the OpenID Connect issuer, unless --oidc-issuer is set or autodiscovered from a JWTAuthenticator")
 	f.StringVar(&flags.oidc.clientID, "oidc-client-id", "pinniped-cli", "OpenID Connect client ID (default: autodiscover)")
 	f.Uint16Var(&flags.oidc.listenPort, "oidc-listen-port", 0, "TCP port for localhost listener (authorization code flow only)")
+	f.StringVar(&flags.oidc.redirectURIPath, "oidc-redirect-uri-path", "", "Path of the localhost redirect URI, whose port is set by --oidc-listen-port (authorization code flow only) (default: /callback)")
 	f.StringSliceVar(&flags.oidc.scopes, "oidc-scopes", []string{oidc.ScopeOfflineAccess, oidc.ScopeOpenID, "pinniped:request-audience"}, "OpenID Connect scopes to request during login")
 	f.BoolVar(&flags.oidc.skipBrowser, "oidc-skip-browser", false, "During OpenID Connect login, skip opening the browser (just print the URL)")
+	f.StringVar(&flags.oidc.browserCommand, "oidc-browser-command", "", "During OpenID Connect login, open the URL with this command instead of the system browser (e.g., 'wslview')")
 	f.StringVar(&flags.oidc.sessionCachePath, "oidc-session-cache", "", "Path to OpenID Connect session cache file")
+	f.BoolVar(&flags.oidc.sessionCacheCreate, "oidc-session-cache-create", false, "Create the parent directory of --oidc-session-cache (with 0700 permissions) if it does not already exist")
 	f.Var(&flags.oidc.caBundle, "oidc-ca-bundle", "Path to TLS certificate authority bundle (PEM format, optional, can be repeated)")
 	f.BoolVar(&flags.oidc.debugSessionCache, "oidc-debug-session-cache", false, "Print debug logs related to the OpenID Connect session cache")
-	f.StringVar(&flags.oidc.requestAudience, "oidc-request-audience", "", "Request a token with an alternate audience using RFC8693 token exchange")
-	f.StringVar(&flags.kubeconfigPath, "kubeconfig", os.Getenv("KUBECONFIG"), "Path to kubeconfig file")
+	f.StringVar(&flags.oidc.requestAudience, "oidc-request-audience", "", "Request a token with an alternate audience using RFC8693 token exchange (only one audience can be requested)")
+	f.StringVar(&flags.oidc.upstreamIDPName, "upstream-identity-provider-name", "", "The name of the upstream identity provider used during login with a Supervisor")
+	f.StringVar(&flags.oidc.upstreamIDPType, "upstream-identity-provider-type", "", "The type of the upstream identity provider used during login with a Supervisor (e.g. 'oidc', 'ldap')")
+	f.StringVar(&flags.kubeconfigPath, "kubeconfig", getenv("KUBECONFIG"), "Path to kubeconfig file, or a list of paths to merge like KUBECONFIG")
 	f.StringVar(&flags.kubeconfigContextOverride, "kubeconfig-context", "", "Kubeconfig context name (default: current active context)")
+	f.StringSliceVar(&flags.validateContexts, "validate-contexts", nil, "Instead of generating a kubeconfig, validate the kubeconfig which would be generated for each of these kubeconfig context names")
 	f.BoolVar(&flags.skipValidate, "skip-validation", false, "Skip final validation of the kubeconfig (default: false)")
+	f.StringVar(&flags.validateAsUser, "validate-as-user", "", "Impersonate this username (with the credentials of the --kubeconfig user) during final validation of the kubeconfig, without adding impersonation to the generated kubeconfig (only supported when the generated kubeconfig uses the same server as the --kubeconfig)")
+	f.StringArrayVar(&flags.validateAsGroups, "validate-as-group", nil, "Impersonate this group during final validation of the kubeconfig, requires --validate-as-user (can be repeated)")
+	f.BoolVar(&flags.validationDiagnostics, "validation-diagnostics", false, "If final validation of the kubeconfig fails, print details about the attempted connection to stderr (default: false)")
+	f.BoolVar(&flags.purge, "purge", false, "Instead of generating a kubeconfig, remove the Pinniped-generated entries from the --kubeconfig file (default: false)")
 	f.DurationVar(&flags.timeout, "timeout", 10*time.Minute, "Timeout for autodiscovery and validation")
+	f.DurationVar(&flags.requestTimeout, "request-timeout", 0, "Timeout for each individual Kubernetes API request, so that a hung request fails fast instead of using up all of --timeout (default: no per-request timeout)")
 	f.StringVarP(&flags.outputPath, "output", "o", "", "Output file path (default: stdout)")
+	f.StringVar(&flags.outputSecret, "output-secret", "", "Instead of writing the kubeconfig to a file, create or update the Secret with this namespace/name in the --kubeconfig cluster, under the \"value\" key")
+	f.StringArrayVar(&flags.outputSecretLabels, "output-secret-label", nil, "Label (KEY=VALUE) to set on the --output-secret Secret (can be repeated)")
+	f.BoolVar(&flags.writeDiscoveryAnnotations, "write-discovery-annotations", false, "Record the discovered authenticator and OIDC issuer in an extension of the user entry of the generated kubeconfig (default: false)")
+	f.StringArrayVar(&flags.execEnv, "exec-env", nil, "Environment variable (KEY=VALUE) to set for the Pinniped login process in the generated kubeconfig (can be repeated)")
+	f.StringArrayVar(&flags.clusterExtensions, "cluster-extension", nil, "Extension (NAME=JSON) to add to the cluster entry of the generated kubeconfig, e.g. to record provenance metadata (can be repeated)")
+	f.Var(&flags.clusterCABundle, "cluster-ca-bundle", "Path to TLS certificate authority bundle (PEM format, optional, can be repeated) to pin as the certificate-authority-data of the cluster in the generated kubeconfig, instead of the discovered CA")
+	f.IntVarP(&flags.verbosity, "verbose", "v", 0, "Log verbosity level, where higher levels log more details about autodiscovery (default: 0)")
+	f.StringVar(&flags.clusterProxyURL, "cluster-proxy-url", "", "URL of the HTTP or SOCKS5 proxy to set as the proxy-url of the cluster in the generated kubeconfig")
+}
 
-	mustMarkHidden(cmd, "oidc-debug-session-cache")
+func runKubeconfigCommand(ctx context.Context, cmd *cobra.Command, deps KubeconfigDeps, flags KubeconfigParams) error {
+	if flags.purge {
+		return purgeKubeconfig(flags, deps)
+	}
+	if len(flags.validateContexts) > 0 {
+		return validateKubeconfigContexts(ctx, flags, deps)
+	}
+	if flags.outputSecret != "" {
+		if flags.outputPath != "" {
+			return fmt.Errorf("--output and --output-secret cannot be used together")
+		}
+		return writeKubeconfigSecret(ctx, flags, deps)
+	}
+	if len(flags.outputSecretLabels) > 0 {
+		return fmt.Errorf("--output-secret-label can only be used with --output-secret")
+	}
+	if flags.outputPath != "" {
+		out, err := os.Create(flags.outputPath)
+		if err != nil {
+			return fmt.Errorf("could not open output file: %w", err)
+		}
+		defer func() { _ = out.Close() }()
+		cmd.SetOut(out)
+	}
+	err := runGetKubeconfig(ctx, cmd.OutOrStdout(), deps, flags)
+	var validationErr *validationError
+	if flags.validationDiagnostics && errors.As(err, &validationErr) {
+		validationErr.diagnostics.render(cmd.ErrOrStderr())
+	}
+	return err
+}
 
-	mustMarkDeprecated(cmd, "concierge-namespace", "not needed anymore")
-	mustMarkHidden(cmd, "concierge-namespace")
+func runGetKubeconfig(ctx context.Context, out io.Writer, deps KubeconfigDeps, flags KubeconfigParams) error {
+	kubeconfig, _, err := GenerateKubeConfig(ctx, flags, deps)
+	if err != nil {
+		return err
+	}
+	return writeConfigAsYAML(out, *kubeconfig)
+}
 
-	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		if flags.outputPath != "" {
-			out, err := os.Create(flags.outputPath)
-			if err != nil {
-				return fmt.Errorf("could not open output file: %w", err)
-			}
-			defer func() { _ = out.Close() }()
-			cmd.SetOut(out)
+// parseNamespacedName splits a NAMESPACE/NAME flag value into its parts.
+func parseNamespacedName(flagName, value string) (string, string, error) {
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid %s %q, expected NAMESPACE/NAME", flagName, value)
+	}
+	return parts[0], parts[1], nil
+}
+
+// writeKubeconfigSecret generates a kubeconfig and stores it under the "value" key of the --output-secret Secret,
+// using the cluster from --kubeconfig/--kubeconfig-context. Any other keys and labels of an existing Secret are
+// preserved, while the --output-secret-label labels are always set.
+func writeKubeconfigSecret(ctx context.Context, flags KubeconfigParams, deps KubeconfigDeps) error {
+	namespace, name, err := parseNamespacedName("--output-secret", flags.outputSecret)
+	if err != nil {
+		return err
+	}
+	labels, err := parseOutputSecretLabels(flags.outputSecretLabels)
+	if err != nil {
+		return err
+	}
+
+	kubeconfig, _, err := GenerateKubeConfig(ctx, flags, deps)
+	if err != nil {
+		return err
+	}
+	kubeconfigYAML, err := clientcmd.Write(*kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	kubeClient, err := deps.getKubeClientset(newClientConfigWithRequestTimeout(flags.kubeconfigPath, flags.kubeconfigContextOverride, flags.requestTimeout))
+	if err != nil {
+		return fmt.Errorf("could not configure Kubernetes client: %w", err)
+	}
+	secrets := kubeClient.CoreV1().Secrets(namespace)
+
+	secret, err := secrets.Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = secrets.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{"value": kubeconfigYAML},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("could not create --output-secret %s/%s: %w", namespace, name, err)
 		}
-		return runGetKubeconfig(cmd.Context(), cmd.OutOrStdout(), deps, flags)
+		deps.log.Info("created Secret with kubeconfig", "namespace", namespace, "name", name)
+	case err != nil:
+		return fmt.Errorf("could not get --output-secret %s/%s: %w", namespace, name, err)
+	default:
+		secret = secret.DeepCopy()
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data["value"] = kubeconfigYAML
+		if len(labels) > 0 && secret.Labels == nil {
+			secret.Labels = map[string]string{}
+		}
+		for key, value := range labels {
+			secret.Labels[key] = value
+		}
+		if _, err := secrets.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("could not update --output-secret %s/%s: %w", namespace, name, err)
+		}
+		deps.log.Info("updated Secret with kubeconfig", "namespace", namespace, "name", name)
 	}
-	return cmd
+	return nil
+}
+
+// validateKubeconfigContexts generates and validates a kubeconfig for each of the --validate-contexts, without
+// writing any of them out. Failures for individual contexts do not stop the others from being validated.
+func validateKubeconfigContexts(ctx context.Context, flags KubeconfigParams, deps KubeconfigDeps) error {
+	if flags.kubeconfigContextOverride != "" {
+		return fmt.Errorf("--validate-contexts cannot be used with --kubeconfig-context")
+	}
+	if flags.skipValidate {
+		return fmt.Errorf("--validate-contexts cannot be used with --skip-validation")
+	}
+
+	var errs []error
+	for _, kubeContext := range flags.validateContexts {
+		contextFlags := flags
+		contextFlags.kubeconfigContextOverride = kubeContext
+		if _, _, err := GenerateKubeConfig(ctx, contextFlags, deps); err != nil {
+			errs = append(errs, fmt.Errorf("context %q: %w", kubeContext, err))
+			continue
+		}
+		deps.log.Info("validated kubeconfig context", "context", kubeContext)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// GenerateKubeConfig builds (and unless skipped, validates) a Pinniped-based kubeconfig from the provided params,
+// without any involvement from cobra. This is the logic behind `pinniped get kubeconfig`. The returned
+// GenerationResult summarizes the settings which ended up in the kubeconfig, and is also passed to the audit
+// func of the deps (if any) along with the time of generation.
+func GenerateKubeConfig(ctx context.Context, flags KubeconfigParams, deps KubeconfigDeps) (*clientcmdapi.Config, *GenerationResult, error) {
+	kubeconfig, result, err := generateKubeConfig(ctx, flags, deps)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	audit := deps.audit
+	if audit == nil {
+		audit = noopAudit
+	}
+	audit(AuditRecord{
+		GenerationResult: *result,
+		ClusterServer:    kubeconfig.Clusters[generatedKubeconfigName].Server,
+		Timestamp:        time.Now(),
+	})
+	return kubeconfig, result, nil
 }
 
 //nolint:funlen
-func runGetKubeconfig(ctx context.Context, out io.Writer, deps kubeconfigDeps, flags getKubeconfigParams) error {
+func generateKubeConfig(ctx context.Context, flags KubeconfigParams, deps KubeconfigDeps) (*clientcmdapi.Config, *GenerationResult, error) {
 	ctx, cancel := context.WithTimeout(ctx, flags.timeout)
 	defer cancel()
 
 	// Validate api group suffix and immediately return an error if it is invalid.
 	if err := groupsuffix.Validate(flags.concierge.apiGroupSuffix); err != nil {
-		return fmt.Errorf("invalid API group suffix: %w", err)
+		return nil, nil, fmt.Errorf("invalid API group suffix: %w", err)
+	}
+
+	// Validate any explicitly provided URLs before making any API calls.
+	if err := validateHTTPSURL("--concierge-endpoint", flags.concierge.endpoint); err != nil {
+		return nil, nil, err
+	}
+	if err := validateHTTPSURL("--oidc-issuer", flags.oidc.issuer); err != nil {
+		return nil, nil, err
+	}
+	if err := validateHTTPSURL("--concierge-impersonation-endpoint", flags.concierge.impersonationEndpoint); err != nil {
+		return nil, nil, err
+	}
+	if err := validateProxyURL("--cluster-proxy-url", flags.clusterProxyURL); err != nil {
+		return nil, nil, err
+	}
+
+	if flags.requestTimeout < 0 {
+		return nil, nil, fmt.Errorf("invalid --request-timeout %s, must not be negative", flags.requestTimeout)
+	}
+
+	// Impersonation during validation requires a username, just like it does for kubectl's --as-group flag.
+	if len(flags.validateAsGroups) > 0 && flags.validateAsUser == "" {
+		return nil, nil, fmt.Errorf("--validate-as-group requires --validate-as-user")
+	}
+	// Both flags choose the audience to request, so setting both would mean silently ignoring one of them.
+	if flags.concierge.authenticatorAudience != "" && flags.oidc.requestAudience != "" {
+		return nil, nil, fmt.Errorf("only one of --concierge-authenticator-audience and --oidc-request-audience can be specified")
+	}
+	// An inline token is sent to the cluster as-is, so there is no login process to exchange it with the Concierge.
+	if flags.staticTokenInline {
+		switch {
+		case flags.staticToken == "":
+			return nil, nil, fmt.Errorf("--static-token-inline requires --static-token")
+		case !flags.concierge.disabled:
+			return nil, nil, fmt.Errorf("--static-token-inline requires --no-concierge, since the Concierge cannot exchange a token which is not sent by a Pinniped login")
+		case len(flags.execEnv) > 0:
+			return nil, nil, fmt.Errorf("--static-token-inline and --exec-env cannot be used together")
+		}
+	}
+	if flags.oidc.sessionCacheCreate && flags.oidc.sessionCachePath == "" {
+		return nil, nil, fmt.Errorf("--oidc-session-cache-create requires --oidc-session-cache")
+	}
+	// Every OIDC login needs a client ID and an ID token, so reject OIDC settings which would break every login.
+	// They are ignored when one of the --static-* flags is used.
+	if flags.staticToken == "" && flags.staticTokenEnvName == "" {
+		if flags.oidc.clientID == "" {
+			return nil, nil, fmt.Errorf("--oidc-client-id must not be empty")
+		}
+		if !sets.NewString(flags.oidc.scopes...).Has(oidc.ScopeOpenID) {
+			return nil, nil, fmt.Errorf("--oidc-scopes must include %q, since the login needs an ID token", oidc.ScopeOpenID)
+		}
+	}
+
+	// Likewise validate --concierge-prefer-authenticator-type, even though it is only used in some cases.
+	switch strings.ToLower(flags.concierge.preferredAuthType) {
+	case "", "webhook", "jwt":
+	default:
+		return nil, nil, fmt.Errorf(`invalid --concierge-prefer-authenticator-type %q, supported values are "webhook" and "jwt"`, flags.concierge.preferredAuthType)
+	}
+
+	// Normalize --concierge-frontend-type to the exact frontend type used in the CredentialIssuer status.
+	if flags.concierge.frontendType != "" {
+		if flags.concierge.mode != modeUnknown {
+			return nil, nil, fmt.Errorf("--concierge-mode and --concierge-frontend-type cannot be used together")
+		}
+		switch {
+		case strings.EqualFold(flags.concierge.frontendType, string(configv1alpha1.TokenCredentialRequestAPIFrontendType)):
+			flags.concierge.frontendType = string(configv1alpha1.TokenCredentialRequestAPIFrontendType)
+		case strings.EqualFold(flags.concierge.frontendType, string(configv1alpha1.ImpersonationProxyFrontendType)):
+			flags.concierge.frontendType = string(configv1alpha1.ImpersonationProxyFrontendType)
+		default:
+			return nil, nil, fmt.Errorf(`invalid --concierge-frontend-type %q, supported values are "TokenCredentialRequestAPI" and "ImpersonationProxy"`, flags.concierge.frontendType)
+		}
+	}
+
+	execEnv, err := parseExecEnv(flags.execEnv)
+	if err != nil {
+		return nil, nil, err
+	}
+	clusterExtensions, err := parseClusterExtensions(flags.clusterExtensions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if flags.concierge.caBundleData != "" {
+		if len(flags.concierge.caBundle) != 0 {
+			return nil, nil, fmt.Errorf("--concierge-ca-bundle and --concierge-ca-bundle-data cannot be used together")
+		}
+		flags.concierge.caBundle, err = decodeCABundleData("--concierge-ca-bundle-data", flags.concierge.caBundleData)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// The shared --ca-bundle flag is only a default, so the more specific --oidc-ca-bundle and
+	// --concierge-ca-bundle flags take precedence over it.
+	if len(flags.oidc.caBundle) == 0 {
+		flags.oidc.caBundle = flags.caBundle
+	}
+	if len(flags.concierge.caBundle) == 0 {
+		flags.concierge.caBundle = flags.caBundle
 	}
 
 	execConfig := clientcmdapi.ExecConfig{
 		APIVersion: clientauthenticationv1beta1.SchemeGroupVersion.String(),
-		Args:       []string{},
-		Env:        []clientcmdapi.ExecEnvVar{},
+		Env:        execEnv,
 	}
+	var loginArgs loginExecArgs
 
-	var err error
-	execConfig.Command, err = deps.getPathToSelf()
+	execConfig.Command, err = deps.selfPath.PathToSelf()
 	if err != nil {
-		return fmt.Errorf("could not determine the Pinniped executable path: %w", err)
+		return nil, nil, fmt.Errorf("could not determine the Pinniped executable path: %w", err)
 	}
 	execConfig.ProvideClusterInfo = true
 
-	clientConfig := newClientConfig(flags.kubeconfigPath, flags.kubeconfigContextOverride)
+	clientConfig := newClientConfigWithRequestTimeout(flags.kubeconfigPath, flags.kubeconfigContextOverride, flags.requestTimeout)
 	currentKubeConfig, err := clientConfig.RawConfig()
 	if err != nil {
-		return fmt.Errorf("could not load --kubeconfig: %w", err)
+		return nil, nil, fmt.Errorf("could not load --kubeconfig: %w", err)
 	}
 	cluster, err := copyCurrentClusterFromExistingKubeConfig(currentKubeConfig, flags.kubeconfigContextOverride)
 	if err != nil {
-		return fmt.Errorf("could not load --kubeconfig/--kubeconfig-context: %w", err)
+		return nil, nil, fmt.Errorf("could not load --kubeconfig/--kubeconfig-context: %w", err)
 	}
 	clientset, err := deps.getClientset(clientConfig, flags.concierge.apiGroupSuffix)
 	if err != nil {
-		return fmt.Errorf("could not configure Kubernetes client: %w", err)
+		return nil, nil, fmt.Errorf("could not configure Kubernetes client: %w", err)
 	}
 
 	if !flags.concierge.disabled {
 		credentialIssuer, err := waitForCredentialIssuer(ctx, clientset, flags, deps)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 
 		authenticator, err := lookupAuthenticator(
+			ctx,
 			clientset,
 			flags.concierge.authenticatorType,
 			flags.concierge.authenticatorName,
+			flags.concierge.authenticatorPrefix,
+			flags.concierge.preferredAuthType,
 			deps.log,
 		)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 		if err := discoverConciergeParams(credentialIssuer, &flags, cluster, deps.log); err != nil {
-			return err
+			return nil, nil, err
 		}
 		if err := discoverAuthenticatorParams(authenticator, &flags, deps.log); err != nil {
-			return err
+			return nil, nil, err
 		}
-		// Append the flags to configure the Concierge credential exchange at runtime.
-		execConfig.Args = append(execConfig.Args,
-			"--enable-concierge",
-			"--concierge-api-group-suffix="+flags.concierge.apiGroupSuffix,
-			"--concierge-authenticator-name="+flags.concierge.authenticatorName,
-			"--concierge-authenticator-type="+flags.concierge.authenticatorType,
-			"--concierge-endpoint="+flags.concierge.endpoint,
-			"--concierge-ca-bundle-data="+base64.StdEncoding.EncodeToString(flags.concierge.caBundle),
-		)
+		// Pass the flags to configure the Concierge credential exchange at runtime.
+		loginArgs.concierge = &conciergeLoginArgs{
+			apiGroupSuffix:    flags.concierge.apiGroupSuffix,
+			authenticatorName: flags.concierge.authenticatorName,
+			authenticatorType: flags.concierge.authenticatorType,
+			endpoint:          flags.concierge.endpoint,
+			caBundle:          flags.concierge.caBundle,
+		}
+		loginArgs.packConcierge = flags.concierge.packedConfig
 
 		// Point kubectl at the concierge endpoint.
 		cluster.Server = flags.concierge.endpoint
 		cluster.CertificateAuthorityData = flags.concierge.caBundle
 	}
 
+	if flags.clusterProxyURL != "" {
+		cluster.ProxyURL = flags.clusterProxyURL
+	}
+	// A pinned cluster CA only changes how kubectl verifies the cluster, not the CA passed to the Concierge login.
+	if len(flags.clusterCABundle) > 0 {
+		cluster.CertificateAuthorityData = flags.clusterCABundle
+	}
+	if len(clusterExtensions) > 0 {
+		if cluster.Extensions == nil {
+			cluster.Extensions = map[string]runtime.Object{}
+		}
+		for name, extension := range clusterExtensions {
+			cluster.Extensions[name] = extension
+		}
+	}
+
 	// If one of the --static-* flags was passed, output a config that runs `pinniped login static`.
 	if flags.staticToken != "" || flags.staticTokenEnvName != "" {
 		if flags.staticToken != "" && flags.staticTokenEnvName != "" {
-			return fmt.Errorf("only one of --static-token and --static-token-env can be specified")
+			return nil, nil, fmt.Errorf("only one of --static-token and --static-token-env can be specified")
 		}
-		execConfig.Args = append([]string{"login", "static"}, execConfig.Args...)
-		if flags.staticToken != "" {
-			execConfig.Args = append(execConfig.Args, "--token="+flags.staticToken)
+		if flags.staticTokenEnvName != "" && deps.getenv(flags.staticTokenEnvName) == "" {
+			deps.log.Info("warning: the --static-token-env environment variable is not currently set, so logins with this kubeconfig may fail", "name", flags.staticTokenEnvName)
 		}
-		if flags.staticTokenEnvName != "" {
-			execConfig.Args = append(execConfig.Args, "--token-env="+flags.staticTokenEnvName)
+		if flags.staticTokenInline {
+			return finishKubeconfig(ctx, flags, newTokenKubeconfig(cluster, flags.staticToken), false, deps.log)
 		}
-
-		kubeconfig := newExecKubeconfig(cluster, &execConfig)
-		if err := validateKubeconfig(ctx, flags, kubeconfig, deps.log); err != nil {
-			return err
+		loginArgs.staticToken = flags.staticToken
+		loginArgs.staticTokenEnv = flags.staticTokenEnvName
+		if execConfig.Args, err = buildLoginExecArgs(loginArgs); err != nil {
+			return nil, nil, err
 		}
-		return writeConfigAsYAML(out, kubeconfig)
+		return finishKubeconfig(ctx, flags, newExecKubeconfig(cluster, &execConfig), false, deps.log)
 	}
 
 	// Otherwise continue to parse the OIDC-related flags and output a config that runs `pinniped login oidc`.
-	execConfig.Args = append([]string{"login", "oidc"}, execConfig.Args...)
+	if flags.oidc.issuer == "" && flags.supervisorFederationDomain != "" {
+		if err := discoverFederationDomainIssuer(ctx, clientConfig, &flags, deps); err != nil {
+			return nil, nil, err
+		}
+	}
 	if flags.oidc.issuer == "" {
-		return fmt.Errorf("could not autodiscover --oidc-issuer and none was provided")
+		return nil, nil, fmt.Errorf("could not autodiscover --oidc-issuer and none was provided")
 	}
-	execConfig.Args = append(execConfig.Args,
-		"--issuer="+flags.oidc.issuer,
-		"--client-id="+flags.oidc.clientID,
-		"--scopes="+strings.Join(flags.oidc.scopes, ","),
-	)
-	if flags.oidc.skipBrowser {
-		execConfig.Args = append(execConfig.Args, "--skip-browser")
+	// Trim a single trailing slash, since the issuer must exactly match the "iss" claim of the issued tokens.
+	if !flags.oidc.issuerNoNormalize {
+		flags.oidc.issuer = strings.TrimSuffix(flags.oidc.issuer, "/")
 	}
-	if flags.oidc.listenPort != 0 {
-		execConfig.Args = append(execConfig.Args, "--listen-port="+strconv.Itoa(int(flags.oidc.listenPort)))
+	// Create the session cache directory now, so that the first login with this kubeconfig does not fail.
+	if flags.oidc.sessionCacheCreate {
+		if err := os.MkdirAll(filepath.Dir(flags.oidc.sessionCachePath), 0700); err != nil {
+			return nil, nil, fmt.Errorf("could not create --oidc-session-cache directory: %w", err)
+		}
 	}
-	if len(flags.oidc.caBundle) != 0 {
-		execConfig.Args = append(execConfig.Args, "--ca-bundle-data="+base64.StdEncoding.EncodeToString(flags.oidc.caBundle))
+	loginArgs.oidc = oidcLoginArgs{
+		issuer:            flags.oidc.issuer,
+		clientID:          flags.oidc.clientID,
+		scopes:            flags.oidc.scopes,
+		skipBrowser:       flags.oidc.skipBrowser,
+		browserCommand:    flags.oidc.browserCommand,
+		listenPort:        flags.oidc.listenPort,
+		redirectURIPath:   flags.oidc.redirectURIPath,
+		caBundle:          flags.oidc.caBundle,
+		sessionCachePath:  flags.oidc.sessionCachePath,
+		debugSessionCache: flags.oidc.debugSessionCache,
+		requestAudience:   flags.oidc.requestAudience,
+		upstreamIDPName:   flags.oidc.upstreamIDPName,
+		upstreamIDPType:   flags.oidc.upstreamIDPType,
+		usernameClaim:     flags.oidc.usernameClaim,
+		groupsClaim:       flags.oidc.groupsClaim,
 	}
-	if flags.oidc.sessionCachePath != "" {
-		execConfig.Args = append(execConfig.Args, "--session-cache="+flags.oidc.sessionCachePath)
+	if execConfig.Args, err = buildLoginExecArgs(loginArgs); err != nil {
+		return nil, nil, err
 	}
-	if flags.oidc.debugSessionCache {
-		execConfig.Args = append(execConfig.Args, "--debug-session-cache")
+	return finishKubeconfig(ctx, flags, newExecKubeconfig(cluster, &execConfig), true, deps.log)
+}
+
+// discoverFederationDomainIssuer sets --oidc-issuer to the spec.issuer of the --supervisor-federationdomain.
+func discoverFederationDomainIssuer(ctx context.Context, clientConfig clientcmd.ClientConfig, flags *KubeconfigParams, deps KubeconfigDeps) error {
+	namespace, name, err := parseNamespacedName("--supervisor-federationdomain", flags.supervisorFederationDomain)
+	if err != nil {
+		return err
 	}
-	if flags.oidc.requestAudience != "" {
-		execConfig.Args = append(execConfig.Args, "--request-audience="+flags.oidc.requestAudience)
+	clientset, err := deps.getSupervisorClientset(clientConfig, flags.concierge.apiGroupSuffix)
+	if err != nil {
+		return fmt.Errorf("could not configure Supervisor client: %w", err)
 	}
-	kubeconfig := newExecKubeconfig(cluster, &execConfig)
-	if err := validateKubeconfig(ctx, flags, kubeconfig, deps.log); err != nil {
-		return err
+	federationDomain, err := clientset.ConfigV1alpha1().FederationDomains(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("could not get --supervisor-federationdomain %s/%s: %w", namespace, name, err)
+	}
+	// Just like for JWTAuthenticators, the OIDC login flow must never fetch tokens from an issuer over plaintext.
+	if !isAbsoluteHTTPSURL(federationDomain.Spec.Issuer) {
+		return fmt.Errorf("FederationDomain %s/%s has spec.issuer %q, but it must be an absolute https URL so that tokens are not sent over plaintext", namespace, name, federationDomain.Spec.Issuer)
 	}
-	return writeConfigAsYAML(out, kubeconfig)
+	deps.log.Info("discovered OIDC issuer from FederationDomain", "namespace", namespace, "name", name, "issuer", federationDomain.Spec.Issuer)
+	flags.oidc.issuer = federationDomain.Spec.Issuer
+	return nil
 }
 
-func waitForCredentialIssuer(ctx context.Context, clientset conciergeclientset.Interface, flags getKubeconfigParams, deps kubeconfigDeps) (*configv1alpha1.CredentialIssuer, error) {
-	credentialIssuer, err := lookupCredentialIssuer(clientset, flags.concierge.credentialIssuer, deps.log)
+// finishKubeconfig adds the optional discovery extension to the generated kubeconfig and then validates it.
+func finishKubeconfig(ctx context.Context, flags KubeconfigParams, kubeconfig clientcmdapi.Config, oidc bool, log logr.Logger) (*clientcmdapi.Config, *GenerationResult, error) {
+	result := newGenerationResult(flags, oidc)
+	if flags.writeDiscoveryAnnotations {
+		if err := addDiscoveryExtension(&kubeconfig, result); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := validateKubeconfig(ctx, flags, kubeconfig, log); err != nil {
+		return nil, nil, err
+	}
+	return &kubeconfig, result, nil
+}
+
+// discoveryExtensionName is the name of the extension which --write-discovery-annotations adds to the user entry.
+const discoveryExtensionName = "discovery.pinniped.dev"
+
+// discoveryExtension records which authenticator and issuer were used for a generated kubeconfig. It is purely
+// informational, i.e. `pinniped login` never reads it.
+type discoveryExtension struct {
+	AuthenticatorType string `json:"authenticatorType,omitempty"`
+	AuthenticatorName string `json:"authenticatorName,omitempty"`
+	Issuer            string `json:"issuer,omitempty"`
+}
+
+func addDiscoveryExtension(kubeconfig *clientcmdapi.Config, result *GenerationResult) error {
+	ext := discoveryExtension{
+		AuthenticatorType: result.AuthenticatorType,
+		AuthenticatorName: result.AuthenticatorName,
+		Issuer:            result.Issuer,
+	}
+	if ext == (discoveryExtension{}) {
+		return nil
+	}
+	raw, err := json.Marshal(ext)
+	if err != nil {
+		return fmt.Errorf("could not encode discovery extension: %w", err)
+	}
+	authInfo := kubeconfig.AuthInfos[generatedKubeconfigName]
+	if authInfo.Extensions == nil {
+		authInfo.Extensions = map[string]runtime.Object{}
+	}
+	authInfo.Extensions[discoveryExtensionName] = &runtime.Unknown{Raw: raw, ContentType: runtime.ContentTypeJSON}
+	return nil
+}
+
+// newGenerationResult summarizes the final flags of GenerateKubeConfig, after all autodiscovery has happened.
+func newGenerationResult(flags KubeconfigParams, oidc bool) *GenerationResult {
+	result := GenerationResult{}
+	if oidc {
+		result.Issuer = flags.oidc.issuer
+		result.Audience = flags.oidc.requestAudience
+	}
+	if !flags.concierge.disabled {
+		result.ConciergeEndpoint = flags.concierge.endpoint
+		result.ConciergeMode = flags.concierge.mode.String()
+		result.AuthenticatorType = flags.concierge.authenticatorType
+		result.AuthenticatorName = flags.concierge.authenticatorName
+	}
+	return &result
+}
+
+func waitForCredentialIssuer(ctx context.Context, clientset conciergeclientset.Interface, flags KubeconfigParams, deps KubeconfigDeps) (*configv1alpha1.CredentialIssuer, error) {
+	var namePattern *regexp.Regexp
+	if flags.concierge.credentialIssuerRegex != "" {
+		if flags.concierge.credentialIssuer != "" {
+			return nil, fmt.Errorf("--concierge-credential-issuer and --concierge-credential-issuer-pattern cannot be used together")
+		}
+		var err error
+		namePattern, err = regexp.Compile(flags.concierge.credentialIssuerRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --concierge-credential-issuer-pattern: %w", err)
+		}
+	}
+
+	credentialIssuer, err := lookupCredentialIssuer(ctx, clientset, flags.concierge.credentialIssuer, namePattern, deps.log)
 	if err != nil {
 		return nil, err
 	}
@@ -304,7 +919,7 @@ func waitForCredentialIssuer(ctx context.Context, clientset conciergeclientset.I
 			case <-ctx.Done():
 				return nil, ctx.Err()
 			case <-ticker.C:
-				credentialIssuer, err = lookupCredentialIssuer(clientset, flags.concierge.credentialIssuer, deps.log)
+				credentialIssuer, err = lookupCredentialIssuer(ctx, clientset, flags.concierge.credentialIssuer, namePattern, deps.log)
 				if err != nil {
 					return nil, err
 				}
@@ -314,9 +929,9 @@ func waitForCredentialIssuer(ctx context.Context, clientset conciergeclientset.I
 	return credentialIssuer, nil
 }
 
-func discoverConciergeParams(credentialIssuer *configv1alpha1.CredentialIssuer, flags *getKubeconfigParams, v1Cluster *clientcmdapi.Cluster, log logr.Logger) error {
+func discoverConciergeParams(credentialIssuer *configv1alpha1.CredentialIssuer, flags *KubeconfigParams, v1Cluster *clientcmdapi.Cluster, log logr.Logger) error {
 	// Autodiscover the --concierge-mode.
-	frontend, err := getConciergeFrontend(credentialIssuer, flags.concierge.mode)
+	frontend, err := getConciergeFrontend(credentialIssuer, flags.concierge.mode, configv1alpha1.FrontendType(flags.concierge.frontendType), flags.concierge.impersonationEndpoint, flags.concierge.maxStrategyAge, log)
 	if err != nil {
 		logStrategies(credentialIssuer, log)
 		return err
@@ -347,17 +962,37 @@ func discoverConciergeParams(credentialIssuer *configv1alpha1.CredentialIssuer,
 
 	// Auto-set --concierge-ca-bundle if it wasn't explicitly set..
 	if len(flags.concierge.caBundle) == 0 {
+		caProvided := true
 		switch frontend.Type {
 		case configv1alpha1.TokenCredentialRequestAPIFrontendType:
 			flags.concierge.caBundle = v1Cluster.CertificateAuthorityData
 		case configv1alpha1.ImpersonationProxyFrontendType:
+			// An empty string is valid base64, but it means that the strategy did not provide any CA data at all,
+			// which is a different problem than CA data which is present but malformed.
+			if frontend.ImpersonationProxyInfo.CertificateAuthorityData == "" {
+				caProvided = false
+				break
+			}
 			data, err := base64.StdEncoding.DecodeString(frontend.ImpersonationProxyInfo.CertificateAuthorityData)
 			if err != nil {
 				return fmt.Errorf("autodiscovered Concierge CA bundle is invalid: %w", err)
 			}
 			flags.concierge.caBundle = data
 		}
-		log.Info("discovered Concierge certificate authority bundle", "roots", countCACerts(flags.concierge.caBundle))
+		roots := countCACerts(flags.concierge.caBundle)
+		log.Info("discovered Concierge certificate authority bundle", "roots", roots)
+		log.V(1).Info("discovered Concierge certificate authority subjects", "subjects", caSubjects(flags.concierge.caBundle))
+		if !caProvided {
+			if flags.concierge.failOnEmptyCA {
+				return fmt.Errorf("autodiscovered Concierge impersonation proxy strategy does not provide a CA bundle")
+			}
+			log.Info("warning: autodiscovered Concierge impersonation proxy strategy does not provide a CA bundle, so the connection to the Concierge will likely fail")
+		} else if roots == 0 {
+			if flags.concierge.failOnEmptyCA {
+				return fmt.Errorf("autodiscovered Concierge CA bundle does not contain any certificates")
+			}
+			log.Info("warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail")
+		}
 	}
 	return nil
 }
@@ -373,20 +1008,35 @@ func logStrategies(credentialIssuer *configv1alpha1.CredentialIssuer, log logr.L
 	}
 }
 
-func discoverAuthenticatorParams(authenticator metav1.Object, flags *getKubeconfigParams, log logr.Logger) error {
+func discoverAuthenticatorParams(authenticator metav1.Object, flags *KubeconfigParams, log logr.Logger) error {
 	switch auth := authenticator.(type) {
 	case *conciergev1alpha1.WebhookAuthenticator:
+		// The Concierge sends tokens to the webhook, so never use one which would receive them over plaintext.
+		if auth.Spec.Endpoint != "" && !isAbsoluteHTTPSURL(auth.Spec.Endpoint) {
+			return fmt.Errorf("WebhookAuthenticator %s has spec.endpoint %q, but it must be an absolute https URL so that tokens are not sent over plaintext", auth.Name, auth.Spec.Endpoint)
+		}
+
+		// A webhook receives the token as-is, so there is no audience to request with a token exchange.
+		if flags.concierge.authenticatorAudience != "" {
+			return fmt.Errorf("--concierge-authenticator-audience can only be used with a JWTAuthenticator, but WebhookAuthenticator %s was selected", auth.Name)
+		}
+
 		// If the --concierge-authenticator-type/--concierge-authenticator-name flags were not set explicitly, set
 		// them to point at the discovered WebhookAuthenticator.
-		if flags.concierge.authenticatorType == "" && flags.concierge.authenticatorName == "" {
+		if flags.concierge.authenticatorType == "" {
 			log.Info("discovered WebhookAuthenticator", "name", auth.Name)
 			flags.concierge.authenticatorType = "webhook"
 			flags.concierge.authenticatorName = auth.Name
 		}
 	case *conciergev1alpha1.JWTAuthenticator:
+		// Likewise, the OIDC login flow must never fetch tokens from an issuer over plaintext.
+		if auth.Spec.Issuer != "" && !isAbsoluteHTTPSURL(auth.Spec.Issuer) {
+			return fmt.Errorf("JWTAuthenticator %s has spec.issuer %q, but it must be an absolute https URL so that tokens are not sent over plaintext", auth.Name, auth.Spec.Issuer)
+		}
+
 		// If the --concierge-authenticator-type/--concierge-authenticator-name flags were not set explicitly, set
 		// them to point at the discovered JWTAuthenticator.
-		if flags.concierge.authenticatorType == "" && flags.concierge.authenticatorName == "" {
+		if flags.concierge.authenticatorType == "" {
 			log.Info("discovered JWTAuthenticator", "name", auth.Name)
 			flags.concierge.authenticatorType = "jwt"
 			flags.concierge.authenticatorName = auth.Name
@@ -398,12 +1048,30 @@ func discoverAuthenticatorParams(authenticator metav1.Object, flags *getKubeconf
 			flags.oidc.issuer = auth.Spec.Issuer
 		}
 
+		// If the --concierge-authenticator-audience flag was set, it takes the place of the audience that would
+		// otherwise be discovered from the JWTAuthenticator. It cannot be combined with --oidc-request-audience.
+		if flags.concierge.authenticatorAudience != "" {
+			flags.oidc.requestAudience = flags.concierge.authenticatorAudience
+		}
+
 		// If the --oidc-request-audience flag was not set explicitly, default it to the spec.audience field of the JWTAuthenticator.
+		// Only this one audience is ever requested, since the JWTAuthenticator validates a single spec.audience and the
+		// Supervisor issues each exchanged token for a single audience (see oidcclient.WithRequestAudience).
 		if flags.oidc.requestAudience == "" {
+			if auth.Spec.Audience == "" {
+				return fmt.Errorf("tried to autodiscover --oidc-request-audience, but JWTAuthenticator %s has empty spec.audience (use --oidc-request-audience to override)", auth.Name)
+			}
 			log.Info("discovered OIDC audience", "audience", auth.Spec.Audience)
 			flags.oidc.requestAudience = auth.Spec.Audience
 		}
 
+		// If the JWTAuthenticator customizes which claims it reads, pass them along so that the CLI can check them.
+		if auth.Spec.Claims.Username != "" || auth.Spec.Claims.Groups != "" {
+			log.Info("discovered OIDC claims", "username", auth.Spec.Claims.Username, "groups", auth.Spec.Claims.Groups)
+			flags.oidc.usernameClaim = auth.Spec.Claims.Username
+			flags.oidc.groupsClaim = auth.Spec.Claims.Groups
+		}
+
 		// If the --oidc-ca-bundle flags was not set explicitly, default it to the
 		// spec.tls.certificateAuthorityData field of the JWTAuthenticator.
 		if len(flags.oidc.caBundle) == 0 && auth.Spec.TLS != nil && auth.Spec.TLS.CertificateAuthorityData != "" {
@@ -412,13 +1080,16 @@ func discoverAuthenticatorParams(authenticator metav1.Object, flags *getKubeconf
 				return fmt.Errorf("tried to autodiscover --oidc-ca-bundle, but JWTAuthenticator %s has invalid spec.tls.certificateAuthorityData: %w", auth.Name, err)
 			}
 			log.Info("discovered OIDC CA bundle", "roots", countCACerts(decoded))
+			log.V(1).Info("discovered OIDC CA subjects", "subjects", caSubjects(decoded))
 			flags.oidc.caBundle = decoded
 		}
 	}
 	return nil
 }
 
-func getConciergeFrontend(credentialIssuer *configv1alpha1.CredentialIssuer, mode conciergeModeFlag) (*configv1alpha1.CredentialIssuerFrontend, error) {
+func getConciergeFrontend(credentialIssuer *configv1alpha1.CredentialIssuer, mode conciergeModeFlag, frontendType configv1alpha1.FrontendType, impersonationEndpoint string, maxStrategyAge time.Duration, log logr.Logger) (*configv1alpha1.CredentialIssuerFrontend, error) {
+	var candidates []*configv1alpha1.CredentialIssuerFrontend
+	staleStrategies := 0
 	for _, strategy := range credentialIssuer.Status.Strategies {
 		// Skip unhealthy strategies.
 		if strategy.Status != configv1alpha1.SuccessStrategyStatus {
@@ -452,17 +1123,97 @@ func getConciergeFrontend(credentialIssuer *configv1alpha1.CredentialIssuer, mod
 		if !mode.MatchesFrontend(strategy.Frontend) {
 			continue
 		}
-		return strategy.Frontend, nil
+		// Skip strategies that don't match --concierge-frontend-type.
+		if frontendType != "" && strategy.Frontend.Type != frontendType {
+			continue
+		}
+		// Skip malformed impersonation proxy strategies, since there would be no endpoint to connect to.
+		if strategy.Frontend.Type == configv1alpha1.ImpersonationProxyFrontendType && impersonationProxyEndpoint(strategy.Frontend) == "" {
+			log.Info("warning: ignoring Concierge impersonation proxy strategy with no endpoint", "type", strategy.Type)
+			continue
+		}
+		// Skip strategies which have not been updated recently enough, since their controller may have died.
+		if maxStrategyAge > 0 && time.Since(strategy.LastUpdateTime.Time) > maxStrategyAge {
+			log.Info("ignoring stale Concierge strategy",
+				"type", strategy.Type,
+				"lastUpdateTime", strategy.LastUpdateTime.UTC().Format(time.RFC3339),
+			)
+			staleStrategies++
+			continue
+		}
+		candidates = append(candidates, strategy.Frontend)
 	}
 
+	if len(candidates) == 0 && staleStrategies > 0 {
+		return nil, fmt.Errorf("could not find successful Concierge strategy updated within --concierge-max-strategy-age=%s", maxStrategyAge.String())
+	}
+
+	// If --concierge-impersonation-endpoint was set, only the impersonation proxy with that endpoint will do.
+	if impersonationEndpoint != "" {
+		for _, candidate := range candidates {
+			if candidate.Type == configv1alpha1.ImpersonationProxyFrontendType && impersonationProxyEndpoint(candidate) == impersonationEndpoint {
+				return candidate, nil
+			}
+		}
+		return nil, fmt.Errorf("could not find successful Concierge impersonation proxy strategy matching --concierge-impersonation-endpoint=%s", impersonationEndpoint)
+	}
+
+	// Otherwise the first matching strategy wins, but let the user know about any other impersonation proxies.
+	if len(candidates) > 0 {
+		chosen := candidates[0]
+		if chosen.Type == configv1alpha1.ImpersonationProxyFrontendType {
+			var alternatives []string
+			for _, candidate := range candidates[1:] {
+				if candidate.Type == configv1alpha1.ImpersonationProxyFrontendType && impersonationProxyEndpoint(candidate) != impersonationProxyEndpoint(chosen) {
+					alternatives = append(alternatives, impersonationProxyEndpoint(candidate))
+				}
+			}
+			if len(alternatives) > 0 {
+				log.Info("discovered multiple impersonation proxy endpoints, using the first one (use --concierge-impersonation-endpoint to choose another)",
+					"endpoint", impersonationProxyEndpoint(chosen),
+					"alternatives", alternatives,
+				)
+			}
+		}
+		return chosen, nil
+	}
+
+	if frontendType != "" {
+		return nil, fmt.Errorf("could not find successful Concierge strategy matching --concierge-frontend-type=%s", frontendType)
+	}
 	if mode == modeUnknown {
-		return nil, fmt.Errorf("could not autodiscover --concierge-mode")
+		return nil, fmt.Errorf("could not autodiscover --concierge-mode, only saw frontends: %v", describeFrontends(credentialIssuer.Status.Strategies))
 	}
 	return nil, fmt.Errorf("could not find successful Concierge strategy matching --concierge-mode=%s", mode.String())
 }
 
+// impersonationProxyEndpoint returns the endpoint of an impersonation proxy frontend, or "" if it has none.
+func impersonationProxyEndpoint(frontend *configv1alpha1.CredentialIssuerFrontend) string {
+	if frontend.ImpersonationProxyInfo == nil {
+		return ""
+	}
+	return frontend.ImpersonationProxyInfo.Endpoint
+}
+
+// describeFrontends returns a sorted list of "<frontend type>=<strategy status>" descriptions of the strategies
+// which have a frontend, for use in error messages.
+func describeFrontends(strategies []configv1alpha1.CredentialIssuerStrategy) []string {
+	descriptions := make([]string, 0, len(strategies))
+	for _, strategy := range strategies {
+		if strategy.Frontend == nil {
+			continue
+		}
+		descriptions = append(descriptions, fmt.Sprintf("%s=%s", strategy.Frontend.Type, strategy.Status))
+	}
+	sort.Strings(descriptions)
+	return descriptions
+}
+
+// generatedKubeconfigName is the name of the cluster, user, and context entries in a generated kubeconfig.
+const generatedKubeconfigName = "pinniped"
+
 func newExecKubeconfig(cluster *clientcmdapi.Cluster, execConfig *clientcmdapi.ExecConfig) clientcmdapi.Config {
-	const name = "pinniped"
+	const name = generatedKubeconfigName
 	return clientcmdapi.Config{
 		Kind:           "Config",
 		APIVersion:     clientcmdapi.SchemeGroupVersion.Version,
@@ -473,8 +1224,20 @@ func newExecKubeconfig(cluster *clientcmdapi.Cluster, execConfig *clientcmdapi.E
 	}
 }
 
-func lookupCredentialIssuer(clientset conciergeclientset.Interface, name string, log logr.Logger) (*configv1alpha1.CredentialIssuer, error) {
-	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*20)
+func newTokenKubeconfig(cluster *clientcmdapi.Cluster, token string) clientcmdapi.Config {
+	const name = generatedKubeconfigName
+	return clientcmdapi.Config{
+		Kind:           "Config",
+		APIVersion:     clientcmdapi.SchemeGroupVersion.Version,
+		Clusters:       map[string]*clientcmdapi.Cluster{name: cluster},
+		AuthInfos:      map[string]*clientcmdapi.AuthInfo{name: {Token: token}},
+		Contexts:       map[string]*clientcmdapi.Context{name: {Cluster: name, AuthInfo: name}},
+		CurrentContext: name,
+	}
+}
+
+func lookupCredentialIssuer(ctx context.Context, clientset conciergeclientset.Interface, name string, namePattern *regexp.Regexp, log logr.Logger) (*configv1alpha1.CredentialIssuer, error) {
+	ctx, cancelFunc := context.WithTimeout(ctx, time.Second*20)
 	defer cancelFunc()
 
 	// If the name is specified, get that object.
@@ -483,10 +1246,37 @@ func lookupCredentialIssuer(clientset conciergeclientset.Interface, name string,
 	}
 
 	// Otherwise list all the available CredentialIssuers and hope there's just a single one
-	results, err := clientset.ConfigV1alpha1().CredentialIssuers().List(ctx, metav1.ListOptions{})
+	var results *configv1alpha1.CredentialIssuerList
+	err := retryTransientListErrors(ctx, "CredentialIssuer", log, func() (err error) {
+		results, err = clientset.ConfigV1alpha1().CredentialIssuers().List(ctx, metav1.ListOptions{})
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list CredentialIssuer objects for autodiscovery: %w", err)
 	}
+
+	// If a name pattern is specified, there must be exactly one CredentialIssuer with a matching name.
+	if namePattern != nil {
+		var matches []configv1alpha1.CredentialIssuer
+		for _, item := range results.Items {
+			if namePattern.MatchString(item.Name) {
+				matches = append(matches, item)
+			}
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no CredentialIssuers were found matching --concierge-credential-issuer-pattern %q", namePattern.String())
+		}
+		if len(matches) > 1 {
+			matchNames := make([]string, 0, len(matches))
+			for _, match := range matches {
+				matchNames = append(matchNames, match.Name)
+			}
+			sort.Strings(matchNames)
+			return nil, fmt.Errorf("multiple CredentialIssuers were found matching --concierge-credential-issuer-pattern %q: %s", namePattern.String(), strings.Join(matchNames, ", "))
+		}
+		results.Items = matches
+	}
+
 	if len(results.Items) == 0 {
 		return nil, fmt.Errorf("no CredentialIssuers were found")
 	}
@@ -499,8 +1289,8 @@ func lookupCredentialIssuer(clientset conciergeclientset.Interface, name string,
 	return result, nil
 }
 
-func lookupAuthenticator(clientset conciergeclientset.Interface, authType, authName string, log logr.Logger) (metav1.Object, error) {
-	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*20)
+func lookupAuthenticator(ctx context.Context, clientset conciergeclientset.Interface, authType, authName, authNamePrefix, preferredAuthType string, log logr.Logger) (metav1.Object, error) {
+	ctx, cancelFunc := context.WithTimeout(ctx, time.Second*20)
 	defer cancelFunc()
 
 	// If one was specified, look it up or error.
@@ -515,39 +1305,168 @@ func lookupAuthenticator(clientset conciergeclientset.Interface, authType, authN
 		}
 	}
 
-	// Otherwise list all the available authenticators and hope there's just a single one.
+	// Otherwise list all the available authenticators and hope there's just a single one. When only the name was
+	// specified, have the server filter by name so that we do not fetch every authenticator in the cluster.
+	listOptions := metav1.ListOptions{}
+	if authName != "" {
+		listOptions.FieldSelector = fields.OneTermEqualSelector("metadata.name", authName).String()
+	}
 
-	jwtAuths, err := clientset.AuthenticationV1alpha1().JWTAuthenticators().List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list JWTAuthenticator objects for autodiscovery: %w", err)
+	// The Concierge can be installed with only one of the authenticator CRDs, or with RBAC that only grants access
+	// to one of them, so treat a NotFound or Forbidden error on one kind as if there were zero of that kind.
+	var jwtAuths *conciergev1alpha1.JWTAuthenticatorList
+	jwtErr := retryTransientListErrors(ctx, "JWTAuthenticator", log, func() (err error) {
+		jwtAuths, err = clientset.AuthenticationV1alpha1().JWTAuthenticators().List(ctx, listOptions)
+		return err
+	})
+	if jwtErr != nil {
+		if !isUnavailableAuthenticatorKind(jwtErr) {
+			return nil, fmt.Errorf("failed to list JWTAuthenticator objects for autodiscovery: %w", jwtErr)
+		}
+		log.Error(jwtErr, "could not list JWTAuthenticator objects for autodiscovery, assuming there are none")
+		jwtAuths = &conciergev1alpha1.JWTAuthenticatorList{}
 	}
-	webhooks, err := clientset.AuthenticationV1alpha1().WebhookAuthenticators().List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list WebhookAuthenticator objects for autodiscovery: %w", err)
+	var webhooks *conciergev1alpha1.WebhookAuthenticatorList
+	webhookErr := retryTransientListErrors(ctx, "WebhookAuthenticator", log, func() (err error) {
+		webhooks, err = clientset.AuthenticationV1alpha1().WebhookAuthenticators().List(ctx, listOptions)
+		return err
+	})
+	if webhookErr != nil {
+		if !isUnavailableAuthenticatorKind(webhookErr) {
+			return nil, fmt.Errorf("failed to list WebhookAuthenticator objects for autodiscovery: %w", webhookErr)
+		}
+		log.Error(webhookErr, "could not list WebhookAuthenticator objects for autodiscovery, assuming there are none")
+		webhooks = &conciergev1alpha1.WebhookAuthenticatorList{}
+	}
+	if jwtErr != nil && webhookErr != nil {
+		// Neither kind could be listed, so there is nothing to fall back to.
+		return nil, fmt.Errorf("failed to list JWTAuthenticator objects for autodiscovery: %w", jwtErr)
 	}
 
+	// Sort the results by name so that the output below is stable regardless of the order returned by the API.
+	sort.Slice(jwtAuths.Items, func(i, j int) bool { return jwtAuths.Items[i].Name < jwtAuths.Items[j].Name })
+	sort.Slice(webhooks.Items, func(i, j int) bool { return webhooks.Items[i].Name < webhooks.Items[j].Name })
+
+	// Narrow the results down to the authenticators whose names share the --concierge-authenticator-name-prefix.
+	// The name is checked again here in case the server did not honor the field selector.
+	nameMatches := func(name string) bool {
+		return strings.HasPrefix(name, authNamePrefix) && (authName == "" || name == authName)
+	}
 	results := make([]metav1.Object, 0, len(jwtAuths.Items)+len(webhooks.Items))
 	for i := range jwtAuths.Items {
-		results = append(results, &jwtAuths.Items[i])
+		if nameMatches(jwtAuths.Items[i].Name) {
+			results = append(results, &jwtAuths.Items[i])
+		}
 	}
 	for i := range webhooks.Items {
-		results = append(results, &webhooks.Items[i])
+		if nameMatches(webhooks.Items[i].Name) {
+			results = append(results, &webhooks.Items[i])
+		}
 	}
 	if len(results) == 0 {
+		if authName != "" {
+			return nil, fmt.Errorf("no authenticators were found with the name %q", authName)
+		}
+		if authNamePrefix != "" {
+			return nil, fmt.Errorf("no authenticators were found with the name prefix %q", authNamePrefix)
+		}
 		return nil, fmt.Errorf("no authenticators were found")
 	}
+	if preferred := preferAuthenticatorType(results, preferredAuthType); preferred != nil {
+		return preferred, nil
+	}
 	if len(results) > 1 {
-		for _, jwtAuth := range jwtAuths.Items {
-			log.Info("found JWTAuthenticator", "name", jwtAuth.Name)
+		for _, result := range results {
+			switch result.(type) {
+			case *conciergev1alpha1.JWTAuthenticator:
+				log.Info("found JWTAuthenticator", "name", result.GetName())
+			case *conciergev1alpha1.WebhookAuthenticator:
+				log.Info("found WebhookAuthenticator", "name", result.GetName())
+			}
+		}
+		if authName != "" {
+			return nil, fmt.Errorf("multiple authenticators were found with the name %q, so the --concierge-authenticator-type flag must be specified", authName)
 		}
-		for _, webhook := range webhooks.Items {
-			log.Info("found WebhookAuthenticator", "name", webhook.Name)
+		if authNamePrefix != "" {
+			return nil, fmt.Errorf("multiple authenticators were found with the name prefix %q, so the --concierge-authenticator-type/--concierge-authenticator-name flags must be specified", authNamePrefix)
 		}
 		return nil, fmt.Errorf("multiple authenticators were found, so the --concierge-authenticator-type/--concierge-authenticator-name flags must be specified")
 	}
 	return results[0], nil
 }
 
+// autodiscoveryListBackoff controls how autodiscovery List calls are retried after transient errors.
+//nolint: gochecknoglobals
+var autodiscoveryListBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 250 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+}
+
+// retryTransientListErrors calls list until it succeeds, until it fails with an error which is not transient, until
+// the steps of autodiscoveryListBackoff are used up, or until ctx is done. It returns the last error from list.
+func retryTransientListErrors(ctx context.Context, kind string, log logr.Logger, list func() error) error {
+	backoff := autodiscoveryListBackoff
+	for {
+		err := list()
+		if err == nil || !isTransientListError(err) || backoff.Steps <= 1 {
+			return err
+		}
+		log.Error(err, "transient error while listing objects for autodiscovery, retrying", "kind", kind)
+		timer := time.NewTimer(backoff.Step())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// isTransientListError returns true for errors which are likely to go away on their own, e.g. while the API server
+// or an aggregated API is being restarted.
+func isTransientListError(err error) bool {
+	return apierrors.IsInternalError(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		utilnet.IsConnectionRefused(err) ||
+		utilnet.IsConnectionReset(err) ||
+		utilnet.IsProbableEOF(err)
+}
+
+// isUnavailableAuthenticatorKind returns true when a list error means that the authenticator kind is not installed
+// or is not visible to the current user, as opposed to a genuine failure to list.
+func isUnavailableAuthenticatorKind(err error) bool {
+	return apierrors.IsNotFound(err) || apierrors.IsForbidden(err)
+}
+
+// preferAuthenticatorType returns the authenticator of the preferred type when the authenticators are exactly one
+// JWTAuthenticator and one WebhookAuthenticator, or nil otherwise.
+func preferAuthenticatorType(authenticators []metav1.Object, preferredAuthType string) metav1.Object {
+	if preferredAuthType == "" || len(authenticators) != 2 {
+		return nil
+	}
+	var jwtAuth, webhook metav1.Object
+	for _, authenticator := range authenticators {
+		switch authenticator.(type) {
+		case *conciergev1alpha1.JWTAuthenticator:
+			jwtAuth = authenticator
+		case *conciergev1alpha1.WebhookAuthenticator:
+			webhook = authenticator
+		}
+	}
+	if jwtAuth == nil || webhook == nil {
+		return nil
+	}
+	if strings.ToLower(preferredAuthType) == "jwt" {
+		return jwtAuth
+	}
+	return webhook
+}
+
 func writeConfigAsYAML(out io.Writer, config clientcmdapi.Config) error {
 	output, err := clientcmd.Write(config)
 	if err != nil {
@@ -560,6 +1479,58 @@ func writeConfigAsYAML(out io.Writer, config clientcmdapi.Config) error {
 	return nil
 }
 
+// purgeKubeconfig removes the entries generated by `pinniped get kubeconfig` from the kubeconfig file at the
+// --kubeconfig path, rewriting that file in place.
+func purgeKubeconfig(flags KubeconfigParams, deps KubeconfigDeps) error {
+	path := flags.kubeconfigPath
+	if path == "" {
+		path = clientcmd.RecommendedHomeFile
+	}
+	// It would be ambiguous which of several merged files should be rewritten.
+	if len(splitKubeconfigPaths(path)) > 1 {
+		return fmt.Errorf("--purge requires a single --kubeconfig path, but got %q", path)
+	}
+	kubeconfig, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		return fmt.Errorf("could not load --kubeconfig: %w", err)
+	}
+	if !removeGeneratedKubeconfigEntries(kubeconfig) {
+		deps.log.Info("no Pinniped entries found in kubeconfig", "path", path)
+		return nil
+	}
+	if err := clientcmd.WriteToFile(*kubeconfig, path); err != nil {
+		return fmt.Errorf("could not write --kubeconfig: %w", err)
+	}
+	deps.log.Info("removed Pinniped entries from kubeconfig", "path", path)
+	return nil
+}
+
+// removeGeneratedKubeconfigEntries deletes the generated cluster, user, and context entries, along with any other
+// contexts which referred to them, and clears the current-context if it was removed. It returns whether anything
+// was removed.
+func removeGeneratedKubeconfigEntries(kubeconfig *clientcmdapi.Config) bool {
+	const name = generatedKubeconfigName
+	removed := false
+	if _, ok := kubeconfig.Clusters[name]; ok {
+		delete(kubeconfig.Clusters, name)
+		removed = true
+	}
+	if _, ok := kubeconfig.AuthInfos[name]; ok {
+		delete(kubeconfig.AuthInfos, name)
+		removed = true
+	}
+	for contextName, kubeContext := range kubeconfig.Contexts {
+		if contextName == name || kubeContext.Cluster == name || kubeContext.AuthInfo == name {
+			delete(kubeconfig.Contexts, contextName)
+			if contextName == kubeconfig.CurrentContext {
+				kubeconfig.CurrentContext = ""
+			}
+			removed = true
+		}
+	}
+	return removed
+}
+
 func copyCurrentClusterFromExistingKubeConfig(currentKubeConfig clientcmdapi.Config, currentContextNameOverride string) (*clientcmdapi.Cluster, error) {
 	contextName := currentKubeConfig.CurrentContext
 	if currentContextNameOverride != "" {
@@ -567,12 +1538,66 @@ func copyCurrentClusterFromExistingKubeConfig(currentKubeConfig clientcmdapi.Con
 	}
 	ctx := currentKubeConfig.Contexts[contextName]
 	if ctx == nil {
-		return nil, fmt.Errorf("no such context %q", contextName)
+		return nil, noSuchContextError(contextName, currentKubeConfig.Contexts)
 	}
 	return currentKubeConfig.Clusters[ctx.Cluster], nil
 }
 
-func validateKubeconfig(ctx context.Context, flags getKubeconfigParams, kubeconfig clientcmdapi.Config, log logr.Logger) error {
+// noSuchContextError describes a missing kubeconfig context, suggesting the most similar existing context name
+// and listing all of them. Ties are broken alphabetically so that the suggestion is stable.
+func noSuchContextError(contextName string, contexts map[string]*clientcmdapi.Context) error {
+	if len(contexts) == 0 {
+		return fmt.Errorf("no such context %q", contextName)
+	}
+	available := make([]string, 0, len(contexts))
+	for name := range contexts {
+		available = append(available, name)
+	}
+	sort.Strings(available)
+
+	closest, closestDistance := available[0], levenshteinDistance(contextName, available[0])
+	for _, name := range available[1:] {
+		if distance := levenshteinDistance(contextName, name); distance < closestDistance {
+			closest, closestDistance = name, distance
+		}
+	}
+	return fmt.Errorf("no such context %q, did you mean %q? (available contexts: %s)", contextName, closest, strings.Join(available, ", "))
+}
+
+// levenshteinDistance returns the minimum number of single character insertions, deletions, or substitutions
+// required to turn a into b.
+func levenshteinDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+func minInt(first int, rest ...int) int {
+	result := first
+	for _, i := range rest {
+		if i < result {
+			result = i
+		}
+	}
+	return result
+}
+
+func validateKubeconfig(ctx context.Context, flags KubeconfigParams, kubeconfig clientcmdapi.Config, log logr.Logger) error {
 	if flags.skipValidate {
 		return nil
 	}
@@ -586,9 +1611,48 @@ func validateKubeconfig(ctx context.Context, flags getKubeconfigParams, kubeconf
 		return fmt.Errorf("invalid kubeconfig (no cluster)")
 	}
 
+	diagnostics := validationDiagnostics{
+		server:        cluster.Server,
+		caRoots:       countCACerts(cluster.CertificateAuthorityData),
+		authenticator: describeAuthenticator(flags.concierge),
+	}
+	fail := func(err, cause error) error {
+		diagnostics.cause = cause
+		return &validationError{err: err, diagnostics: diagnostics}
+	}
+
 	kubeconfigCA := x509.NewCertPool()
 	if !kubeconfigCA.AppendCertsFromPEM(cluster.CertificateAuthorityData) {
-		return fmt.Errorf("invalid kubeconfig (no certificateAuthorityData)")
+		err := fmt.Errorf("invalid kubeconfig (no certificateAuthorityData)")
+		return fail(err, err)
+	}
+
+	// In impersonation proxy mode, fail fast if the endpoint does not present a certificate signed by the
+	// discovered CA, since waiting will not fix that. Other errors fall through to the retries below.
+	if flags.concierge.mode == modeImpersonationProxy {
+		err := dialImpersonationProxy(ctx, cluster.Server, kubeconfigCA)
+		if certErr := certificateError(err); certErr != nil {
+			return fail(fmt.Errorf("could not verify the TLS certificate of the impersonation proxy at %s: %w", cluster.Server, certErr), certErr)
+		}
+		if err == nil {
+			log.Info("validated TLS connection to the impersonation proxy", "endpoint", cluster.Server)
+		}
+	}
+
+	// Connect the same way that kubectl will, i.e. through the cluster's proxy-url if it has one.
+	proxy := http.ProxyFromEnvironment
+	if cluster.ProxyURL != "" {
+		proxyURL, err := url.Parse(cluster.ProxyURL)
+		if err != nil {
+			err = fmt.Errorf("invalid kubeconfig (invalid proxy-url): %w", err)
+			return fail(err, err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	requestTimeout := 10 * time.Second
+	if flags.requestTimeout > 0 {
+		requestTimeout = flags.requestTimeout
 	}
 
 	httpClient := &http.Client{
@@ -597,64 +1661,309 @@ func validateKubeconfig(ctx context.Context, flags getKubeconfigParams, kubeconf
 				MinVersion: tls.VersionTLS12,
 				RootCAs:    kubeconfigCA,
 			},
-			Proxy:               http.ProxyFromEnvironment,
+			Proxy:               proxy,
 			TLSHandshakeTimeout: 10 * time.Second,
 		},
-		Timeout: 10 * time.Second,
+		Timeout: requestTimeout,
+	}
+	validateURL := cluster.Server
+
+	// Only the validation request impersonates, the generated kubeconfig never does. Impersonation requires an
+	// authenticated request, so use the credentials of the --kubeconfig user, and ask for the discovery endpoint,
+	// which any authenticated user may read.
+	if flags.validateAsUser != "" {
+		impersonatingClient, err := newImpersonatingValidationClient(flags, cluster, requestTimeout)
+		if err != nil {
+			err = fmt.Errorf("could not configure validation as user %q: %w", flags.validateAsUser, err)
+			return fail(err, err)
+		}
+		httpClient = impersonatingClient
+		validateURL = strings.TrimSuffix(cluster.Server, "/") + "/api"
 	}
 
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
-	pingCluster := func() error {
-		reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	// pingCluster returns permanent=true when retrying cannot help, i.e. when the impersonated request was rejected.
+	pingCluster := func() (permanent bool, err error) {
+		reqCtx, cancel := context.WithTimeout(ctx, requestTimeout)
 		defer cancel()
-		req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, cluster.Server, nil)
+		req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, validateURL, nil)
 		if err != nil {
-			return fmt.Errorf("could not form request to validate cluster: %w", err)
+			return false, fmt.Errorf("could not form request to validate cluster: %w", err)
 		}
 		resp, err := httpClient.Do(req)
 		if err != nil {
-			return err
+			return false, err
 		}
 		_ = resp.Body.Close()
+		if flags.validateAsUser != "" && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+			return true, fmt.Errorf("the cluster rejected the request as user %q with status code %d", flags.validateAsUser, resp.StatusCode)
+		}
 		if resp.StatusCode >= 500 {
-			return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+			return false, fmt.Errorf("unexpected status code %d", resp.StatusCode)
 		}
-		return nil
+		return false, nil
 	}
 
-	err := pingCluster()
+	permanent, err := pingCluster()
 	if err == nil {
 		log.Info("validated connection to the cluster")
 		return nil
 	}
+	if permanent {
+		return fail(fmt.Errorf("could not validate the kubeconfig: %w", err), err)
+	}
 
 	log.Info("could not immediately connect to the cluster but it may be initializing, will retry until timeout")
 	deadline, _ := ctx.Deadline()
 	attempts := 0
+	lastErr := err
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return fail(ctx.Err(), lastErr)
 		case <-ticker.C:
 			attempts++
-			err := pingCluster()
+			permanent, err := pingCluster()
+			lastErr = err
 			if err == nil {
 				log.Info("validated connection to the cluster", "attempts", attempts)
 				return nil
 			}
+			if permanent {
+				return fail(fmt.Errorf("could not validate the kubeconfig: %w", err), err)
+			}
 			log.Error(err, "could not connect to cluster, retrying...", "attempts", attempts, "remaining", time.Until(deadline).Round(time.Second).String())
 		}
 	}
 }
 
+// newImpersonatingValidationClient returns an http.Client which sends requests with the credentials of the --kubeconfig
+// user, impersonating --validate-as-user and --validate-as-group. Those credentials are only ever sent to the cluster
+// of the --kubeconfig itself, using its own TLS settings, so an error is returned when the generated kubeconfig points
+// at a different server (e.g. the impersonation proxy).
+func newImpersonatingValidationClient(flags KubeconfigParams, cluster *clientcmdapi.Cluster, requestTimeout time.Duration) (*http.Client, error) {
+	restConfig, err := newClientConfigWithRequestTimeout(flags.kubeconfigPath, flags.kubeconfigContextOverride, flags.requestTimeout).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(restConfig.Host, "/") != strings.TrimSuffix(cluster.Server, "/") {
+		return nil, fmt.Errorf("the generated kubeconfig uses server %q, but impersonated validation is only supported for the server of the --kubeconfig (%q)", cluster.Server, restConfig.Host)
+	}
+	restConfig.Impersonate = rest.ImpersonationConfig{
+		UserName: flags.validateAsUser,
+		Groups:   flags.validateAsGroups,
+	}
+	roundTripper, err := rest.TransportFor(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: roundTripper, Timeout: requestTimeout}, nil
+}
+
+// validationError is returned by validateKubeconfig when the generated kubeconfig could not be validated. It carries
+// the details of the attempted connection so that they can be printed when --validation-diagnostics is set.
+type validationError struct {
+	err         error
+	diagnostics validationDiagnostics
+}
+
+func (e *validationError) Error() string { return e.err.Error() }
+func (e *validationError) Unwrap() error { return e.err }
+
+// validationDiagnostics describes a failed attempt to validate a generated kubeconfig.
+type validationDiagnostics struct {
+	server        string
+	caRoots       int
+	authenticator string
+	cause         error
+}
+
+func (d validationDiagnostics) render(w io.Writer) {
+	_, _ = fmt.Fprint(w, here.Docf(`
+		Kubeconfig validation diagnostics:
+		  server: %s
+		  certificate authority roots: %d
+		  authenticator: %s
+		  cause: %v
+	`, d.server, d.caRoots, d.authenticator, d.cause))
+}
+
+// describeAuthenticator returns a short description of the Concierge authenticator used by the generated kubeconfig.
+func describeAuthenticator(flags getKubeconfigConciergeParams) string {
+	if flags.disabled {
+		return "none (Concierge disabled)"
+	}
+	return fmt.Sprintf("%s/%s", strings.ToLower(flags.authenticatorType), flags.authenticatorName)
+}
+
+// validateHTTPSURL returns an error unless the value of the flag is either empty or an absolute https URL with a host.
+func validateHTTPSURL(flagName, value string) error {
+	if value == "" {
+		return nil
+	}
+	if !isAbsoluteHTTPSURL(value) {
+		return fmt.Errorf("invalid %s %q: must be an absolute https URL", flagName, value)
+	}
+	return nil
+}
+
+func isAbsoluteHTTPSURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && parsed.IsAbs() && parsed.Scheme == "https" && parsed.Host != ""
+}
+
+// validateProxyURL returns an error unless the value of the flag is either empty or an absolute http, https,
+// or socks5 URL with a host, which are the proxy schemes supported by client-go.
+func validateProxyURL(flagName, value string) error {
+	if value == "" {
+		return nil
+	}
+	if parsed, err := url.Parse(value); err == nil && parsed.Host != "" {
+		switch parsed.Scheme {
+		case "http", "https", "socks5":
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid %s %q: must be an absolute http, https, or socks5 URL", flagName, value)
+}
+
+// dialImpersonationProxy makes a TLS connection to the endpoint, verifying its certificate against the provided CA.
+func dialImpersonationProxy(ctx context.Context, endpoint string, ca *x509.CertPool) error {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+	port := endpointURL.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	dialer := tls.Dialer{Config: &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    ca,
+		ServerName: endpointURL.Hostname(),
+	}}
+	conn, err := dialer.DialContext(dialCtx, "tcp", net.JoinHostPort(endpointURL.Hostname(), port))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// certificateError returns the x509 certificate verification error wrapped by err, or nil if there is none.
+func certificateError(err error) error {
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	if errors.As(err, &unknownAuthorityErr) {
+		return unknownAuthorityErr
+	}
+	var hostnameErr x509.HostnameError
+	if errors.As(err, &hostnameErr) {
+		return hostnameErr
+	}
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &invalidErr) {
+		return invalidErr
+	}
+	return nil
+}
+
+// parseExecEnv converts the KEY=VALUE entries of --exec-env into exec env vars, preserving their order.
+func parseExecEnv(entries []string) ([]clientcmdapi.ExecEnvVar, error) {
+	env := make([]clientcmdapi.ExecEnvVar, 0, len(entries))
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid --exec-env %q, expected KEY=VALUE", entry)
+		}
+		env = append(env, clientcmdapi.ExecEnvVar{Name: parts[0], Value: parts[1]})
+	}
+	return env, nil
+}
+
+// parseClusterExtensions parses the NAME=JSON entries of --cluster-extension into kubeconfig extensions.
+func parseClusterExtensions(entries []string) (map[string]runtime.Object, error) {
+	extensions := make(map[string]runtime.Object, len(entries))
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid --cluster-extension %q, expected NAME=JSON", entry)
+		}
+		if !json.Valid([]byte(parts[1])) {
+			return nil, fmt.Errorf("invalid --cluster-extension %q, value is not valid JSON", entry)
+		}
+		extensions[parts[0]] = &runtime.Unknown{Raw: []byte(parts[1]), ContentType: runtime.ContentTypeJSON}
+	}
+	return extensions, nil
+}
+
+// parseOutputSecretLabels parses the KEY=VALUE entries of --output-secret-label, validating them as Kubernetes labels.
+func parseOutputSecretLabels(entries []string) (map[string]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid --output-secret-label %q, expected KEY=VALUE", entry)
+		}
+		if errs := validation.IsQualifiedName(parts[0]); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid --output-secret-label %q: %s", entry, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(parts[1]); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid --output-secret-label %q: %s", entry, strings.Join(errs, "; "))
+		}
+		labels[parts[0]] = parts[1]
+	}
+	return labels, nil
+}
+
+// decodeCABundleData decodes the base64 encoded PEM value of the flag, validating it like caBundleFlag.Set does.
+func decodeCABundleData(flagName, data string) ([]byte, error) {
+	pemData, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", flagName, err)
+	}
+	if countCACerts(pemData) == 0 {
+		return nil, fmt.Errorf("failed to load any CA certificates from %s", flagName)
+	}
+	merged, err := pemutil.MergePEM(pemData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", flagName, err)
+	}
+	return merged, nil
+}
+
 func countCACerts(pemData []byte) int {
 	pool := x509.NewCertPool()
 	pool.AppendCertsFromPEM(pemData)
 	return len(pool.Subjects())
 }
 
+// caSubjects returns the subject DN of each parseable certificate in the PEM bundle, in bundle order.
+func caSubjects(pemData []byte) []string {
+	subjects := []string{}
+	for {
+		var block *pem.Block
+		block, pemData = pem.Decode(pemData)
+		if block == nil {
+			return subjects
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		subjects = append(subjects, cert.Subject.String())
+	}
+}
+
 func hasPendingStrategy(credentialIssuer *configv1alpha1.CredentialIssuer) bool {
 	for _, strategy := range credentialIssuer.Status.Strategies {
 		if strategy.Reason == configv1alpha1.PendingStrategyReason {
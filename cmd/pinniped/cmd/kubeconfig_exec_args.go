@@ -0,0 +1,207 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// loginExecArgs holds everything that ends up in the args of the `pinniped login` exec plugin of a generated
+// kubeconfig. Use buildLoginExecArgs to turn it into the ordered arg list.
+type loginExecArgs struct {
+	// When either of these is set, the kubeconfig runs `pinniped login static`, otherwise `pinniped login oidc`.
+	staticToken    string
+	staticTokenEnv string
+
+	// concierge is nil when the kubeconfig does not use the Concierge.
+	concierge *conciergeLoginArgs
+
+	// packConcierge packs the Concierge settings into a single --concierge-config arg.
+	packConcierge bool
+
+	// oidc is only used for `pinniped login oidc`.
+	oidc oidcLoginArgs
+}
+
+type conciergeLoginArgs struct {
+	apiGroupSuffix    string
+	authenticatorName string
+	authenticatorType string
+	endpoint          string
+	caBundle          []byte
+}
+
+type oidcLoginArgs struct {
+	issuer            string
+	clientID          string
+	scopes            []string
+	skipBrowser       bool
+	browserCommand    string
+	listenPort        uint16
+	redirectURIPath   string
+	caBundle          []byte
+	sessionCachePath  string
+	debugSessionCache bool
+	requestAudience   string
+	upstreamIDPName   string
+	upstreamIDPType   string
+	usernameClaim     string
+	groupsClaim       string
+}
+
+// buildLoginExecArgs returns the exec plugin args in a stable order: the login subcommand, then the Concierge
+// flags, then the flags of the login subcommand. Optional flags are omitted when they are unset.
+func buildLoginExecArgs(a loginExecArgs) ([]string, error) {
+	static := a.staticToken != "" || a.staticTokenEnv != ""
+
+	args := []string{"login", "oidc"}
+	if static {
+		args = []string{"login", "static"}
+	}
+
+	if a.concierge != nil && a.packConcierge {
+		packed, err := encodePackedConciergeConfig(a.concierge)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--concierge-config="+packed)
+	} else if a.concierge != nil {
+		args = append(args,
+			"--enable-concierge",
+			"--concierge-api-group-suffix="+a.concierge.apiGroupSuffix,
+			"--concierge-authenticator-name="+a.concierge.authenticatorName,
+			"--concierge-authenticator-type="+a.concierge.authenticatorType,
+			"--concierge-endpoint="+a.concierge.endpoint,
+			"--concierge-ca-bundle-data="+base64.StdEncoding.EncodeToString(a.concierge.caBundle),
+		)
+	}
+
+	if static {
+		if a.staticToken != "" {
+			args = append(args, "--token="+a.staticToken)
+		}
+		if a.staticTokenEnv != "" {
+			args = append(args, "--token-env="+a.staticTokenEnv)
+		}
+		return args, nil
+	}
+
+	args = append(args,
+		"--issuer="+a.oidc.issuer,
+		"--client-id="+a.oidc.clientID,
+		"--scopes="+strings.Join(a.oidc.scopes, ","),
+	)
+	if a.oidc.skipBrowser {
+		args = append(args, "--skip-browser")
+	}
+	if a.oidc.browserCommand != "" {
+		args = append(args, "--browser-command="+a.oidc.browserCommand)
+	}
+	if a.oidc.listenPort != 0 {
+		args = append(args, "--listen-port="+strconv.Itoa(int(a.oidc.listenPort)))
+	}
+	if a.oidc.redirectURIPath != "" {
+		args = append(args, "--redirect-uri-path="+a.oidc.redirectURIPath)
+	}
+	if len(a.oidc.caBundle) != 0 {
+		args = append(args, "--ca-bundle-data="+base64.StdEncoding.EncodeToString(a.oidc.caBundle))
+	}
+	if a.oidc.sessionCachePath != "" {
+		args = append(args, "--session-cache="+a.oidc.sessionCachePath)
+	}
+	if a.oidc.debugSessionCache {
+		args = append(args, "--debug-session-cache")
+	}
+	if a.oidc.requestAudience != "" {
+		args = append(args, "--request-audience="+a.oidc.requestAudience)
+	}
+	if a.oidc.upstreamIDPName != "" {
+		args = append(args, "--upstream-identity-provider-name="+a.oidc.upstreamIDPName)
+	}
+	if a.oidc.upstreamIDPType != "" {
+		args = append(args, "--upstream-identity-provider-type="+a.oidc.upstreamIDPType)
+	}
+	if a.oidc.usernameClaim != "" {
+		args = append(args, "--username-claim="+a.oidc.usernameClaim)
+	}
+	if a.oidc.groupsClaim != "" {
+		args = append(args, "--groups-claim="+a.oidc.groupsClaim)
+	}
+	return args, nil
+}
+
+// packedConciergeConfig is the JSON schema of the --concierge-config login flag. It carries the same settings as
+// the individual --concierge-* login flags, and its presence implies --enable-concierge.
+type packedConciergeConfig struct {
+	APIGroupSuffix    string `json:"apiGroupSuffix"`
+	AuthenticatorName string `json:"authenticatorName"`
+	AuthenticatorType string `json:"authenticatorType"`
+	Endpoint          string `json:"endpoint"`
+	// CABundleData is the base64 encoded PEM bundle, just like --concierge-ca-bundle-data.
+	CABundleData string `json:"caBundleData"`
+}
+
+// encodePackedConciergeConfig returns the base64 encoded JSON value of the --concierge-config login flag.
+func encodePackedConciergeConfig(c *conciergeLoginArgs) (string, error) {
+	data, err := json.Marshal(packedConciergeConfig{
+		APIGroupSuffix:    c.apiGroupSuffix,
+		AuthenticatorName: c.authenticatorName,
+		AuthenticatorType: c.authenticatorType,
+		Endpoint:          c.endpoint,
+		CABundleData:      base64.StdEncoding.EncodeToString(c.caBundle),
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not encode --concierge-config: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// decodePackedConciergeConfig parses the value of the --concierge-config login flag.
+func decodePackedConciergeConfig(value string) (*packedConciergeConfig, error) {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --concierge-config: %w", err)
+	}
+	var config packedConciergeConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid --concierge-config: %w", err)
+	}
+	return &config, nil
+}
+
+// conciergeLoginFlags are the Concierge flags shared by the `pinniped login` subcommands.
+type conciergeLoginFlags struct {
+	conciergeEnabled           bool
+	conciergeAuthenticatorType string
+	conciergeAuthenticatorName string
+	conciergeEndpoint          string
+	conciergeCABundle          string
+	conciergeAPIGroupSuffix    string
+	conciergeConfig            string
+}
+
+// applyPackedConciergeConfig replaces the individual Concierge flags with the settings packed into
+// --concierge-config, when it was set.
+func (f *conciergeLoginFlags) applyPackedConciergeConfig() error {
+	if f.conciergeConfig == "" {
+		return nil
+	}
+	packed, err := decodePackedConciergeConfig(f.conciergeConfig)
+	if err != nil {
+		return err
+	}
+	f.conciergeEnabled = true
+	f.conciergeAuthenticatorType = packed.AuthenticatorType
+	f.conciergeAuthenticatorName = packed.AuthenticatorName
+	f.conciergeEndpoint = packed.Endpoint
+	f.conciergeCABundle = packed.CABundleData
+	if packed.APIGroupSuffix != "" {
+		f.conciergeAPIGroupSuffix = packed.APIGroupSuffix
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildLoginExecArgs(t *testing.T) {
+	concierge := &conciergeLoginArgs{
+		apiGroupSuffix:    "pinniped.dev",
+		authenticatorName: "test-authenticator",
+		authenticatorType: "jwt",
+		endpoint:          "https://concierge.example.com",
+		caBundle:          []byte("test-ca"),
+	}
+	conciergeArgs := []string{
+		"--enable-concierge",
+		"--concierge-api-group-suffix=pinniped.dev",
+		"--concierge-authenticator-name=test-authenticator",
+		"--concierge-authenticator-type=jwt",
+		"--concierge-endpoint=https://concierge.example.com",
+		"--concierge-ca-bundle-data=dGVzdC1jYQ==",
+	}
+	// The base64 encoding of:
+	// {"apiGroupSuffix":"pinniped.dev","authenticatorName":"test-authenticator","authenticatorType":"jwt",
+	// "endpoint":"https://concierge.example.com","caBundleData":"dGVzdC1jYQ=="}
+	packedConciergeArg := "eyJhcGlHcm91cFN1ZmZpeCI6InBpbm5pcGVkLmRldiIsImF1dGhlbnRpY2F0b3JOYW1lIjoidGVzdC1hdXRoZW50aWNhdG9yIiwiYXV0aGVudGljYXRvclR5cGUiOiJqd3QiLCJlbmRwb2ludCI6Imh0dHBzOi8vY29uY2llcmdlLmV4YW1wbGUuY29tIiwiY2FCdW5kbGVEYXRhIjoiZEdWemRDMWpZUT09In0="
+	minimalOIDC := oidcLoginArgs{
+		issuer:   "https://issuer.example.com",
+		clientID: "pinniped-cli",
+		scopes:   []string{"openid", "offline_access"},
+	}
+	fullOIDC := oidcLoginArgs{
+		issuer:            "https://issuer.example.com",
+		clientID:          "pinniped-cli",
+		scopes:            []string{"openid"},
+		skipBrowser:       true,
+		browserCommand:    "firefox --new-window",
+		listenPort:        1234,
+		redirectURIPath:   "/callback",
+		caBundle:          []byte("test-oidc-ca"),
+		sessionCachePath:  "/path/to/sessions.yaml",
+		debugSessionCache: true,
+		requestAudience:   "aud-1",
+		upstreamIDPName:   "test-idp",
+		upstreamIDPType:   "ldap",
+		usernameClaim:     "email",
+		groupsClaim:       "roles",
+	}
+
+	tests := []struct {
+		name string
+		args loginExecArgs
+		want []string
+	}{
+		{
+			name: "oidc with minimal flags and --no-concierge",
+			args: loginExecArgs{oidc: minimalOIDC},
+			want: []string{
+				"login", "oidc",
+				"--issuer=https://issuer.example.com",
+				"--client-id=pinniped-cli",
+				"--scopes=openid,offline_access",
+			},
+		},
+		{
+			name: "oidc with every optional flag and the concierge",
+			args: loginExecArgs{concierge: concierge, oidc: fullOIDC},
+			want: append(append([]string{"login", "oidc"}, conciergeArgs...),
+				"--issuer=https://issuer.example.com",
+				"--client-id=pinniped-cli",
+				"--scopes=openid",
+				"--skip-browser",
+				"--browser-command=firefox --new-window",
+				"--listen-port=1234",
+				"--redirect-uri-path=/callback",
+				"--ca-bundle-data=dGVzdC1vaWRjLWNh",
+				"--session-cache=/path/to/sessions.yaml",
+				"--debug-session-cache",
+				"--request-audience=aud-1",
+				"--upstream-identity-provider-name=test-idp",
+				"--upstream-identity-provider-type=ldap",
+				"--username-claim=email",
+				"--groups-claim=roles",
+			),
+		},
+		{
+			name: "static token and --no-concierge ignores oidc settings",
+			args: loginExecArgs{staticToken: "test-token", oidc: fullOIDC},
+			want: []string{"login", "static", "--token=test-token"},
+		},
+		{
+			name: "static token env with the concierge",
+			args: loginExecArgs{staticTokenEnv: "TEST_TOKEN", concierge: concierge},
+			want: append(append([]string{"login", "static"}, conciergeArgs...), "--token-env=TEST_TOKEN"),
+		},
+		{
+			name: "oidc with the concierge packed into a single arg",
+			args: loginExecArgs{concierge: concierge, packConcierge: true, oidc: minimalOIDC},
+			want: []string{
+				"login", "oidc",
+				"--concierge-config=" + packedConciergeArg,
+				"--issuer=https://issuer.example.com",
+				"--client-id=pinniped-cli",
+				"--scopes=openid,offline_access",
+			},
+		},
+		{
+			name: "packing is ignored with --no-concierge",
+			args: loginExecArgs{staticToken: "test-token", packConcierge: true},
+			want: []string{"login", "static", "--token=test-token"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildLoginExecArgs(tt.args)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDecodePackedConciergeConfig(t *testing.T) {
+	concierge := &conciergeLoginArgs{
+		apiGroupSuffix:    "some.suffix.com",
+		authenticatorName: "test-authenticator",
+		authenticatorType: "webhook",
+		endpoint:          "https://concierge.example.com",
+		caBundle:          []byte("test-ca"),
+	}
+	got, err := decodePackedConciergeConfig(mustEncodePackedConciergeConfig(t, concierge))
+	require.NoError(t, err)
+	require.Equal(t, &packedConciergeConfig{
+		APIGroupSuffix:    "some.suffix.com",
+		AuthenticatorName: "test-authenticator",
+		AuthenticatorType: "webhook",
+		Endpoint:          "https://concierge.example.com",
+		CABundleData:      "dGVzdC1jYQ==",
+	}, got)
+
+	_, err = decodePackedConciergeConfig("invalid-base64")
+	require.EqualError(t, err, "invalid --concierge-config: illegal base64 data at input byte 7")
+
+	_, err = decodePackedConciergeConfig("bm90LWpzb24=") // "not-json"
+	require.EqualError(t, err, "invalid --concierge-config: invalid character 'o' in literal null (expecting 'u')")
+}
+
+func mustEncodePackedConciergeConfig(t *testing.T, c *conciergeLoginArgs) string {
+	t.Helper()
+	packed, err := encodePackedConciergeConfig(c)
+	require.NoError(t, err)
+	return packed
+}
@@ -5,23 +5,37 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-logr/stdr"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
 	kubetesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
 	conciergev1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/authentication/v1alpha1"
 	configv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
+	supervisorconfigv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
 	conciergeclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
 	fakeconciergeclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned/fake"
+	supervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned"
+	fakesupervisorclientset "go.pinniped.dev/generated/latest/client/supervisor/clientset/versioned/fake"
 	"go.pinniped.dev/internal/certauthority"
 	"go.pinniped.dev/internal/here"
 	"go.pinniped.dev/internal/testutil"
@@ -40,6 +54,50 @@ func TestGetKubeconfig(t *testing.T) {
 	testConciergeCABundlePath := filepath.Join(tmpdir, "testconciergeca.pem")
 	require.NoError(t, ioutil.WriteFile(testConciergeCABundlePath, testConciergeCA.Bundle(), 0600))
 
+	testOtherConciergeCA, err := certauthority.New("Test Other Concierge CA", 1*time.Hour)
+	require.NoError(t, err)
+	testOverlappingConciergeCABundlePath := filepath.Join(tmpdir, "testconciergeca-overlapping.pem")
+	require.NoError(t, ioutil.WriteFile(testOverlappingConciergeCABundlePath, append(testConciergeCA.Bundle(), testOtherConciergeCA.Bundle()...), 0600))
+
+	impersonationProxyCABundle, impersonationProxyURL := testutil.TLSTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	// requireListFieldSelector fails any list of authenticators which does not carry the expected field selector.
+	requireListFieldSelector := func(wantFieldSelector string) kubetesting.Reactor {
+		return &kubetesting.SimpleReactor{
+			Verb:     "list",
+			Resource: "*",
+			Reaction: func(action kubetesting.Action) (bool, runtime.Object, error) {
+				if action.GetResource().Resource == "credentialissuers" {
+					return false, nil, nil
+				}
+				if got := action.(kubetesting.ListAction).GetListRestrictions().Fields.String(); got != wantFieldSelector {
+					return true, nil, fmt.Errorf("unexpected field selector for %s: %q", action.GetResource().Resource, got)
+				}
+				return false, nil, nil
+			},
+		}
+	}
+	impersonationProxyCredentialIssuer := func(caBundle string) *configv1alpha1.CredentialIssuer {
+		return &configv1alpha1.CredentialIssuer{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+			Status: configv1alpha1.CredentialIssuerStatus{
+				Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+					Type:   configv1alpha1.ImpersonationProxyStrategyType,
+					Status: configv1alpha1.SuccessStrategyStatus,
+					Reason: configv1alpha1.ListeningStrategyReason,
+					Frontend: &configv1alpha1.CredentialIssuerFrontend{
+						Type: configv1alpha1.ImpersonationProxyFrontendType,
+						ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+							Endpoint:                 impersonationProxyURL,
+							CertificateAuthorityData: base64.StdEncoding.EncodeToString([]byte(caBundle)),
+						},
+					},
+				}},
+			},
+		}
+	}
+
 	tests := []struct {
 		name               string
 		args               []string
@@ -48,6 +106,7 @@ func TestGetKubeconfig(t *testing.T) {
 		getClientsetErr    error
 		conciergeObjects   []runtime.Object
 		conciergeReactions []kubetesting.Reactor
+		logVerbosity       int
 		wantLogs           []string
 		wantError          bool
 		wantStdout         string
@@ -65,31 +124,64 @@ func TestGetKubeconfig(t *testing.T) {
 				  kubeconfig [flags]
 
 				Flags:
-				      --concierge-api-group-suffix string     Concierge API group suffix (default "pinniped.dev")
-				      --concierge-authenticator-name string   Concierge authenticator name (default: autodiscover)
-				      --concierge-authenticator-type string   Concierge authenticator type (e.g., 'webhook', 'jwt') (default: autodiscover)
-				      --concierge-ca-bundle path              Path to TLS certificate authority bundle (PEM format, optional, can be repeated) to use when connecting to the Concierge
-				      --concierge-credential-issuer string    Concierge CredentialIssuer object to use for autodiscovery (default: autodiscover)
-				      --concierge-endpoint string             API base for the Concierge endpoint
-				      --concierge-mode mode                   Concierge mode of operation (default TokenCredentialRequestAPI)
-				      --concierge-skip-wait                   Skip waiting for any pending Concierge strategies to become ready (default: false)
-				  -h, --help                                  help for kubeconfig
-				      --kubeconfig string                     Path to kubeconfig file
-				      --kubeconfig-context string             Kubeconfig context name (default: current active context)
-				      --no-concierge                          Generate a configuration which does not use the Concierge, but sends the credential to the cluster directly
-				      --oidc-ca-bundle path                   Path to TLS certificate authority bundle (PEM format, optional, can be repeated)
-				      --oidc-client-id string                 OpenID Connect client ID (default: autodiscover) (default "pinniped-cli")
-				      --oidc-issuer string                    OpenID Connect issuer URL (default: autodiscover)
-				      --oidc-listen-port uint16               TCP port for localhost listener (authorization code flow only)
-				      --oidc-request-audience string          Request a token with an alternate audience using RFC8693 token exchange
-				      --oidc-scopes strings                   OpenID Connect scopes to request during login (default [offline_access,openid,pinniped:request-audience])
-				      --oidc-session-cache string             Path to OpenID Connect session cache file
-				      --oidc-skip-browser                     During OpenID Connect login, skip opening the browser (just print the URL)
-				  -o, --output string                         Output file path (default: stdout)
-				      --skip-validation                       Skip final validation of the kubeconfig (default: false)
-				      --static-token string                   Instead of doing an OIDC-based login, specify a static token
-				      --static-token-env string               Instead of doing an OIDC-based login, read a static token from the environment
-				      --timeout duration                      Timeout for autodiscovery and validation (default 10m0s)
+				      --ca-bundle path                               Path to TLS certificate authority bundle (PEM format, optional, can be repeated) to use for both the OpenID Connect issuer and the Concierge, unless overridden by --oidc-ca-bundle or --concierge-ca-bundle
+				      --cluster-ca-bundle path                       Path to TLS certificate authority bundle (PEM format, optional, can be repeated) to pin as the certificate-authority-data of the cluster in the generated kubeconfig, instead of the discovered CA
+				      --cluster-extension stringArray                Extension (NAME=JSON) to add to the cluster entry of the generated kubeconfig, e.g. to record provenance metadata (can be repeated)
+				      --cluster-proxy-url string                     URL of the HTTP or SOCKS5 proxy to set as the proxy-url of the cluster in the generated kubeconfig
+				      --concierge-api-group-suffix string            Concierge API group suffix (default "pinniped.dev")
+				      --concierge-authenticator-audience string      Audience to request for a JWTAuthenticator using RFC8693 token exchange, instead of the authenticator's spec.audience (default: autodiscover)
+				      --concierge-authenticator-name string          Concierge authenticator name (default: autodiscover)
+				      --concierge-authenticator-name-prefix string   Only autodiscover Concierge authenticators whose names start with this prefix
+				      --concierge-authenticator-type string          Concierge authenticator type (e.g., 'webhook', 'jwt') (default: autodiscover)
+				      --concierge-ca-bundle path                     Path to TLS certificate authority bundle (PEM format, optional, can be repeated) to use when connecting to the Concierge
+				      --concierge-ca-bundle-data string              Base64 encoded TLS certificate authority bundle (base64 encoded PEM format, optional) to use when connecting to the Concierge, instead of --concierge-ca-bundle
+				      --concierge-credential-issuer string           Concierge CredentialIssuer object to use for autodiscovery (default: autodiscover)
+				      --concierge-credential-issuer-pattern string   Regular expression which must match the name of exactly one Concierge CredentialIssuer object to use for autodiscovery
+				      --concierge-endpoint string                    API base for the Concierge endpoint
+				      --concierge-frontend-type string               Use the first successful CredentialIssuer strategy with this frontend type (e.g., 'TokenCredentialRequestAPI', 'ImpersonationProxy'), as an alternative to --concierge-mode
+				      --concierge-impersonation-endpoint string      When the Concierge advertises multiple impersonation proxy endpoints, use the one with this URL (default: the first one)
+				      --concierge-max-strategy-age duration          Ignore Concierge strategies which were last updated longer ago than this duration during autodiscovery (default: no limit)
+				      --concierge-mode mode                          Concierge mode of operation (default TokenCredentialRequestAPI)
+				      --concierge-packed-config                      Pass the Concierge settings to the login command as a single base64 encoded JSON --concierge-config arg, instead of one arg per setting (default: false)
+				      --concierge-prefer-authenticator-type string   When autodiscovery finds exactly one JWTAuthenticator and one WebhookAuthenticator, use the one of this type (e.g., 'webhook', 'jwt') instead of failing
+				      --concierge-skip-wait                          Skip waiting for any pending Concierge strategies to become ready (default: false)
+				      --exec-env stringArray                         Environment variable (KEY=VALUE) to set for the Pinniped login process in the generated kubeconfig (can be repeated)
+				      --fail-on-empty-ca                             Fail if the autodiscovered Concierge CA bundle is missing or does not contain any certificates, instead of only warning (default: false)
+				  -h, --help                                         help for kubeconfig
+				      --kubeconfig string                            Path to kubeconfig file, or a list of paths to merge like KUBECONFIG
+				      --kubeconfig-context string                    Kubeconfig context name (default: current active context)
+				      --no-concierge                                 Generate a configuration which does not use the Concierge, but sends the credential to the cluster directly
+				      --oidc-browser-command string                  During OpenID Connect login, open the URL with this command instead of the system browser (e.g., 'wslview')
+				      --oidc-ca-bundle path                          Path to TLS certificate authority bundle (PEM format, optional, can be repeated)
+				      --oidc-client-id string                        OpenID Connect client ID (default: autodiscover) (default "pinniped-cli")
+				      --oidc-issuer string                           OpenID Connect issuer URL (default: autodiscover)
+				      --oidc-issuer-no-normalize                     Do not trim a trailing slash from the OpenID Connect issuer URL (default: false)
+				      --oidc-listen-port uint16                      TCP port for localhost listener (authorization code flow only)
+				      --oidc-redirect-uri-path string                Path of the localhost redirect URI, whose port is set by --oidc-listen-port (authorization code flow only) (default: /callback)
+				      --oidc-request-audience string                 Request a token with an alternate audience using RFC8693 token exchange (only one audience can be requested)
+				      --oidc-scopes strings                          OpenID Connect scopes to request during login (default [offline_access,openid,pinniped:request-audience])
+				      --oidc-session-cache string                    Path to OpenID Connect session cache file
+				      --oidc-session-cache-create                    Create the parent directory of --oidc-session-cache (with 0700 permissions) if it does not already exist
+				      --oidc-skip-browser                            During OpenID Connect login, skip opening the browser (just print the URL)
+				  -o, --output string                                Output file path (default: stdout)
+				      --output-secret string                         Instead of writing the kubeconfig to a file, create or update the Secret with this namespace/name in the --kubeconfig cluster, under the "value" key
+				      --output-secret-label stringArray              Label (KEY=VALUE) to set on the --output-secret Secret (can be repeated)
+				      --purge                                        Instead of generating a kubeconfig, remove the Pinniped-generated entries from the --kubeconfig file (default: false)
+				      --request-timeout duration                     Timeout for each individual Kubernetes API request, so that a hung request fails fast instead of using up all of --timeout (default: no per-request timeout)
+				      --skip-validation                              Skip final validation of the kubeconfig (default: false)
+				      --static-token string                          Instead of doing an OIDC-based login, specify a static token
+				      --static-token-env string                      Instead of doing an OIDC-based login, read a static token from the environment
+				      --static-token-inline                          Write the --static-token directly into the generated kubeconfig instead of running a Pinniped login (requires --no-concierge)
+				      --supervisor-federationdomain string           NAMESPACE/NAME of a Supervisor FederationDomain in the --kubeconfig cluster whose issuer is used as the OpenID Connect issuer, unless --oidc-issuer is set or autodiscovered from a JWTAuthenticator
+				      --timeout duration                             Timeout for autodiscovery and validation (default 10m0s)
+				      --upstream-identity-provider-name string       The name of the upstream identity provider used during login with a Supervisor
+				      --upstream-identity-provider-type string       The type of the upstream identity provider used during login with a Supervisor (e.g. 'oidc', 'ldap')
+				      --validate-as-group stringArray                Impersonate this group during final validation of the kubeconfig, requires --validate-as-user (can be repeated)
+				      --validate-as-user string                      Impersonate this username (with the credentials of the --kubeconfig user) during final validation of the kubeconfig, without adding impersonation to the generated kubeconfig (only supported when the generated kubeconfig uses the same server as the --kubeconfig)
+				      --validate-contexts strings                    Instead of generating a kubeconfig, validate the kubeconfig which would be generated for each of these kubeconfig context names
+				      --validation-diagnostics                       If final validation of the kubeconfig fails, print details about the attempted connection to stderr (default: false)
+				  -v, --verbose int                                  Log verbosity level, where higher levels log more details about autodiscovery (default: 0)
+				      --write-discovery-annotations                  Record the discovered authenticator and OIDC issuer in an extension of the user entry of the generated kubeconfig (default: false)
 			`),
 		},
 		{
@@ -122,6 +214,28 @@ func TestGetKubeconfig(t *testing.T) {
 				Error: invalid argument "./does/not/exist" for "--concierge-ca-bundle" flag: could not read CA bundle path: open ./does/not/exist: no such file or directory
 			`),
 		},
+		{
+			name: "invalid cluster CA bundle path",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--cluster-ca-bundle", "./does/not/exist",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: invalid argument "./does/not/exist" for "--cluster-ca-bundle" flag: could not read CA bundle path: open ./does/not/exist: no such file or directory
+			`),
+		},
+		{
+			name: "cluster CA bundle without any PEM certificates",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--cluster-ca-bundle", "./testdata/kubeconfig.yaml",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: invalid argument "./testdata/kubeconfig.yaml" for "--cluster-ca-bundle" flag: failed to load any CA certificates from "./testdata/kubeconfig.yaml"
+			`),
+		},
 		{
 			name: "invalid kubeconfig path",
 			args: []string{
@@ -140,7 +254,7 @@ func TestGetKubeconfig(t *testing.T) {
 			},
 			wantError: true,
 			wantStderr: here.Doc(`
-				Error: could not load --kubeconfig/--kubeconfig-context: no such context "invalid"
+				Error: could not load --kubeconfig/--kubeconfig-context: no such context "invalid", did you mean "kind-kind"? (available contexts: kind-kind, some-other-context)
 			`),
 		},
 		{
@@ -179,6 +293,59 @@ func TestGetKubeconfig(t *testing.T) {
 				Error: credentialissuers.config.concierge.pinniped.dev "does-not-exist" not found
 			`),
 		},
+		{
+			name: "invalid --concierge-credential-issuer-pattern",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-credential-issuer-pattern", "pinniped-(",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: invalid --concierge-credential-issuer-pattern: error parsing regexp: missing closing ): ` + "`pinniped-(`" + `
+			`),
+		},
+		{
+			name: "--concierge-credential-issuer and --concierge-credential-issuer-pattern together",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-credential-issuer", "test-credential-issuer",
+				"--concierge-credential-issuer-pattern", "^test-",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: --concierge-credential-issuer and --concierge-credential-issuer-pattern cannot be used together
+			`),
+		},
+		{
+			name: "no credentialissuers matching --concierge-credential-issuer-pattern",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-credential-issuer-pattern", "^pinniped-concierge-",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"}},
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: no CredentialIssuers were found matching --concierge-credential-issuer-pattern "^pinniped-concierge-"
+			`),
+		},
+		{
+			name: "multiple credentialissuers matching --concierge-credential-issuer-pattern",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-credential-issuer-pattern", "^pinniped-concierge-",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{ObjectMeta: metav1.ObjectMeta{Name: "pinniped-concierge-def456"}},
+				&configv1alpha1.CredentialIssuer{ObjectMeta: metav1.ObjectMeta{Name: "pinniped-concierge-abc123"}},
+				&configv1alpha1.CredentialIssuer{ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"}},
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: multiple CredentialIssuers were found matching --concierge-credential-issuer-pattern "^pinniped-concierge-": pinniped-concierge-abc123, pinniped-concierge-def456
+			`),
+		},
 		{
 			name: "webhook authenticator not found",
 			args: []string{
@@ -283,6 +450,40 @@ func TestGetKubeconfig(t *testing.T) {
 				Error: failed to list WebhookAuthenticator objects for autodiscovery: some list error
 			`),
 		},
+		{
+			name: "fail to autodetect authenticator, neither authenticator kind is installed",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"}},
+			},
+			conciergeReactions: []kubetesting.Reactor{
+				&kubetesting.SimpleReactor{
+					Verb:     "list",
+					Resource: "jwtauthenticators",
+					Reaction: func(kubetesting.Action) (bool, runtime.Object, error) {
+						return true, nil, apierrors.NewNotFound(conciergev1alpha1.Resource("jwtauthenticators"), "")
+					},
+				},
+				&kubetesting.SimpleReactor{
+					Verb:     "list",
+					Resource: "webhookauthenticators",
+					Reaction: func(kubetesting.Action) (bool, runtime.Object, error) {
+						return true, nil, apierrors.NewNotFound(conciergev1alpha1.Resource("webhookauthenticators"), "")
+					},
+				},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"msg"="could not list JWTAuthenticator objects for autodiscovery, assuming there are none" "error"="jwtauthenticators.authentication.concierge.pinniped.dev \"\" not found"`,
+				`"msg"="could not list WebhookAuthenticator objects for autodiscovery, assuming there are none" "error"="webhookauthenticators.authentication.concierge.pinniped.dev \"\" not found"`,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: failed to list JWTAuthenticator objects for autodiscovery: jwtauthenticators.authentication.concierge.pinniped.dev "" not found
+			`),
+		},
 		{
 			name: "fail to autodetect authenticator, none found",
 			args: []string{
@@ -306,10 +507,11 @@ func TestGetKubeconfig(t *testing.T) {
 			},
 			conciergeObjects: []runtime.Object{
 				&configv1alpha1.CredentialIssuer{ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"}},
-				&conciergev1alpha1.JWTAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator-1"}},
+				// These are intentionally out of order to ensure that the logged output is sorted.
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator-4"}},
 				&conciergev1alpha1.JWTAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator-2"}},
 				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator-3"}},
-				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator-4"}},
+				&conciergev1alpha1.JWTAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator-1"}},
 			},
 			wantLogs: []string{
 				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
@@ -323,6 +525,47 @@ func TestGetKubeconfig(t *testing.T) {
 				Error: multiple authenticators were found, so the --concierge-authenticator-type/--concierge-authenticator-name flags must be specified
 			`),
 		},
+		{
+			name: "fail to autodetect authenticator, none found with prefix",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-authenticator-name-prefix", "tenant-c-",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"}},
+				&conciergev1alpha1.JWTAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a-authenticator"}},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "tenant-b-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: no authenticators were found with the name prefix "tenant-c-"
+			`),
+		},
+		{
+			name: "fail to autodetect authenticator, multiple found with prefix",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-authenticator-name-prefix", "tenant-a-",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"}},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a-webhook"}},
+				&conciergev1alpha1.JWTAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a-jwt"}},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "tenant-b-webhook"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="found JWTAuthenticator"  "name"="tenant-a-jwt"`,
+				`"level"=0 "msg"="found WebhookAuthenticator"  "name"="tenant-a-webhook"`,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: multiple authenticators were found with the name prefix "tenant-a-", so the --concierge-authenticator-type/--concierge-authenticator-name flags must be specified
+			`),
+		},
 		{
 			name: "autodetect webhook authenticator, bad credential issuer with only failing strategy",
 			args: []string{
@@ -348,7 +591,46 @@ func TestGetKubeconfig(t *testing.T) {
 			},
 			wantError: true,
 			wantStderr: here.Doc(`
-				Error: could not autodiscover --concierge-mode
+				Error: could not autodiscover --concierge-mode, only saw frontends: []
+			`),
+		},
+		{
+			name: "autodetect webhook authenticator, bad credential issuer with only failing frontends",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{
+							{
+								Type:     configv1alpha1.KubeClusterSigningCertificateStrategyType,
+								Status:   configv1alpha1.ErrorStrategyStatus,
+								Reason:   configv1alpha1.CouldNotFetchKeyStrategyReason,
+								Message:  "Some message",
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{Type: configv1alpha1.TokenCredentialRequestAPIFrontendType},
+							},
+							{
+								Type:     configv1alpha1.ImpersonationProxyStrategyType,
+								Status:   configv1alpha1.ErrorStrategyStatus,
+								Reason:   configv1alpha1.ErrorDuringSetupStrategyReason,
+								Message:  "Some other message",
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{Type: configv1alpha1.ImpersonationProxyFrontendType},
+							},
+						},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="found CredentialIssuer strategy"  "message"="Some message" "reason"="CouldNotFetchKey" "status"="Error" "type"="KubeClusterSigningCertificate"`,
+				`"level"=0 "msg"="found CredentialIssuer strategy"  "message"="Some other message" "reason"="ErrorDuringSetup" "status"="Error" "type"="ImpersonationProxy"`,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: could not autodiscover --concierge-mode, only saw frontends: [ImpersonationProxy=Error TokenCredentialRequestAPI=Error]
 			`),
 		},
 		{
@@ -439,6 +721,7 @@ func TestGetKubeconfig(t *testing.T) {
 				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
 				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
 				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
 				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
 			},
 			wantError: true,
@@ -446,6 +729,58 @@ func TestGetKubeconfig(t *testing.T) {
 				Error: could not autodiscover --oidc-issuer and none was provided
 			`),
 		},
+		{
+			name: "autodetect webhook authenticator with --concierge-authenticator-audience",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-authenticator-audience", "override-audience",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:           "SomeType",
+							Status:         configv1alpha1.SuccessStrategyStatus,
+							Reason:         "SomeReason",
+							Message:        "Some message",
+							LastUpdateTime: metav1.Now(),
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint",
+									CertificateAuthorityData: "ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==",
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: --concierge-authenticator-audience can only be used with a JWTAuthenticator, but WebhookAuthenticator test-authenticator was selected
+			`),
+		},
+		{
+			name: "both --concierge-authenticator-audience and --oidc-request-audience",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-authenticator-audience", "override-audience",
+				"--oidc-request-audience", "other-audience",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: only one of --concierge-authenticator-audience and --oidc-request-audience can be specified
+			`),
+		},
 		{
 			name: "autodetect JWT authenticator, invalid TLS bundle",
 			args: []string{
@@ -486,6 +821,7 @@ func TestGetKubeconfig(t *testing.T) {
 				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
 				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
 				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
 				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
 				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://test-issuer.example.com"`,
 				`"level"=0 "msg"="discovered OIDC audience"  "audience"="some-test-audience"`,
@@ -534,6 +870,66 @@ func TestGetKubeconfig(t *testing.T) {
 				Error: only one of --static-token and --static-token-env can be specified
 			`),
 		},
+		{
+			name: "invalid concierge endpoint, not a URL",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-endpoint", "foo",
+			},
+			getClientsetErr: fmt.Errorf("should not have made any API calls"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: invalid --concierge-endpoint "foo": must be an absolute https URL
+			`),
+		},
+		{
+			name: "invalid concierge endpoint, http scheme",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-endpoint", "http://concierge-endpoint.example.com",
+			},
+			getClientsetErr: fmt.Errorf("should not have made any API calls"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: invalid --concierge-endpoint "http://concierge-endpoint.example.com": must be an absolute https URL
+			`),
+		},
+		{
+			name: "invalid concierge endpoint, no host",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-endpoint", "https:///path",
+			},
+			getClientsetErr: fmt.Errorf("should not have made any API calls"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: invalid --concierge-endpoint "https:///path": must be an absolute https URL
+			`),
+		},
+		{
+			name: "invalid OIDC issuer, not a URL",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--oidc-issuer", "example.com/issuer",
+			},
+			getClientsetErr: fmt.Errorf("should not have made any API calls"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: invalid --oidc-issuer "example.com/issuer": must be an absolute https URL
+			`),
+		},
+		{
+			name: "invalid OIDC issuer, unparseable",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--oidc-issuer", "https://example.com:port/issuer",
+			},
+			getClientsetErr: fmt.Errorf("should not have made any API calls"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: invalid --oidc-issuer "https://example.com:port/issuer": must be an absolute https URL
+			`),
+		},
 		{
 			name: "invalid API group suffix",
 			args: []string{
@@ -545,10 +941,11 @@ func TestGetKubeconfig(t *testing.T) {
 			`),
 		},
 		{
-			name: "valid static token",
+			name: "autodiscovered Concierge CA bundle is empty with --fail-on-empty-ca",
 			args: []string{
 				"--kubeconfig", "./testdata/kubeconfig.yaml",
 				"--static-token", "test-token",
+				"--fail-on-empty-ca",
 				"--skip-validation",
 			},
 			conciergeObjects: []runtime.Object{
@@ -576,48 +973,92 @@ func TestGetKubeconfig(t *testing.T) {
 				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
 				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
 				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
-				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
 			},
-			wantStdout: here.Doc(`
-        		apiVersion: v1
-        		clusters:
-        		- cluster:
-        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
-        		    server: https://fake-server-url-value
-        		  name: pinniped
-        		contexts:
-        		- context:
-        		    cluster: pinniped
-        		    user: pinniped
-        		  name: pinniped
-        		current-context: pinniped
-        		kind: Config
-        		preferences: {}
-        		users:
-        		- name: pinniped
-        		  user:
-        		    exec:
-        		      apiVersion: client.authentication.k8s.io/v1beta1
-        		      args:
-        		      - login
-        		      - static
-        		      - --enable-concierge
-        		      - --concierge-api-group-suffix=pinniped.dev
-        		      - --concierge-authenticator-name=test-authenticator
-        		      - --concierge-authenticator-type=webhook
-        		      - --concierge-endpoint=https://fake-server-url-value
-        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
-        		      - --token=test-token
-        		      command: '.../path/to/pinniped'
-        		      env: []
-        		      provideClusterInfo: true
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: autodiscovered Concierge CA bundle does not contain any certificates
 			`),
 		},
 		{
-			name: "valid static token from env var",
+			name: "autodiscovered impersonation proxy strategy has empty CA data",
 			args: []string{
 				"--kubeconfig", "./testdata/kubeconfig.yaml",
-				"--static-token-env", "TEST_TOKEN",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.ImpersonationProxyStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.ListeningStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.ImpersonationProxyFrontendType,
+								ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+									Endpoint:                 "https://impersonation-endpoint",
+									CertificateAuthorityData: "",
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in impersonation proxy mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://impersonation-endpoint"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge impersonation proxy strategy does not provide a CA bundle, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: could not autodiscover --oidc-issuer and none was provided
+			`),
+		},
+		{
+			name: "autodiscovered impersonation proxy strategy has empty CA data with --fail-on-empty-ca",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--fail-on-empty-ca",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.ImpersonationProxyStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.ListeningStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.ImpersonationProxyFrontendType,
+								ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+									Endpoint:                 "https://impersonation-endpoint",
+									CertificateAuthorityData: "",
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in impersonation proxy mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://impersonation-endpoint"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: autodiscovered Concierge impersonation proxy strategy does not provide a CA bundle
+			`),
+		},
+		{
+			name: "valid static token",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
 				"--skip-validation",
 			},
 			conciergeObjects: []runtime.Object{
@@ -645,6 +1086,7 @@ func TestGetKubeconfig(t *testing.T) {
 				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
 				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
 				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
 				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
 			},
 			wantStdout: here.Doc(`
@@ -676,17 +1118,19 @@ func TestGetKubeconfig(t *testing.T) {
         		      - --concierge-authenticator-type=webhook
         		      - --concierge-endpoint=https://fake-server-url-value
         		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
-        		      - --token-env=TEST_TOKEN
+        		      - --token=test-token
         		      command: '.../path/to/pinniped'
         		      env: []
         		      provideClusterInfo: true
 			`),
 		},
 		{
-			name: "autodetect JWT authenticator",
+			name: "valid static token with --concierge-packed-config",
 			args: []string{
 				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
 				"--skip-validation",
+				"--concierge-packed-config",
 			},
 			conciergeObjects: []runtime.Object{
 				&configv1alpha1.CredentialIssuer{
@@ -706,28 +1150,17 @@ func TestGetKubeconfig(t *testing.T) {
 						}},
 					},
 				},
-				&conciergev1alpha1.JWTAuthenticator{
-					ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"},
-					Spec: conciergev1alpha1.JWTAuthenticatorSpec{
-						Issuer:   "https://example.com/issuer",
-						Audience: "test-audience",
-						TLS: &conciergev1alpha1.TLSSpec{
-							CertificateAuthorityData: base64.StdEncoding.EncodeToString(testOIDCCA.Bundle()),
-						},
-					},
-				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
 			},
 			wantLogs: []string{
 				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
 				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
 				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
 				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
-				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
-				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer"`,
-				`"level"=0 "msg"="discovered OIDC audience"  "audience"="test-audience"`,
-				`"level"=0 "msg"="discovered OIDC CA bundle"  "roots"=1`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
 			},
-			wantStdout: here.Docf(`
+			wantStdout: here.Doc(`
         		apiVersion: v1
         		clusters:
         		- cluster:
@@ -749,41 +1182,85 @@ func TestGetKubeconfig(t *testing.T) {
         		      apiVersion: client.authentication.k8s.io/v1beta1
         		      args:
         		      - login
-        		      - oidc
-        		      - --enable-concierge
-        		      - --concierge-api-group-suffix=pinniped.dev
-        		      - --concierge-authenticator-name=test-authenticator
-        		      - --concierge-authenticator-type=jwt
-        		      - --concierge-endpoint=https://fake-server-url-value
-        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
-        		      - --issuer=https://example.com/issuer
-        		      - --client-id=pinniped-cli
-        		      - --scopes=offline_access,openid,pinniped:request-audience
-        		      - --ca-bundle-data=%s
-        		      - --request-audience=test-audience
+        		      - static
+        		      - --concierge-config=eyJhcGlHcm91cFN1ZmZpeCI6InBpbm5pcGVkLmRldiIsImF1dGhlbnRpY2F0b3JOYW1lIjoidGVzdC1hdXRoZW50aWNhdG9yIiwiYXV0aGVudGljYXRvclR5cGUiOiJ3ZWJob29rIiwiZW5kcG9pbnQiOiJodHRwczovL2Zha2Utc2VydmVyLXVybC12YWx1ZSIsImNhQnVuZGxlRGF0YSI6IlptRnJaUzFqWlhKMGFXWnBZMkYwWlMxaGRYUm9iM0pwZEhrdFpHRjBZUzEyWVd4MVpRPT0ifQ==
+        		      - --token=test-token
         		      command: '.../path/to/pinniped'
         		      env: []
         		      provideClusterInfo: true
-			`, base64.StdEncoding.EncodeToString(testOIDCCA.Bundle())),
+			`),
 		},
 		{
-			name: "autodetect nothing, set a bunch of options",
+			name: "empty --oidc-client-id",
 			args: []string{
 				"--kubeconfig", "./testdata/kubeconfig.yaml",
-				"--concierge-credential-issuer", "test-credential-issuer",
-				"--concierge-api-group-suffix", "tuna.io",
-				"--concierge-authenticator-type", "webhook",
-				"--concierge-authenticator-name", "test-authenticator",
-				"--concierge-mode", "TokenCredentialRequestAPI",
-				"--concierge-endpoint", "https://explicit-concierge-endpoint.example.com",
-				"--concierge-ca-bundle", testConciergeCABundlePath,
-				"--oidc-issuer", "https://example.com/issuer",
-				"--oidc-skip-browser",
-				"--oidc-listen-port", "1234",
-				"--oidc-ca-bundle", testOIDCCABundlePath,
-				"--oidc-session-cache", "/path/to/cache/dir/sessions.yaml",
-				"--oidc-debug-session-cache",
-				"--oidc-request-audience", "test-audience",
+				"--oidc-client-id", "",
+			},
+			getClientsetErr: fmt.Errorf("some error configuring clientset"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: --oidc-client-id must not be empty
+			`),
+		},
+		{
+			name: "--oidc-scopes without openid",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--oidc-scopes", "offline_access,pinniped:request-audience",
+			},
+			getClientsetErr: fmt.Errorf("some error configuring clientset"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: --oidc-scopes must include "openid", since the login needs an ID token
+			`),
+		},
+		{
+			name: "empty --oidc-client-id and --oidc-scopes without openid are ignored with --static-token",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--oidc-client-id", "",
+				"--oidc-scopes", "offline_access",
+			},
+			getClientsetErr: fmt.Errorf("some error configuring clientset"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: could not configure Kubernetes client: some error configuring clientset
+			`),
+		},
+		{
+			name: "invalid --exec-env",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--exec-env", "HTTPS_PROXY=https://proxy.example.com",
+				"--exec-env", "NOT_KEY_VALUE",
+			},
+			getClientsetErr: fmt.Errorf("some error configuring clientset"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: invalid --exec-env "NOT_KEY_VALUE", expected KEY=VALUE
+			`),
+		},
+		{
+			name: "invalid --exec-env with empty key",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--exec-env", "=some-value",
+			},
+			getClientsetErr: fmt.Errorf("some error configuring clientset"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: invalid --exec-env "=some-value", expected KEY=VALUE
+			`),
+		},
+		{
+			name: "valid static token with --exec-env",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--exec-env", "HTTPS_PROXY=https://proxy.example.com:3128",
+				"--exec-env", "NO_PROXY=localhost,127.0.0.1",
+				"--exec-env", "EMPTY_VALUE=",
 				"--skip-validation",
 			},
 			conciergeObjects: []runtime.Object{
@@ -798,23 +1275,28 @@ func TestGetKubeconfig(t *testing.T) {
 								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
 								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
 									Server:                   "https://concierge-endpoint.example.com",
-									CertificateAuthorityData: "dGVzdC10Y3ItYXBpLWNh",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
 								},
 							},
 						}},
 					},
 				},
-				&conciergev1alpha1.WebhookAuthenticator{
-					ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"},
-				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
 			},
-			wantLogs: nil,
-			wantStdout: here.Docf(`
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
+			},
+			wantStdout: here.Doc(`
         		apiVersion: v1
         		clusters:
         		- cluster:
-        		    certificate-authority-data: %s
-        		    server: https://explicit-concierge-endpoint.example.com
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
         		  name: pinniped
         		contexts:
         		- context:
@@ -831,104 +1313,117 @@ func TestGetKubeconfig(t *testing.T) {
         		      apiVersion: client.authentication.k8s.io/v1beta1
         		      args:
         		      - login
-        		      - oidc
+        		      - static
         		      - --enable-concierge
-        		      - --concierge-api-group-suffix=tuna.io
+        		      - --concierge-api-group-suffix=pinniped.dev
         		      - --concierge-authenticator-name=test-authenticator
         		      - --concierge-authenticator-type=webhook
-        		      - --concierge-endpoint=https://explicit-concierge-endpoint.example.com
-        		      - --concierge-ca-bundle-data=%s
-        		      - --issuer=https://example.com/issuer
-        		      - --client-id=pinniped-cli
-        		      - --scopes=offline_access,openid,pinniped:request-audience
-        		      - --skip-browser
-        		      - --listen-port=1234
-        		      - --ca-bundle-data=%s
-        		      - --session-cache=/path/to/cache/dir/sessions.yaml
-        		      - --debug-session-cache
-        		      - --request-audience=test-audience
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --token=test-token
         		      command: '.../path/to/pinniped'
-        		      env: []
+        		      env:
+        		      - name: HTTPS_PROXY
+        		        value: https://proxy.example.com:3128
+        		      - name: NO_PROXY
+        		        value: localhost,127.0.0.1
+        		      - name: EMPTY_VALUE
+        		        value: ""
         		      provideClusterInfo: true
-			`,
-				base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
-				base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
-				base64.StdEncoding.EncodeToString(testOIDCCA.Bundle()),
-			),
-			wantAPIGroupSuffix: "tuna.io",
+			`),
 		},
 		{
-			name: "configure impersonation proxy with autodiscovered JWT authenticator",
+			name: "invalid base64 in --concierge-ca-bundle-data",
 			args: []string{
 				"--kubeconfig", "./testdata/kubeconfig.yaml",
-				"--concierge-mode", "ImpersonationProxy",
-				"--skip-validation",
+				"--concierge-ca-bundle-data", "invalid-base64!",
 			},
-			conciergeObjects: []runtime.Object{
-				&configv1alpha1.CredentialIssuer{
-					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
-					Status: configv1alpha1.CredentialIssuerStatus{
-						Strategies: []configv1alpha1.CredentialIssuerStrategy{
-							// This TokenCredentialRequestAPI strategy would normally be chosen, but
-							// --concierge-mode=ImpersonationProxy should force it to be skipped.
-							{
-								Type:           "SomeType",
-								Status:         configv1alpha1.SuccessStrategyStatus,
-								Reason:         "SomeReason",
-								Message:        "Some message",
-								LastUpdateTime: metav1.Now(),
-								Frontend: &configv1alpha1.CredentialIssuerFrontend{
-									Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
-									TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
-										Server:                   "https://token-credential-request-api-endpoint.test",
-										CertificateAuthorityData: "dGVzdC10Y3ItYXBpLWNh",
-									},
-								},
-							},
-							// The endpoint and CA from this impersonation proxy strategy should be autodiscovered.
-							{
-								Type:           "SomeOtherType",
-								Status:         configv1alpha1.SuccessStrategyStatus,
-								Reason:         "SomeOtherReason",
-								Message:        "Some other message",
-								LastUpdateTime: metav1.Now(),
-								Frontend: &configv1alpha1.CredentialIssuerFrontend{
-									Type: configv1alpha1.ImpersonationProxyFrontendType,
-									ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
-										Endpoint:                 "https://impersonation-proxy-endpoint.test",
-										CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
-									},
+			getClientsetErr: fmt.Errorf("some error configuring clientset"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: invalid --concierge-ca-bundle-data: illegal base64 data at input byte 7
+			`),
+		},
+		{
+			name: "no certificates in --concierge-ca-bundle-data",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-ca-bundle-data", base64.StdEncoding.EncodeToString([]byte("not a certificate")),
+			},
+			getClientsetErr: fmt.Errorf("some error configuring clientset"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: failed to load any CA certificates from --concierge-ca-bundle-data
+			`),
+		},
+		{
+			name: "both --concierge-ca-bundle and --concierge-ca-bundle-data",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-ca-bundle", testConciergeCABundlePath,
+				"--concierge-ca-bundle-data", base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+			},
+			getClientsetErr: fmt.Errorf("some error configuring clientset"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: --concierge-ca-bundle and --concierge-ca-bundle-data cannot be used together
+			`),
+		},
+		{
+			name: "invalid --cluster-proxy-url",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--cluster-proxy-url", "ftp://proxy.example.com",
+			},
+			getClientsetErr: fmt.Errorf("some error configuring clientset"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: invalid --cluster-proxy-url "ftp://proxy.example.com": must be an absolute http, https, or socks5 URL
+			`),
+		},
+		{
+			name: "valid static token with --cluster-proxy-url",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--cluster-proxy-url", "socks5://proxy.example.com:1080",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
 								},
 							},
-						},
-					},
-				},
-				&conciergev1alpha1.JWTAuthenticator{
-					ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"},
-					Spec: conciergev1alpha1.JWTAuthenticatorSpec{
-						Issuer:   "https://example.com/issuer",
-						Audience: "test-audience",
-						TLS: &conciergev1alpha1.TLSSpec{
-							CertificateAuthorityData: base64.StdEncoding.EncodeToString(testOIDCCA.Bundle()),
-						},
+						}},
 					},
 				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
 			},
 			wantLogs: []string{
 				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
-				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://impersonation-proxy-endpoint.test"`,
-				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=1`,
-				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
-				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer"`,
-				`"level"=0 "msg"="discovered OIDC audience"  "audience"="test-audience"`,
-				`"level"=0 "msg"="discovered OIDC CA bundle"  "roots"=1`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
 			},
-			wantStdout: here.Docf(`
+			wantStdout: here.Doc(`
         		apiVersion: v1
         		clusters:
         		- cluster:
-        		    certificate-authority-data: %s
-        		    server: https://impersonation-proxy-endpoint.test
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    proxy-url: socks5://proxy.example.com:1080
+        		    server: https://fake-server-url-value
         		  name: pinniped
         		contexts:
         		- context:
@@ -945,96 +1440,75 @@ func TestGetKubeconfig(t *testing.T) {
         		      apiVersion: client.authentication.k8s.io/v1beta1
         		      args:
         		      - login
-        		      - oidc
+        		      - static
         		      - --enable-concierge
         		      - --concierge-api-group-suffix=pinniped.dev
         		      - --concierge-authenticator-name=test-authenticator
-        		      - --concierge-authenticator-type=jwt
-        		      - --concierge-endpoint=https://impersonation-proxy-endpoint.test
-        		      - --concierge-ca-bundle-data=%s
-        		      - --issuer=https://example.com/issuer
-        		      - --client-id=pinniped-cli
-        		      - --scopes=offline_access,openid,pinniped:request-audience
-        		      - --ca-bundle-data=%s
-        		      - --request-audience=test-audience
+        		      - --concierge-authenticator-type=webhook
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --token=test-token
         		      command: '.../path/to/pinniped'
         		      env: []
         		      provideClusterInfo: true
-			`,
-				base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
-				base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
-				base64.StdEncoding.EncodeToString(testOIDCCA.Bundle()),
-			),
+			`),
 		},
 		{
-			name: "autodetect impersonation proxy with autodiscovered JWT authenticator",
+			name: "valid static token with a fresh strategy and a stale strategy",
 			args: []string{
 				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--concierge-max-strategy-age", "1h",
 				"--skip-validation",
 			},
 			conciergeObjects: []runtime.Object{
 				&configv1alpha1.CredentialIssuer{
 					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
 					Status: configv1alpha1.CredentialIssuerStatus{
-						Strategies: []configv1alpha1.CredentialIssuerStrategy{
-							{
-								Type:           "SomeType",
-								Status:         configv1alpha1.SuccessStrategyStatus,
-								Reason:         "SomeReason",
-								Message:        "Some message",
-								LastUpdateTime: metav1.Now(),
-								Frontend: &configv1alpha1.CredentialIssuerFrontend{
-									Type: configv1alpha1.ImpersonationProxyFrontendType,
-									ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
-										Endpoint:                 "https://impersonation-proxy-endpoint.test",
-										CertificateAuthorityData: "dGVzdC1jb25jaWVyZ2UtY2E=",
-									},
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:           configv1alpha1.ImpersonationProxyStrategyType,
+							Status:         configv1alpha1.SuccessStrategyStatus,
+							Reason:         configv1alpha1.ListeningStrategyReason,
+							LastUpdateTime: metav1.NewTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.ImpersonationProxyFrontendType,
+								ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+									Endpoint:                 "https://impersonation-proxy-endpoint.test",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
 								},
 							},
-							{
-								Type:           "SomeOtherType",
-								Status:         configv1alpha1.SuccessStrategyStatus,
-								Reason:         "SomeOtherReason",
-								Message:        "Some other message",
-								LastUpdateTime: metav1.Now(),
-								Frontend: &configv1alpha1.CredentialIssuerFrontend{
-									Type: configv1alpha1.ImpersonationProxyFrontendType,
-									ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
-										Endpoint:                 "https://some-other-impersonation-endpoint",
-										CertificateAuthorityData: "dGVzdC1jb25jaWVyZ2UtY2E=",
-									},
+						}, {
+							Type:           configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status:         configv1alpha1.SuccessStrategyStatus,
+							Reason:         configv1alpha1.FetchedKeyStrategyReason,
+							LastUpdateTime: metav1.Now(),
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
 								},
 							},
-						},
-					},
-				},
-				&conciergev1alpha1.JWTAuthenticator{
-					ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"},
-					Spec: conciergev1alpha1.JWTAuthenticatorSpec{
-						Issuer:   "https://example.com/issuer",
-						Audience: "test-audience",
-						TLS: &conciergev1alpha1.TLSSpec{
-							CertificateAuthorityData: base64.StdEncoding.EncodeToString(testOIDCCA.Bundle()),
-						},
+						}},
 					},
 				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
 			},
 			wantLogs: []string{
 				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
-				`"level"=0 "msg"="discovered Concierge operating in impersonation proxy mode"`,
-				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://impersonation-proxy-endpoint.test"`,
+				`"level"=0 "msg"="ignoring stale Concierge strategy"  "lastUpdateTime"="2020-01-01T00:00:00Z" "type"="ImpersonationProxy"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
 				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
-				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
-				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer"`,
-				`"level"=0 "msg"="discovered OIDC audience"  "audience"="test-audience"`,
-				`"level"=0 "msg"="discovered OIDC CA bundle"  "roots"=1`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
 			},
-			wantStdout: here.Docf(`
+			wantStdout: here.Doc(`
         		apiVersion: v1
         		clusters:
         		- cluster:
-        		    certificate-authority-data: dGVzdC1jb25jaWVyZ2UtY2E=
-        		    server: https://impersonation-proxy-endpoint.test
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
         		  name: pinniped
         		contexts:
         		- context:
@@ -1051,67 +1525,4766 @@ func TestGetKubeconfig(t *testing.T) {
         		      apiVersion: client.authentication.k8s.io/v1beta1
         		      args:
         		      - login
-        		      - oidc
+        		      - static
         		      - --enable-concierge
         		      - --concierge-api-group-suffix=pinniped.dev
         		      - --concierge-authenticator-name=test-authenticator
-        		      - --concierge-authenticator-type=jwt
-        		      - --concierge-endpoint=https://impersonation-proxy-endpoint.test
-        		      - --concierge-ca-bundle-data=dGVzdC1jb25jaWVyZ2UtY2E=
-        		      - --issuer=https://example.com/issuer
-        		      - --client-id=pinniped-cli
-        		      - --scopes=offline_access,openid,pinniped:request-audience
-        		      - --ca-bundle-data=%s
-        		      - --request-audience=test-audience
+        		      - --concierge-authenticator-type=webhook
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --token=test-token
         		      command: '.../path/to/pinniped'
         		      env: []
         		      provideClusterInfo: true
-			`, base64.StdEncoding.EncodeToString(testOIDCCA.Bundle())),
+			`),
 		},
-	}
-	for _, tt := range tests {
-		tt := tt
-		t.Run(tt.name, func(t *testing.T) {
-			testLog := testlogger.New(t)
-			cmd := kubeconfigCommand(kubeconfigDeps{
-				getPathToSelf: func() (string, error) {
-					if tt.getPathToSelfErr != nil {
-						return "", tt.getPathToSelfErr
-					}
-					return ".../path/to/pinniped", nil
-				},
-				getClientset: func(clientConfig clientcmd.ClientConfig, apiGroupSuffix string) (conciergeclientset.Interface, error) {
-					if tt.wantAPIGroupSuffix == "" {
-						require.Equal(t, "pinniped.dev", apiGroupSuffix) // "pinniped.dev" = api group suffix default
-					} else {
-						require.Equal(t, tt.wantAPIGroupSuffix, apiGroupSuffix)
-					}
-					if tt.getClientsetErr != nil {
-						return nil, tt.getClientsetErr
-					}
-					fake := fakeconciergeclientset.NewSimpleClientset(tt.conciergeObjects...)
-					if len(tt.conciergeReactions) > 0 {
-						fake.ReactionChain = append(tt.conciergeReactions, fake.ReactionChain...)
-					}
-					return fake, nil
+		{
+			name: "all matching strategies are older than --concierge-max-strategy-age",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--concierge-max-strategy-age", "1h",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:           configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status:         configv1alpha1.SuccessStrategyStatus,
+							Reason:         configv1alpha1.FetchedKeyStrategyReason,
+							Message:        "Successfully fetched key",
+							LastUpdateTime: metav1.NewTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
 				},
-				log: testLog,
-			})
-			require.NotNil(t, cmd)
-
-			var stdout, stderr bytes.Buffer
-			cmd.SetOut(&stdout)
-			cmd.SetErr(&stderr)
-			cmd.SetArgs(tt.args)
-			err := cmd.Execute()
-			if tt.wantError {
-				require.Error(t, err)
-			} else {
-				require.NoError(t, err)
-			}
-			testLog.Expect(tt.wantLogs)
-			require.Equal(t, tt.wantStdout, stdout.String(), "unexpected stdout")
-			require.Equal(t, tt.wantStderr, stderr.String(), "unexpected stderr")
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="ignoring stale Concierge strategy"  "lastUpdateTime"="2020-01-01T00:00:00Z" "type"="KubeClusterSigningCertificate"`,
+				`"level"=0 "msg"="found CredentialIssuer strategy"  "message"="Successfully fetched key" "reason"="FetchedKey" "status"="Success" "type"="KubeClusterSigningCertificate"`,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: could not find successful Concierge strategy updated within --concierge-max-strategy-age=1h0m0s
+			`),
+		},
+		{
+			name: "valid static token with credentialissuer matching --concierge-credential-issuer-pattern",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--concierge-credential-issuer-pattern", "^pinniped-concierge-",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"}},
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "pinniped-concierge-abc123"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="pinniped-concierge-abc123"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
+			},
+			wantStdout: here.Doc(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - static
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=webhook
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --token=test-token
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`),
+		},
+		{
+			name: "valid static token with only --concierge-authenticator-name",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--concierge-authenticator-name", "test-authenticator",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "other-webhook-authenticator"}},
+				&conciergev1alpha1.JWTAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "other-jwt-authenticator"}},
+			},
+			conciergeReactions: []kubetesting.Reactor{requireListFieldSelector("metadata.name=test-authenticator")},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
+			},
+			wantStdout: here.Doc(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - static
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=webhook
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --token=test-token
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`),
+		},
+		{
+			name: "only --concierge-authenticator-name, no authenticator with that name",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--concierge-authenticator-name", "test-authenticator",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "other-webhook-authenticator"}},
+			},
+			conciergeReactions: []kubetesting.Reactor{requireListFieldSelector("metadata.name=test-authenticator")},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: no authenticators were found with the name "test-authenticator"
+			`),
+		},
+		{
+			name: "only --concierge-authenticator-name, authenticators of both types with that name",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--concierge-authenticator-name", "test-authenticator",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+				&conciergev1alpha1.JWTAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+			},
+			conciergeReactions: []kubetesting.Reactor{requireListFieldSelector("metadata.name=test-authenticator")},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="found JWTAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="found WebhookAuthenticator"  "name"="test-authenticator"`,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: multiple authenticators were found with the name "test-authenticator", so the --concierge-authenticator-type flag must be specified
+			`),
+		},
+		{
+			name: "valid static token with one of each authenticator type, preferring jwt",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--skip-validation",
+				"--concierge-prefer-authenticator-type", "jwt",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-webhook-authenticator"}},
+				&conciergev1alpha1.JWTAuthenticator{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-jwt-authenticator"},
+					Spec: conciergev1alpha1.JWTAuthenticatorSpec{
+						Issuer:   "https://example.com/issuer",
+						Audience: "test-audience",
+					},
+				},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-jwt-authenticator"`,
+				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer"`,
+				`"level"=0 "msg"="discovered OIDC audience"  "audience"="test-audience"`,
+			},
+			wantStdout: here.Doc(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - static
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-jwt-authenticator
+        		      - --concierge-authenticator-type=jwt
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --token=test-token
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`),
+		},
+		{
+			name: "valid static token with one of each authenticator type, preferring webhook",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--skip-validation",
+				"--concierge-prefer-authenticator-type", "WebHook",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-webhook-authenticator"}},
+				&conciergev1alpha1.JWTAuthenticator{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-jwt-authenticator"},
+					Spec: conciergev1alpha1.JWTAuthenticatorSpec{
+						Issuer:   "https://example.com/issuer",
+						Audience: "test-audience",
+					},
+				},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-webhook-authenticator"`,
+			},
+			wantStdout: here.Doc(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - static
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-webhook-authenticator
+        		      - --concierge-authenticator-type=webhook
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --token=test-token
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`),
+		},
+		{
+			name: "autodetect JWT authenticator when the WebhookAuthenticator kind is not installed",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.JWTAuthenticator{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-jwt-authenticator"},
+					Spec: conciergev1alpha1.JWTAuthenticatorSpec{
+						Issuer:   "https://example.com/issuer",
+						Audience: "test-audience",
+					},
+				},
+			},
+			conciergeReactions: []kubetesting.Reactor{
+				&kubetesting.SimpleReactor{
+					Verb:     "list",
+					Resource: "webhookauthenticators",
+					Reaction: func(kubetesting.Action) (bool, runtime.Object, error) {
+						return true, nil, apierrors.NewNotFound(conciergev1alpha1.Resource("webhookauthenticators"), "")
+					},
+				},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"msg"="could not list WebhookAuthenticator objects for autodiscovery, assuming there are none" "error"="webhookauthenticators.authentication.concierge.pinniped.dev \"\" not found"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-jwt-authenticator"`,
+				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer"`,
+				`"level"=0 "msg"="discovered OIDC audience"  "audience"="test-audience"`,
+			},
+			wantStdout: here.Doc(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - static
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-jwt-authenticator
+        		      - --concierge-authenticator-type=jwt
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --token=test-token
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`),
+		},
+		{
+			name: "autodetect webhook authenticator when JWTAuthenticator objects are forbidden",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-webhook-authenticator"}},
+			},
+			conciergeReactions: []kubetesting.Reactor{
+				&kubetesting.SimpleReactor{
+					Verb:     "list",
+					Resource: "jwtauthenticators",
+					Reaction: func(kubetesting.Action) (bool, runtime.Object, error) {
+						return true, nil, apierrors.NewForbidden(conciergev1alpha1.Resource("jwtauthenticators"), "", fmt.Errorf("RBAC: access denied"))
+					},
+				},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"msg"="could not list JWTAuthenticator objects for autodiscovery, assuming there are none" "error"="jwtauthenticators.authentication.concierge.pinniped.dev is forbidden: RBAC: access denied"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-webhook-authenticator"`,
+			},
+			wantStdout: here.Doc(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - static
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-webhook-authenticator
+        		      - --concierge-authenticator-type=webhook
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --token=test-token
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`),
+		},
+		{
+			name: "valid static token with --no-concierge",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--no-concierge",
+				"--static-token", "test-token",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"}},
+				&conciergev1alpha1.JWTAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-jwt-authenticator"}},
+			},
+			conciergeReactions: []kubetesting.Reactor{
+				&kubetesting.SimpleReactor{
+					Verb:     "*",
+					Resource: "*",
+					Reaction: func(action kubetesting.Action) (bool, runtime.Object, error) {
+						return true, nil, fmt.Errorf("unexpected Concierge API call: %s %s", action.GetVerb(), action.GetResource().Resource)
+					},
+				},
+			},
+			wantStdout: here.Doc(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - static
+        		      - --token=test-token
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`),
+		},
+		{
+			name: "valid static token with --static-token-inline",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--no-concierge",
+				"--static-token", "test-token",
+				"--static-token-inline",
+				"--skip-validation",
+			},
+			wantStdout: here.Doc(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    token: test-token
+			`),
+		},
+		{
+			name: "--kubeconfig list with the selected context in the second file",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml" + string(os.PathListSeparator) + "./testdata/kubeconfig-split.yaml",
+				"--kubeconfig-context", "split-context",
+				"--no-concierge",
+				"--static-token", "test-token",
+				"--static-token-inline",
+				"--skip-validation",
+			},
+			wantStdout: here.Doc(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: c3BsaXQtZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://split-fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    token: test-token
+			`),
+		},
+		{
+			name: "--kubeconfig list uses the current-context of the first file which sets one",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml" + string(os.PathListSeparator) + "./testdata/kubeconfig-split.yaml",
+				"--no-concierge",
+				"--static-token", "test-token",
+				"--static-token-inline",
+				"--skip-validation",
+			},
+			wantStdout: here.Doc(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    token: test-token
+			`),
+		},
+		{
+			name: "--purge with a --kubeconfig list",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml" + string(os.PathListSeparator) + "./testdata/kubeconfig-split.yaml",
+				"--purge",
+			},
+			wantError:  true,
+			wantStderr: fmt.Sprintf("Error: --purge requires a single --kubeconfig path, but got %q\n", "./testdata/kubeconfig.yaml"+string(os.PathListSeparator)+"./testdata/kubeconfig-split.yaml"),
+		},
+		{
+			name: "--static-token-inline without --no-concierge",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--static-token-inline",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: --static-token-inline requires --no-concierge, since the Concierge cannot exchange a token which is not sent by a Pinniped login
+			`),
+		},
+		{
+			name: "--static-token-inline with --static-token-env",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--no-concierge",
+				"--static-token-env", "TEST_TOKEN",
+				"--static-token-inline",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: --static-token-inline requires --static-token
+			`),
+		},
+		{
+			name: "--static-token-inline with --exec-env",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--no-concierge",
+				"--static-token", "test-token",
+				"--static-token-inline",
+				"--exec-env", "FOO=bar",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: --static-token-inline and --exec-env cannot be used together
+			`),
+		},
+		{
+			name: "valid static token with --cluster-extension",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--no-concierge",
+				"--static-token", "test-token",
+				"--skip-validation",
+				"--cluster-extension", `provenance.example.com={"generatedBy": "pinniped", "version": "v0.0.0"}`,
+				"--cluster-extension", `string.example.com="some-string"`,
+			},
+			wantStdout: here.Doc(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    extensions:
+        		    - extension:
+        		        generatedBy: pinniped
+        		        version: v0.0.0
+        		      name: provenance.example.com
+        		    - extension: some-string
+        		      name: string.example.com
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - static
+        		      - --token=test-token
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`),
+		},
+		{
+			name: "invalid --cluster-extension format",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--cluster-extension", "provenance.example.com",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: invalid --cluster-extension "provenance.example.com", expected NAME=JSON
+			`),
+		},
+		{
+			name: "invalid --cluster-extension JSON",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--cluster-extension", "provenance.example.com={not-json",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: invalid --cluster-extension "provenance.example.com={not-json", value is not valid JSON
+			`),
+		},
+		{
+			name: "oidc login with --no-concierge",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--no-concierge",
+				"--oidc-issuer", "https://example.com/issuer",
+				"--oidc-request-audience", "test-audience",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"}},
+				&conciergev1alpha1.JWTAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-jwt-authenticator"}},
+			},
+			conciergeReactions: []kubetesting.Reactor{
+				&kubetesting.SimpleReactor{
+					Verb:     "*",
+					Resource: "*",
+					Reaction: func(action kubetesting.Action) (bool, runtime.Object, error) {
+						return true, nil, fmt.Errorf("unexpected Concierge API call: %s %s", action.GetVerb(), action.GetResource().Resource)
+					},
+				},
+			},
+			wantStdout: here.Doc(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --request-audience=test-audience
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`),
+		},
+		{
+			name: "invalid --concierge-prefer-authenticator-type",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-prefer-authenticator-type", "ldap",
+			},
+			getClientsetErr: fmt.Errorf("some error configuring clientset"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: invalid --concierge-prefer-authenticator-type "ldap", supported values are "webhook" and "jwt"
+			`),
+		},
+		{
+			name: "autodetect authenticator, multiple of one type found with a preferred type",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-prefer-authenticator-type", "jwt",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"}},
+				&conciergev1alpha1.JWTAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator-1"}},
+				&conciergev1alpha1.JWTAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator-2"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="found JWTAuthenticator"  "name"="test-authenticator-1"`,
+				`"level"=0 "msg"="found JWTAuthenticator"  "name"="test-authenticator-2"`,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: multiple authenticators were found, so the --concierge-authenticator-type/--concierge-authenticator-name flags must be specified
+			`),
+		},
+		{
+			name: "valid static token with authenticator autodiscovered by unique name prefix",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--skip-validation",
+				"--concierge-authenticator-name-prefix", "tenant-a-",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a-authenticator"}},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "tenant-b-authenticator"}},
+				&conciergev1alpha1.JWTAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "tenant-b-jwt-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="tenant-a-authenticator"`,
+			},
+			wantStdout: here.Doc(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - static
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=tenant-a-authenticator
+        		      - --concierge-authenticator-type=webhook
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --token=test-token
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`),
+		},
+		{
+			name: "valid static token with validated impersonation proxy",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+			},
+			conciergeObjects: []runtime.Object{
+				impersonationProxyCredentialIssuer(impersonationProxyCABundle),
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in impersonation proxy mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="` + impersonationProxyURL + `"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=1`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="validated TLS connection to the impersonation proxy"  "endpoint"="` + impersonationProxyURL + `"`,
+				`"level"=0 "msg"="validated connection to the cluster"`,
+			},
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: %[1]s
+        		    server: %[2]s
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - static
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=webhook
+        		      - --concierge-endpoint=%[2]s
+        		      - --concierge-ca-bundle-data=%[1]s
+        		      - --token=test-token
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`, base64.StdEncoding.EncodeToString([]byte(impersonationProxyCABundle)), impersonationProxyURL),
+		},
+		{
+			name: "impersonation proxy with an untrusted certificate",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+			},
+			conciergeObjects: []runtime.Object{
+				impersonationProxyCredentialIssuer(string(testConciergeCA.Bundle())),
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in impersonation proxy mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="` + impersonationProxyURL + `"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=1`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
+			},
+			wantError: true,
+			wantStderr: here.Docf(`
+				Error: could not verify the TLS certificate of the impersonation proxy at %s: x509: certificate signed by unknown authority
+			`, impersonationProxyURL),
+		},
+		{
+			name: "impersonation proxy with an untrusted certificate and --validation-diagnostics",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--validation-diagnostics",
+			},
+			conciergeObjects: []runtime.Object{
+				impersonationProxyCredentialIssuer(string(testConciergeCA.Bundle())),
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in impersonation proxy mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="` + impersonationProxyURL + `"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=1`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
+			},
+			wantError: true,
+			wantStderr: here.Docf(`
+				Kubeconfig validation diagnostics:
+				  server: %[1]s
+				  certificate authority roots: 1
+				  authenticator: webhook/test-authenticator
+				  cause: x509: certificate signed by unknown authority
+				Error: could not verify the TLS certificate of the impersonation proxy at %[1]s: x509: certificate signed by unknown authority
+			`, impersonationProxyURL),
+		},
+		{
+			name: "validate multiple contexts",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--validate-contexts", "kind-kind,some-other-context",
+			},
+			conciergeObjects: []runtime.Object{
+				impersonationProxyCredentialIssuer(impersonationProxyCABundle),
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in impersonation proxy mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="` + impersonationProxyURL + `"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=1`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="validated TLS connection to the impersonation proxy"  "endpoint"="` + impersonationProxyURL + `"`,
+				`"level"=0 "msg"="validated connection to the cluster"`,
+				`"level"=0 "msg"="validated kubeconfig context"  "context"="kind-kind"`,
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in impersonation proxy mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="` + impersonationProxyURL + `"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=1`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="validated TLS connection to the impersonation proxy"  "endpoint"="` + impersonationProxyURL + `"`,
+				`"level"=0 "msg"="validated connection to the cluster"`,
+				`"level"=0 "msg"="validated kubeconfig context"  "context"="some-other-context"`,
+			},
+		},
+		{
+			name: "validate multiple contexts with some failures",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--validate-contexts", "does-not-exist,kind-kind,also-does-not-exist",
+			},
+			conciergeObjects: []runtime.Object{
+				impersonationProxyCredentialIssuer(impersonationProxyCABundle),
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in impersonation proxy mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="` + impersonationProxyURL + `"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=1`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="validated TLS connection to the impersonation proxy"  "endpoint"="` + impersonationProxyURL + `"`,
+				`"level"=0 "msg"="validated connection to the cluster"`,
+				`"level"=0 "msg"="validated kubeconfig context"  "context"="kind-kind"`,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: [context "does-not-exist": could not load --kubeconfig/--kubeconfig-context: no such context "does-not-exist", did you mean "kind-kind"? (available contexts: kind-kind, some-other-context), context "also-does-not-exist": could not load --kubeconfig/--kubeconfig-context: no such context "also-does-not-exist", did you mean "some-other-context"? (available contexts: kind-kind, some-other-context)]
+			`),
+		},
+		{
+			name: "validate multiple contexts with --kubeconfig-context",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--kubeconfig-context", "kind-kind",
+				"--validate-contexts", "kind-kind,some-other-context",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: --validate-contexts cannot be used with --kubeconfig-context
+			`),
+		},
+		{
+			name: "validate multiple contexts with --skip-validation",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--skip-validation",
+				"--validate-contexts", "kind-kind,some-other-context",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: --validate-contexts cannot be used with --skip-validation
+			`),
+		},
+		{
+			name: "valid static token with overlapping --concierge-ca-bundle files",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--concierge-ca-bundle", testConciergeCABundlePath,
+				"--concierge-ca-bundle", testOverlappingConciergeCABundlePath,
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
+			},
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: %s
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - static
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=webhook
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=%s
+        		      - --token=test-token
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`,
+				base64.StdEncoding.EncodeToString(append(testConciergeCA.Bundle(), testOtherConciergeCA.Bundle()...)),
+				base64.StdEncoding.EncodeToString(append(testConciergeCA.Bundle(), testOtherConciergeCA.Bundle()...)),
+			),
+		},
+		{
+			name: "valid static token from env var",
+			args: []string{
+				"--static-token-env", "TEST_TOKEN",
+				"--skip-validation",
+			},
+			env: map[string]string{
+				"KUBECONFIG": "./testdata/kubeconfig.yaml",
+				"TEST_TOKEN": "test-token",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
+			},
+			wantStdout: here.Doc(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - static
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=webhook
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --token-env=TEST_TOKEN
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`),
+		},
+		{
+			name: "valid static token from env var which is not currently set",
+			args: []string{
+				"--static-token-env", "TEST_TOKEN",
+				"--skip-validation",
+			},
+			env: map[string]string{
+				"KUBECONFIG": "./testdata/kubeconfig.yaml",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="warning: the --static-token-env environment variable is not currently set, so logins with this kubeconfig may fail"  "name"="TEST_TOKEN"`,
+			},
+			wantStdout: here.Doc(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - static
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=webhook
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --token-env=TEST_TOKEN
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`),
+		},
+		{
+			name: "autodetect JWT authenticator",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				testutil.NewJWTAuthenticator("test-authenticator", "https://example.com/issuer", "test-audience", testOIDCCA.Bundle()),
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer"`,
+				`"level"=0 "msg"="discovered OIDC audience"  "audience"="test-audience"`,
+				`"level"=0 "msg"="discovered OIDC CA bundle"  "roots"=1`,
+			},
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=jwt
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --ca-bundle-data=%s
+        		      - --request-audience=test-audience
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`, base64.StdEncoding.EncodeToString(testOIDCCA.Bundle())),
+		},
+		{
+			name: "autodetect JWT authenticator with custom username and groups claims",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.JWTAuthenticator{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"},
+					Spec: conciergev1alpha1.JWTAuthenticatorSpec{
+						Issuer:   "https://example.com/issuer",
+						Audience: "test-audience",
+						Claims: conciergev1alpha1.JWTTokenClaims{
+							Username: "email",
+							Groups:   "roles",
+						},
+					},
+				},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer"`,
+				`"level"=0 "msg"="discovered OIDC audience"  "audience"="test-audience"`,
+				`"level"=0 "msg"="discovered OIDC claims"  "groups"="roles" "username"="email"`,
+			},
+			wantStdout: here.Doc(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=jwt
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --request-audience=test-audience
+        		      - --username-claim=email
+        		      - --groups-claim=roles
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`),
+		},
+		{
+			name: "autodetect JWT authenticator with a trailing slash on the issuer",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				testutil.NewJWTAuthenticator("test-authenticator", "https://example.com/issuer/", "test-audience", testOIDCCA.Bundle()),
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer/"`,
+				`"level"=0 "msg"="discovered OIDC audience"  "audience"="test-audience"`,
+				`"level"=0 "msg"="discovered OIDC CA bundle"  "roots"=1`,
+			},
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=jwt
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --ca-bundle-data=%s
+        		      - --request-audience=test-audience
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`, base64.StdEncoding.EncodeToString(testOIDCCA.Bundle())),
+		},
+		{
+			name: "autodetect JWT authenticator with a trailing slash on the issuer and --oidc-issuer-no-normalize",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--oidc-issuer-no-normalize",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				testutil.NewJWTAuthenticator("test-authenticator", "https://example.com/issuer/", "test-audience", testOIDCCA.Bundle()),
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer/"`,
+				`"level"=0 "msg"="discovered OIDC audience"  "audience"="test-audience"`,
+				`"level"=0 "msg"="discovered OIDC CA bundle"  "roots"=1`,
+			},
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=jwt
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --issuer=https://example.com/issuer/
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --ca-bundle-data=%s
+        		      - --request-audience=test-audience
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`, base64.StdEncoding.EncodeToString(testOIDCCA.Bundle())),
+		},
+		{
+			name: "explicit --oidc-issuer with a trailing slash",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--oidc-issuer", "https://example.com/explicit-issuer/",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				testutil.NewJWTAuthenticator("test-authenticator", "https://example.com/issuer", "test-audience", testOIDCCA.Bundle()),
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="discovered OIDC audience"  "audience"="test-audience"`,
+				`"level"=0 "msg"="discovered OIDC CA bundle"  "roots"=1`,
+			},
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=jwt
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --issuer=https://example.com/explicit-issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --ca-bundle-data=%s
+        		      - --request-audience=test-audience
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`, base64.StdEncoding.EncodeToString(testOIDCCA.Bundle())),
+		},
+		{
+			name: "autodetect JWT authenticator with --concierge-authenticator-audience override",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-authenticator-audience", "override-audience",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				testutil.NewJWTAuthenticator("test-authenticator", "https://example.com/issuer", "test-audience", testOIDCCA.Bundle()),
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer"`,
+				`"level"=0 "msg"="discovered OIDC CA bundle"  "roots"=1`,
+			},
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=jwt
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --ca-bundle-data=%s
+        		      - --request-audience=override-audience
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`, base64.StdEncoding.EncodeToString(testOIDCCA.Bundle())),
+		},
+		{
+			name: "autodetect JWT authenticator with empty audience",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.JWTAuthenticator{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"},
+					Spec: conciergev1alpha1.JWTAuthenticatorSpec{
+						Issuer: "https://example.com/issuer",
+						TLS: &conciergev1alpha1.TLSSpec{
+							CertificateAuthorityData: base64.StdEncoding.EncodeToString(testOIDCCA.Bundle()),
+						},
+					},
+				},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer"`,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: tried to autodiscover --oidc-request-audience, but JWTAuthenticator test-authenticator has empty spec.audience (use --oidc-request-audience to override)
+			`),
+		},
+		{
+			name: "autodetect JWT authenticator with http issuer",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.JWTAuthenticator{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"},
+					Spec: conciergev1alpha1.JWTAuthenticatorSpec{
+						Issuer:   "http://example.com/issuer",
+						Audience: "test-audience",
+					},
+				},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: JWTAuthenticator test-authenticator has spec.issuer "http://example.com/issuer", but it must be an absolute https URL so that tokens are not sent over plaintext
+			`),
+		},
+		{
+			name: "autodetect webhook authenticator with http endpoint",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--skip-validation",
+				"--static-token", "test-token",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"},
+					Spec:       conciergev1alpha1.WebhookAuthenticatorSpec{Endpoint: "http://example.com/webhook"},
+				},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: WebhookAuthenticator test-authenticator has spec.endpoint "http://example.com/webhook", but it must be an absolute https URL so that tokens are not sent over plaintext
+			`),
+		},
+		{
+			name: "autodetect JWT authenticator with empty audience and --oidc-request-audience override",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--oidc-request-audience", "override-audience",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.JWTAuthenticator{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"},
+					Spec: conciergev1alpha1.JWTAuthenticatorSpec{
+						Issuer: "https://example.com/issuer",
+						TLS: &conciergev1alpha1.TLSSpec{
+							CertificateAuthorityData: base64.StdEncoding.EncodeToString(testOIDCCA.Bundle()),
+						},
+					},
+				},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer"`,
+				`"level"=0 "msg"="discovered OIDC CA bundle"  "roots"=1`,
+			},
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=jwt
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --ca-bundle-data=%s
+        		      - --request-audience=override-audience
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`, base64.StdEncoding.EncodeToString(testOIDCCA.Bundle())),
+		},
+		{
+			name: "autodetect nothing, set a bunch of options",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-credential-issuer", "test-credential-issuer",
+				"--concierge-api-group-suffix", "tuna.io",
+				"--concierge-authenticator-type", "webhook",
+				"--concierge-authenticator-name", "test-authenticator",
+				"--concierge-mode", "TokenCredentialRequestAPI",
+				"--concierge-endpoint", "https://explicit-concierge-endpoint.example.com",
+				"--concierge-ca-bundle", testConciergeCABundlePath,
+				"--oidc-issuer", "https://example.com/issuer",
+				"--oidc-skip-browser",
+				"--oidc-listen-port", "1234",
+				"--oidc-redirect-uri-path", "/some/callback",
+				"--oidc-ca-bundle", testOIDCCABundlePath,
+				"--oidc-session-cache", "/path/to/cache/dir/sessions.yaml",
+				"--oidc-debug-session-cache",
+				"--oidc-request-audience", "test-audience",
+				"--upstream-identity-provider-name", "some-ldap-idp",
+				"--upstream-identity-provider-type", "ldap",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: "dGVzdC10Y3ItYXBpLWNh",
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"},
+				},
+			},
+			wantLogs: nil,
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: %s
+        		    server: https://explicit-concierge-endpoint.example.com
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=tuna.io
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=webhook
+        		      - --concierge-endpoint=https://explicit-concierge-endpoint.example.com
+        		      - --concierge-ca-bundle-data=%s
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --skip-browser
+        		      - --listen-port=1234
+        		      - --redirect-uri-path=/some/callback
+        		      - --ca-bundle-data=%s
+        		      - --session-cache=/path/to/cache/dir/sessions.yaml
+        		      - --debug-session-cache
+        		      - --request-audience=test-audience
+        		      - --upstream-identity-provider-name=some-ldap-idp
+        		      - --upstream-identity-provider-type=ldap
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`,
+				base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+				base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+				base64.StdEncoding.EncodeToString(testOIDCCA.Bundle()),
+			),
+			wantAPIGroupSuffix: "tuna.io",
+		},
+		{
+			name: "autodetect nothing, set --concierge-ca-bundle-data",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-credential-issuer", "test-credential-issuer",
+				"--concierge-authenticator-type", "webhook",
+				"--concierge-authenticator-name", "test-authenticator",
+				"--concierge-mode", "TokenCredentialRequestAPI",
+				"--concierge-endpoint", "https://explicit-concierge-endpoint.example.com",
+				"--concierge-ca-bundle-data", base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+				"--oidc-issuer", "https://example.com/issuer",
+				"--oidc-request-audience", "test-audience",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: "dGVzdC10Y3ItYXBpLWNh",
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"},
+				},
+			},
+			wantLogs: nil,
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: %s
+        		    server: https://explicit-concierge-endpoint.example.com
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=webhook
+        		      - --concierge-endpoint=https://explicit-concierge-endpoint.example.com
+        		      - --concierge-ca-bundle-data=%s
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --request-audience=test-audience
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`,
+				base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+				base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+			),
+		},
+		{
+			name: "autodetect nothing, set --oidc-browser-command",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-credential-issuer", "test-credential-issuer",
+				"--concierge-authenticator-type", "webhook",
+				"--concierge-authenticator-name", "test-authenticator",
+				"--concierge-mode", "TokenCredentialRequestAPI",
+				"--concierge-endpoint", "https://explicit-concierge-endpoint.example.com",
+				"--concierge-ca-bundle-data", base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+				"--oidc-issuer", "https://example.com/issuer",
+				"--oidc-request-audience", "test-audience",
+				"--oidc-browser-command", "wslview",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: "dGVzdC10Y3ItYXBpLWNh",
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"},
+				},
+			},
+			wantLogs: nil,
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: %s
+        		    server: https://explicit-concierge-endpoint.example.com
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=webhook
+        		      - --concierge-endpoint=https://explicit-concierge-endpoint.example.com
+        		      - --concierge-ca-bundle-data=%s
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --browser-command=wslview
+        		      - --request-audience=test-audience
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`,
+				base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+				base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+			),
+		},
+		{
+			name: "autodetect nothing, use a shared CA bundle for both the OIDC issuer and the Concierge",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-credential-issuer", "test-credential-issuer",
+				"--concierge-authenticator-type", "webhook",
+				"--concierge-authenticator-name", "test-authenticator",
+				"--concierge-mode", "TokenCredentialRequestAPI",
+				"--concierge-endpoint", "https://explicit-concierge-endpoint.example.com",
+				"--oidc-issuer", "https://example.com/issuer",
+				"--ca-bundle", testConciergeCABundlePath,
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: "dGVzdC10Y3ItYXBpLWNh",
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"},
+				},
+			},
+			wantLogs: nil,
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: %s
+        		    server: https://explicit-concierge-endpoint.example.com
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=webhook
+        		      - --concierge-endpoint=https://explicit-concierge-endpoint.example.com
+        		      - --concierge-ca-bundle-data=%s
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --ca-bundle-data=%s
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`,
+				base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+				base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+				base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+			),
+		},
+		{
+			name: "autodetect nothing, override a shared CA bundle with a more specific OIDC CA bundle",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-credential-issuer", "test-credential-issuer",
+				"--concierge-authenticator-type", "webhook",
+				"--concierge-authenticator-name", "test-authenticator",
+				"--concierge-mode", "TokenCredentialRequestAPI",
+				"--concierge-endpoint", "https://explicit-concierge-endpoint.example.com",
+				"--oidc-issuer", "https://example.com/issuer",
+				"--ca-bundle", testConciergeCABundlePath,
+				"--oidc-ca-bundle", testOIDCCABundlePath,
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+								TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+									Server:                   "https://concierge-endpoint.example.com",
+									CertificateAuthorityData: "dGVzdC10Y3ItYXBpLWNh",
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"},
+				},
+			},
+			wantLogs: nil,
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: %s
+        		    server: https://explicit-concierge-endpoint.example.com
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=webhook
+        		      - --concierge-endpoint=https://explicit-concierge-endpoint.example.com
+        		      - --concierge-ca-bundle-data=%s
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --ca-bundle-data=%s
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`,
+				base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+				base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+				base64.StdEncoding.EncodeToString(testOIDCCA.Bundle()),
+			),
+		},
+		{
+			name: "configure impersonation proxy with autodiscovered JWT authenticator",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-mode", "ImpersonationProxy",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{
+							// This TokenCredentialRequestAPI strategy would normally be chosen, but
+							// --concierge-mode=ImpersonationProxy should force it to be skipped.
+							{
+								Type:           "SomeType",
+								Status:         configv1alpha1.SuccessStrategyStatus,
+								Reason:         "SomeReason",
+								Message:        "Some message",
+								LastUpdateTime: metav1.Now(),
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{
+									Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+									TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+										Server:                   "https://token-credential-request-api-endpoint.test",
+										CertificateAuthorityData: "dGVzdC10Y3ItYXBpLWNh",
+									},
+								},
+							},
+							// The endpoint and CA from this impersonation proxy strategy should be autodiscovered.
+							{
+								Type:           "SomeOtherType",
+								Status:         configv1alpha1.SuccessStrategyStatus,
+								Reason:         "SomeOtherReason",
+								Message:        "Some other message",
+								LastUpdateTime: metav1.Now(),
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{
+									Type: configv1alpha1.ImpersonationProxyFrontendType,
+									ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+										Endpoint:                 "https://impersonation-proxy-endpoint.test",
+										CertificateAuthorityData: base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+									},
+								},
+							},
+						},
+					},
+				},
+				testutil.NewJWTAuthenticator("test-authenticator", "https://example.com/issuer", "test-audience", testOIDCCA.Bundle()),
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://impersonation-proxy-endpoint.test"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=1`,
+				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer"`,
+				`"level"=0 "msg"="discovered OIDC audience"  "audience"="test-audience"`,
+				`"level"=0 "msg"="discovered OIDC CA bundle"  "roots"=1`,
+			},
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: %s
+        		    server: https://impersonation-proxy-endpoint.test
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=jwt
+        		      - --concierge-endpoint=https://impersonation-proxy-endpoint.test
+        		      - --concierge-ca-bundle-data=%s
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --ca-bundle-data=%s
+        		      - --request-audience=test-audience
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`,
+				base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+				base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+				base64.StdEncoding.EncodeToString(testOIDCCA.Bundle()),
+			),
+		},
+		{
+			name: "logs CA subjects at higher verbosity",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-mode", "ImpersonationProxy",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:           "SomeType",
+							Status:         configv1alpha1.SuccessStrategyStatus,
+							Reason:         "SomeReason",
+							Message:        "Some message",
+							LastUpdateTime: metav1.Now(),
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.ImpersonationProxyFrontendType,
+								ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+									Endpoint:                 "https://impersonation-proxy-endpoint.test",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(append(testConciergeCA.Bundle(), testOtherConciergeCA.Bundle()...)),
+								},
+							},
+						}},
+					},
+				},
+				testutil.NewJWTAuthenticator("test-authenticator", "https://example.com/issuer", "test-audience", testOIDCCA.Bundle()),
+			},
+			logVerbosity: 1,
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://impersonation-proxy-endpoint.test"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=2`,
+				`"level"=1 "msg"="discovered Concierge certificate authority subjects"  "subjects"=["CN=Test Concierge CA","CN=Test Other Concierge CA"]`,
+				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer"`,
+				`"level"=0 "msg"="discovered OIDC audience"  "audience"="test-audience"`,
+				`"level"=0 "msg"="discovered OIDC CA bundle"  "roots"=1`,
+				`"level"=1 "msg"="discovered OIDC CA subjects"  "subjects"=["CN=Test CA"]`,
+			},
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: %s
+        		    server: https://impersonation-proxy-endpoint.test
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=jwt
+        		      - --concierge-endpoint=https://impersonation-proxy-endpoint.test
+        		      - --concierge-ca-bundle-data=%s
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --ca-bundle-data=%s
+        		      - --request-audience=test-audience
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`,
+				base64.StdEncoding.EncodeToString(append(testConciergeCA.Bundle(), testOtherConciergeCA.Bundle()...)),
+				base64.StdEncoding.EncodeToString(append(testConciergeCA.Bundle(), testOtherConciergeCA.Bundle()...)),
+				base64.StdEncoding.EncodeToString(testOIDCCA.Bundle()),
+			),
+		},
+		{
+			name: "lowercase --concierge-mode resolves to the canonical mode",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-mode", "impersonationproxy",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:           "SomeType",
+							Status:         configv1alpha1.SuccessStrategyStatus,
+							Reason:         "SomeReason",
+							Message:        "Some message",
+							LastUpdateTime: metav1.Now(),
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.ImpersonationProxyFrontendType,
+								ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+									Endpoint:                 "https://impersonation-proxy-endpoint.test",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(append(testConciergeCA.Bundle(), testOtherConciergeCA.Bundle()...)),
+								},
+							},
+						}},
+					},
+				},
+				testutil.NewJWTAuthenticator("test-authenticator", "https://example.com/issuer", "test-audience", testOIDCCA.Bundle()),
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://impersonation-proxy-endpoint.test"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=2`,
+				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer"`,
+				`"level"=0 "msg"="discovered OIDC audience"  "audience"="test-audience"`,
+				`"level"=0 "msg"="discovered OIDC CA bundle"  "roots"=1`,
+			},
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: %s
+        		    server: https://impersonation-proxy-endpoint.test
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=jwt
+        		      - --concierge-endpoint=https://impersonation-proxy-endpoint.test
+        		      - --concierge-ca-bundle-data=%s
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --ca-bundle-data=%s
+        		      - --request-audience=test-audience
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`,
+				base64.StdEncoding.EncodeToString(append(testConciergeCA.Bundle(), testOtherConciergeCA.Bundle()...)),
+				base64.StdEncoding.EncodeToString(append(testConciergeCA.Bundle(), testOtherConciergeCA.Bundle()...)),
+				base64.StdEncoding.EncodeToString(testOIDCCA.Bundle()),
+			),
+		},
+		{
+			name: "logs CA subjects with --verbose",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-mode", "ImpersonationProxy",
+				"--skip-validation",
+				"--verbose", "1",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:           "SomeType",
+							Status:         configv1alpha1.SuccessStrategyStatus,
+							Reason:         "SomeReason",
+							Message:        "Some message",
+							LastUpdateTime: metav1.Now(),
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.ImpersonationProxyFrontendType,
+								ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+									Endpoint:                 "https://impersonation-proxy-endpoint.test",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString(append(testConciergeCA.Bundle(), testOtherConciergeCA.Bundle()...)),
+								},
+							},
+						}},
+					},
+				},
+				testutil.NewJWTAuthenticator("test-authenticator", "https://example.com/issuer", "test-audience", testOIDCCA.Bundle()),
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://impersonation-proxy-endpoint.test"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=2`,
+				`"level"=0 "msg"="discovered Concierge certificate authority subjects"  "subjects"=["CN=Test Concierge CA","CN=Test Other Concierge CA"]`,
+				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer"`,
+				`"level"=0 "msg"="discovered OIDC audience"  "audience"="test-audience"`,
+				`"level"=0 "msg"="discovered OIDC CA bundle"  "roots"=1`,
+				`"level"=0 "msg"="discovered OIDC CA subjects"  "subjects"=["CN=Test CA"]`,
+			},
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: %s
+        		    server: https://impersonation-proxy-endpoint.test
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=jwt
+        		      - --concierge-endpoint=https://impersonation-proxy-endpoint.test
+        		      - --concierge-ca-bundle-data=%s
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --ca-bundle-data=%s
+        		      - --request-audience=test-audience
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`,
+				base64.StdEncoding.EncodeToString(append(testConciergeCA.Bundle(), testOtherConciergeCA.Bundle()...)),
+				base64.StdEncoding.EncodeToString(append(testConciergeCA.Bundle(), testOtherConciergeCA.Bundle()...)),
+				base64.StdEncoding.EncodeToString(testOIDCCA.Bundle()),
+			),
+		},
+		{
+			name: "skips malformed impersonation proxy strategies",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{
+							{
+								Type:           "MissingInfoType",
+								Status:         configv1alpha1.SuccessStrategyStatus,
+								Reason:         "SomeReason",
+								Message:        "Some message",
+								LastUpdateTime: metav1.Now(),
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{
+									Type: configv1alpha1.ImpersonationProxyFrontendType,
+								},
+							},
+							{
+								Type:           "EmptyEndpointType",
+								Status:         configv1alpha1.SuccessStrategyStatus,
+								Reason:         "SomeReason",
+								Message:        "Some message",
+								LastUpdateTime: metav1.Now(),
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{
+									Type:                   configv1alpha1.ImpersonationProxyFrontendType,
+									ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{},
+								},
+							},
+							{
+								Type:           "SomeType",
+								Status:         configv1alpha1.SuccessStrategyStatus,
+								Reason:         "SomeReason",
+								Message:        "Some message",
+								LastUpdateTime: metav1.Now(),
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{
+									Type: configv1alpha1.ImpersonationProxyFrontendType,
+									ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+										Endpoint:                 "https://impersonation-proxy-endpoint.test",
+										CertificateAuthorityData: base64.StdEncoding.EncodeToString(append(testConciergeCA.Bundle(), testOtherConciergeCA.Bundle()...)),
+									},
+								},
+							},
+						},
+					},
+				},
+				testutil.NewJWTAuthenticator("test-authenticator", "https://example.com/issuer", "test-audience", testOIDCCA.Bundle()),
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="warning: ignoring Concierge impersonation proxy strategy with no endpoint"  "type"="MissingInfoType"`,
+				`"level"=0 "msg"="warning: ignoring Concierge impersonation proxy strategy with no endpoint"  "type"="EmptyEndpointType"`,
+				`"level"=0 "msg"="discovered Concierge operating in impersonation proxy mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://impersonation-proxy-endpoint.test"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=2`,
+				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer"`,
+				`"level"=0 "msg"="discovered OIDC audience"  "audience"="test-audience"`,
+				`"level"=0 "msg"="discovered OIDC CA bundle"  "roots"=1`,
+			},
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: %s
+        		    server: https://impersonation-proxy-endpoint.test
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=jwt
+        		      - --concierge-endpoint=https://impersonation-proxy-endpoint.test
+        		      - --concierge-ca-bundle-data=%s
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --ca-bundle-data=%s
+        		      - --request-audience=test-audience
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`,
+				base64.StdEncoding.EncodeToString(append(testConciergeCA.Bundle(), testOtherConciergeCA.Bundle()...)),
+				base64.StdEncoding.EncodeToString(append(testConciergeCA.Bundle(), testOtherConciergeCA.Bundle()...)),
+				base64.StdEncoding.EncodeToString(testOIDCCA.Bundle()),
+			),
+		},
+		{
+			name: "autodetect impersonation proxy with autodiscovered JWT authenticator",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{
+							{
+								Type:           "SomeType",
+								Status:         configv1alpha1.SuccessStrategyStatus,
+								Reason:         "SomeReason",
+								Message:        "Some message",
+								LastUpdateTime: metav1.Now(),
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{
+									Type: configv1alpha1.ImpersonationProxyFrontendType,
+									ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+										Endpoint:                 "https://impersonation-proxy-endpoint.test",
+										CertificateAuthorityData: "dGVzdC1jb25jaWVyZ2UtY2E=",
+									},
+								},
+							},
+							{
+								Type:           "SomeOtherType",
+								Status:         configv1alpha1.SuccessStrategyStatus,
+								Reason:         "SomeOtherReason",
+								Message:        "Some other message",
+								LastUpdateTime: metav1.Now(),
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{
+									Type: configv1alpha1.ImpersonationProxyFrontendType,
+									ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+										Endpoint:                 "https://some-other-impersonation-endpoint",
+										CertificateAuthorityData: "dGVzdC1jb25jaWVyZ2UtY2E=",
+									},
+								},
+							},
+						},
+					},
+				},
+				testutil.NewJWTAuthenticator("test-authenticator", "https://example.com/issuer", "test-audience", testOIDCCA.Bundle()),
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered multiple impersonation proxy endpoints, using the first one (use --concierge-impersonation-endpoint to choose another)"  "alternatives"=["https://some-other-impersonation-endpoint"] "endpoint"="https://impersonation-proxy-endpoint.test"`,
+				`"level"=0 "msg"="discovered Concierge operating in impersonation proxy mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://impersonation-proxy-endpoint.test"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer"`,
+				`"level"=0 "msg"="discovered OIDC audience"  "audience"="test-audience"`,
+				`"level"=0 "msg"="discovered OIDC CA bundle"  "roots"=1`,
+			},
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: dGVzdC1jb25jaWVyZ2UtY2E=
+        		    server: https://impersonation-proxy-endpoint.test
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=jwt
+        		      - --concierge-endpoint=https://impersonation-proxy-endpoint.test
+        		      - --concierge-ca-bundle-data=dGVzdC1jb25jaWVyZ2UtY2E=
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --ca-bundle-data=%s
+        		      - --request-audience=test-audience
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`, base64.StdEncoding.EncodeToString(testOIDCCA.Bundle())),
+		},
+		{
+			name: "pinned --cluster-ca-bundle with autodiscovered impersonation proxy",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--skip-validation",
+				"--cluster-ca-bundle", testConciergeCABundlePath,
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{
+							{
+								Type:           "SomeType",
+								Status:         configv1alpha1.SuccessStrategyStatus,
+								Reason:         "SomeReason",
+								Message:        "Some message",
+								LastUpdateTime: metav1.Now(),
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{
+									Type: configv1alpha1.ImpersonationProxyFrontendType,
+									ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+										Endpoint:                 "https://impersonation-proxy-endpoint.test",
+										CertificateAuthorityData: "dGVzdC1jb25jaWVyZ2UtY2E=",
+									},
+								},
+							},
+							{
+								Type:           "SomeOtherType",
+								Status:         configv1alpha1.SuccessStrategyStatus,
+								Reason:         "SomeOtherReason",
+								Message:        "Some other message",
+								LastUpdateTime: metav1.Now(),
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{
+									Type: configv1alpha1.ImpersonationProxyFrontendType,
+									ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+										Endpoint:                 "https://some-other-impersonation-endpoint",
+										CertificateAuthorityData: "dGVzdC1jb25jaWVyZ2UtY2E=",
+									},
+								},
+							},
+						},
+					},
+				},
+				testutil.NewJWTAuthenticator("test-authenticator", "https://example.com/issuer", "test-audience", testOIDCCA.Bundle()),
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered multiple impersonation proxy endpoints, using the first one (use --concierge-impersonation-endpoint to choose another)"  "alternatives"=["https://some-other-impersonation-endpoint"] "endpoint"="https://impersonation-proxy-endpoint.test"`,
+				`"level"=0 "msg"="discovered Concierge operating in impersonation proxy mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://impersonation-proxy-endpoint.test"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer"`,
+				`"level"=0 "msg"="discovered OIDC audience"  "audience"="test-audience"`,
+				`"level"=0 "msg"="discovered OIDC CA bundle"  "roots"=1`,
+			},
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: %s
+        		    server: https://impersonation-proxy-endpoint.test
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=jwt
+        		      - --concierge-endpoint=https://impersonation-proxy-endpoint.test
+        		      - --concierge-ca-bundle-data=dGVzdC1jb25jaWVyZ2UtY2E=
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --ca-bundle-data=%s
+        		      - --request-audience=test-audience
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`,
+				base64.StdEncoding.EncodeToString(testConciergeCA.Bundle()),
+				base64.StdEncoding.EncodeToString(testOIDCCA.Bundle()),
+			),
+		},
+		{
+			name: "autodetect impersonation proxy with --concierge-impersonation-endpoint preference",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-impersonation-endpoint", "https://some-other-impersonation-endpoint",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{
+							{
+								Type:           "SomeType",
+								Status:         configv1alpha1.SuccessStrategyStatus,
+								Reason:         "SomeReason",
+								Message:        "Some message",
+								LastUpdateTime: metav1.Now(),
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{
+									Type: configv1alpha1.ImpersonationProxyFrontendType,
+									ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+										Endpoint:                 "https://impersonation-proxy-endpoint.test",
+										CertificateAuthorityData: "dGVzdC1jb25jaWVyZ2UtY2E=",
+									},
+								},
+							},
+							{
+								Type:           "SomeOtherType",
+								Status:         configv1alpha1.SuccessStrategyStatus,
+								Reason:         "SomeOtherReason",
+								Message:        "Some other message",
+								LastUpdateTime: metav1.Now(),
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{
+									Type: configv1alpha1.ImpersonationProxyFrontendType,
+									ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+										Endpoint:                 "https://some-other-impersonation-endpoint",
+										CertificateAuthorityData: "dGVzdC1jb25jaWVyZ2UtY2E=",
+									},
+								},
+							},
+						},
+					},
+				},
+				testutil.NewJWTAuthenticator("test-authenticator", "https://example.com/issuer", "test-audience", testOIDCCA.Bundle()),
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in impersonation proxy mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://some-other-impersonation-endpoint"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered JWTAuthenticator"  "name"="test-authenticator"`,
+				`"level"=0 "msg"="discovered OIDC issuer"  "issuer"="https://example.com/issuer"`,
+				`"level"=0 "msg"="discovered OIDC audience"  "audience"="test-audience"`,
+				`"level"=0 "msg"="discovered OIDC CA bundle"  "roots"=1`,
+			},
+			wantStdout: here.Docf(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: dGVzdC1jb25jaWVyZ2UtY2E=
+        		    server: https://some-other-impersonation-endpoint
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - oidc
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-authenticator
+        		      - --concierge-authenticator-type=jwt
+        		      - --concierge-endpoint=https://some-other-impersonation-endpoint
+        		      - --concierge-ca-bundle-data=dGVzdC1jb25jaWVyZ2UtY2E=
+        		      - --issuer=https://example.com/issuer
+        		      - --client-id=pinniped-cli
+        		      - --scopes=offline_access,openid,pinniped:request-audience
+        		      - --ca-bundle-data=%s
+        		      - --request-audience=test-audience
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`, base64.StdEncoding.EncodeToString(testOIDCCA.Bundle())),
+		},
+		{
+			name: "--concierge-impersonation-endpoint does not match any impersonation proxy",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-impersonation-endpoint", "https://not-an-impersonation-endpoint",
+				"--skip-validation",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{
+							{
+								Type:           "SomeType",
+								Status:         configv1alpha1.SuccessStrategyStatus,
+								Reason:         "SomeReason",
+								Message:        "Some message",
+								LastUpdateTime: metav1.Now(),
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{
+									Type: configv1alpha1.ImpersonationProxyFrontendType,
+									ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+										Endpoint:                 "https://impersonation-proxy-endpoint.test",
+										CertificateAuthorityData: "dGVzdC1jb25jaWVyZ2UtY2E=",
+									},
+								},
+							},
+							{
+								Type:           "SomeOtherType",
+								Status:         configv1alpha1.SuccessStrategyStatus,
+								Reason:         "SomeOtherReason",
+								Message:        "Some other message",
+								LastUpdateTime: metav1.Now(),
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{
+									Type: configv1alpha1.ImpersonationProxyFrontendType,
+									ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+										Endpoint:                 "https://some-other-impersonation-endpoint",
+										CertificateAuthorityData: "dGVzdC1jb25jaWVyZ2UtY2E=",
+									},
+								},
+							},
+						},
+					},
+				},
+				testutil.NewJWTAuthenticator("test-authenticator", "https://example.com/issuer", "test-audience", testOIDCCA.Bundle()),
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="found CredentialIssuer strategy"  "message"="Some message" "reason"="SomeReason" "status"="Success" "type"="SomeType"`,
+				`"level"=0 "msg"="found CredentialIssuer strategy"  "message"="Some other message" "reason"="SomeOtherReason" "status"="Success" "type"="SomeOtherType"`,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: could not find successful Concierge impersonation proxy strategy matching --concierge-impersonation-endpoint=https://not-an-impersonation-endpoint
+			`),
+		},
+		{
+			name: "--concierge-frontend-type selects the first ImpersonationProxy strategy",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--skip-validation",
+				"--concierge-frontend-type", "ImpersonationProxy",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{
+							{
+								Type:           configv1alpha1.KubeClusterSigningCertificateStrategyType,
+								Status:         configv1alpha1.SuccessStrategyStatus,
+								Reason:         configv1alpha1.FetchedKeyStrategyReason,
+								Message:        "Some message",
+								LastUpdateTime: metav1.Now(),
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{
+									Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+									TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+										Server:                   "https://concierge-endpoint.example.com",
+										CertificateAuthorityData: "dGVzdC10Y3ItYXBpLWNh",
+									},
+								},
+							},
+							{
+								Type:           configv1alpha1.ImpersonationProxyStrategyType,
+								Status:         configv1alpha1.SuccessStrategyStatus,
+								Reason:         configv1alpha1.ListeningStrategyReason,
+								Message:        "Some other message",
+								LastUpdateTime: metav1.Now(),
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{
+									Type: configv1alpha1.ImpersonationProxyFrontendType,
+									ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+										Endpoint:                 "https://impersonation-proxy-endpoint.test",
+										CertificateAuthorityData: "dGVzdC1jb25jaWVyZ2UtY2E=",
+									},
+								},
+							},
+						},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-webhook-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in impersonation proxy mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://impersonation-proxy-endpoint.test"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-webhook-authenticator"`,
+			},
+			wantStdout: here.Doc(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: dGVzdC1jb25jaWVyZ2UtY2E=
+        		    server: https://impersonation-proxy-endpoint.test
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - static
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-webhook-authenticator
+        		      - --concierge-authenticator-type=webhook
+        		      - --concierge-endpoint=https://impersonation-proxy-endpoint.test
+        		      - --concierge-ca-bundle-data=dGVzdC1jb25jaWVyZ2UtY2E=
+        		      - --token=test-token
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`),
+		},
+		{
+			name: "--concierge-frontend-type selects the first TokenCredentialRequestAPI strategy, case insensitively",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--skip-validation",
+				"--concierge-frontend-type", "tokencredentialrequestapi",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{
+							{
+								Type:           configv1alpha1.ImpersonationProxyStrategyType,
+								Status:         configv1alpha1.SuccessStrategyStatus,
+								Reason:         configv1alpha1.ListeningStrategyReason,
+								Message:        "Some other message",
+								LastUpdateTime: metav1.Now(),
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{
+									Type: configv1alpha1.ImpersonationProxyFrontendType,
+									ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+										Endpoint:                 "https://impersonation-proxy-endpoint.test",
+										CertificateAuthorityData: "dGVzdC1jb25jaWVyZ2UtY2E=",
+									},
+								},
+							},
+							{
+								Type:           configv1alpha1.KubeClusterSigningCertificateStrategyType,
+								Status:         configv1alpha1.SuccessStrategyStatus,
+								Reason:         configv1alpha1.FetchedKeyStrategyReason,
+								Message:        "Some message",
+								LastUpdateTime: metav1.Now(),
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{
+									Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+									TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+										Server:                   "https://concierge-endpoint.example.com",
+										CertificateAuthorityData: "dGVzdC10Y3ItYXBpLWNh",
+									},
+								},
+							},
+						},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-webhook-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="discovered Concierge operating in TokenCredentialRequest API mode"`,
+				`"level"=0 "msg"="discovered Concierge endpoint"  "endpoint"="https://fake-server-url-value"`,
+				`"level"=0 "msg"="discovered Concierge certificate authority bundle"  "roots"=0`,
+				`"level"=0 "msg"="warning: autodiscovered Concierge CA bundle does not contain any certificates, so the connection to the Concierge will likely fail"`,
+				`"level"=0 "msg"="discovered WebhookAuthenticator"  "name"="test-webhook-authenticator"`,
+			},
+			wantStdout: here.Doc(`
+        		apiVersion: v1
+        		clusters:
+        		- cluster:
+        		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		    server: https://fake-server-url-value
+        		  name: pinniped
+        		contexts:
+        		- context:
+        		    cluster: pinniped
+        		    user: pinniped
+        		  name: pinniped
+        		current-context: pinniped
+        		kind: Config
+        		preferences: {}
+        		users:
+        		- name: pinniped
+        		  user:
+        		    exec:
+        		      apiVersion: client.authentication.k8s.io/v1beta1
+        		      args:
+        		      - login
+        		      - static
+        		      - --enable-concierge
+        		      - --concierge-api-group-suffix=pinniped.dev
+        		      - --concierge-authenticator-name=test-webhook-authenticator
+        		      - --concierge-authenticator-type=webhook
+        		      - --concierge-endpoint=https://fake-server-url-value
+        		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+        		      - --token=test-token
+        		      command: '.../path/to/pinniped'
+        		      env: []
+        		      provideClusterInfo: true
+			`),
+		},
+		{
+			name: "--concierge-frontend-type does not match any successful strategy",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--static-token", "test-token",
+				"--skip-validation",
+				"--concierge-frontend-type", "ImpersonationProxy",
+			},
+			conciergeObjects: []runtime.Object{
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{
+							{
+								Type:           configv1alpha1.KubeClusterSigningCertificateStrategyType,
+								Status:         configv1alpha1.SuccessStrategyStatus,
+								Reason:         configv1alpha1.FetchedKeyStrategyReason,
+								Message:        "Some message",
+								LastUpdateTime: metav1.Now(),
+								Frontend: &configv1alpha1.CredentialIssuerFrontend{
+									Type: configv1alpha1.TokenCredentialRequestAPIFrontendType,
+									TokenCredentialRequestAPIInfo: &configv1alpha1.TokenCredentialRequestAPIInfo{
+										Server:                   "https://concierge-endpoint.example.com",
+										CertificateAuthorityData: "dGVzdC10Y3ItYXBpLWNh",
+									},
+								},
+							},
+						},
+					},
+				},
+				&conciergev1alpha1.WebhookAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-webhook-authenticator"}},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+				`"level"=0 "msg"="found CredentialIssuer strategy"  "message"="Some message" "reason"="FetchedKey" "status"="Success" "type"="KubeClusterSigningCertificate"`,
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: could not find successful Concierge strategy matching --concierge-frontend-type=ImpersonationProxy
+			`),
+		},
+		{
+			name: "invalid --concierge-frontend-type",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-frontend-type", "LDAP",
+			},
+			getClientsetErr: fmt.Errorf("some error configuring clientset"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: invalid --concierge-frontend-type "LDAP", supported values are "TokenCredentialRequestAPI" and "ImpersonationProxy"
+			`),
+		},
+		{
+			name: "--concierge-frontend-type and --concierge-mode",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-frontend-type", "ImpersonationProxy",
+				"--concierge-mode", "ImpersonationProxy",
+			},
+			getClientsetErr: fmt.Errorf("some error configuring clientset"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: --concierge-mode and --concierge-frontend-type cannot be used together
+			`),
+		},
+		{
+			name: "invalid --concierge-impersonation-endpoint",
+			args: []string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--concierge-impersonation-endpoint", "http://impersonation-proxy-endpoint.test",
+			},
+			getClientsetErr: fmt.Errorf("some error configuring clientset"),
+			wantError:       true,
+			wantStderr: here.Doc(`
+				Error: invalid --concierge-impersonation-endpoint "http://impersonation-proxy-endpoint.test": must be an absolute https URL
+			`),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.logVerbosity != 0 {
+				oldVerbosity := stdr.SetVerbosity(tt.logVerbosity)
+				t.Cleanup(func() { stdr.SetVerbosity(oldVerbosity) })
+			}
+			testLog := testlogger.New(t)
+			cmd := kubeconfigCommand(KubeconfigDeps{
+				selfPath: SelfPathResolverFunc(func() (string, error) {
+					if tt.getPathToSelfErr != nil {
+						return "", tt.getPathToSelfErr
+					}
+					return ".../path/to/pinniped", nil
+				}),
+				getClientset: func(clientConfig clientcmd.ClientConfig, apiGroupSuffix string) (conciergeclientset.Interface, error) {
+					if tt.wantAPIGroupSuffix == "" {
+						require.Equal(t, "pinniped.dev", apiGroupSuffix) // "pinniped.dev" = api group suffix default
+					} else {
+						require.Equal(t, tt.wantAPIGroupSuffix, apiGroupSuffix)
+					}
+					if tt.getClientsetErr != nil {
+						return nil, tt.getClientsetErr
+					}
+					fake := fakeconciergeclientset.NewSimpleClientset(tt.conciergeObjects...)
+					if len(tt.conciergeReactions) > 0 {
+						fake.ReactionChain = append(tt.conciergeReactions, fake.ReactionChain...)
+					}
+					return fake, nil
+				},
+				getenv: func(key string) string { return tt.env[key] },
+				log:    testLog,
+			})
+			require.NotNil(t, cmd)
+
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(tt.args)
+			err := cmd.Execute()
+			if tt.wantError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			testLog.Expect(tt.wantLogs)
+			require.Equal(t, tt.wantStdout, stdout.String(), "unexpected stdout")
+			require.Equal(t, tt.wantStderr, stderr.String(), "unexpected stderr")
+
+			// The --verbose flag only applies to the logger of the command, never to the global stdr verbosity.
+			require.Equal(t, tt.logVerbosity, stdr.SetVerbosity(tt.logVerbosity), "unexpected global log verbosity")
+		})
+	}
+}
+
+func TestGenerateKubeConfig(t *testing.T) {
+	testLog := testlogger.New(t)
+	deps := KubeconfigDeps{
+		selfPath: SelfPathResolverFunc(func() (string, error) { return ".../path/to/pinniped", nil }),
+		getClientset: func(clientConfig clientcmd.ClientConfig, apiGroupSuffix string) (conciergeclientset.Interface, error) {
+			require.Equal(t, "pinniped.dev", apiGroupSuffix)
+			return fakeconciergeclientset.NewSimpleClientset(), nil
+		},
+		getenv: func(string) string { return "" },
+		log:    testLog,
+	}
+
+	params := KubeconfigParams{
+		kubeconfigPath: "./testdata/kubeconfig.yaml",
+		skipValidate:   true,
+		timeout:        time.Minute,
+		staticToken:    "test-token",
+		concierge: getKubeconfigConciergeParams{
+			disabled:       true,
+			apiGroupSuffix: "pinniped.dev",
+		},
+	}
+
+	kubeconfig, result, err := GenerateKubeConfig(context.Background(), params, deps)
+	require.NoError(t, err)
+	testLog.Expect(nil)
+	require.Equal(t, &GenerationResult{}, result)
+
+	require.Equal(t, "pinniped", kubeconfig.CurrentContext)
+	require.Equal(t, "https://fake-server-url-value", kubeconfig.Clusters["pinniped"].Server)
+	require.Equal(t, []byte("fake-certificate-authority-data-value"), kubeconfig.Clusters["pinniped"].CertificateAuthorityData)
+	require.Equal(t, &clientcmdapi.ExecConfig{
+		APIVersion:         "client.authentication.k8s.io/v1beta1",
+		Command:            ".../path/to/pinniped",
+		Args:               []string{"login", "static", "--token=test-token"},
+		Env:                []clientcmdapi.ExecEnvVar{},
+		ProvideClusterInfo: true,
+	}, kubeconfig.AuthInfos["pinniped"].Exec)
+
+	// Errors are returned rather than written anywhere.
+	params.staticTokenEnvName = "TEST_TOKEN"
+	kubeconfig, result, err = GenerateKubeConfig(context.Background(), params, deps)
+	require.EqualError(t, err, "only one of --static-token and --static-token-env can be specified")
+	require.Nil(t, kubeconfig)
+	require.Nil(t, result)
+}
+
+func TestGenerateKubeConfigResult(t *testing.T) {
+	deps := KubeconfigDeps{
+		selfPath: SelfPathResolverFunc(func() (string, error) { return ".../path/to/pinniped", nil }),
+		getClientset: func(clientConfig clientcmd.ClientConfig, apiGroupSuffix string) (conciergeclientset.Interface, error) {
+			return fakeconciergeclientset.NewSimpleClientset(
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.ImpersonationProxyStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.ListeningStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.ImpersonationProxyFrontendType,
+								ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+									Endpoint:                 "https://impersonation-proxy-endpoint.test",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString([]byte("test-ca")),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.JWTAuthenticator{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"},
+					Spec: conciergev1alpha1.JWTAuthenticatorSpec{
+						Issuer:   "https://example.com/issuer",
+						Audience: "test-audience",
+					},
+				},
+			), nil
+		},
+		getenv: func(string) string { return "" },
+		log:    testlogger.New(t),
+	}
+
+	params := KubeconfigParams{
+		kubeconfigPath: "./testdata/kubeconfig.yaml",
+		skipValidate:   true,
+		timeout:        time.Minute,
+		oidc: getKubeconfigOIDCParams{
+			clientID: "pinniped-cli",
+			scopes:   []string{"openid"},
+		},
+		concierge: getKubeconfigConciergeParams{
+			apiGroupSuffix: "pinniped.dev",
+		},
+	}
+
+	kubeconfig, result, err := GenerateKubeConfig(context.Background(), params, deps)
+	require.NoError(t, err)
+	require.NotNil(t, kubeconfig)
+	require.Equal(t, &GenerationResult{
+		Issuer:            "https://example.com/issuer",
+		Audience:          "test-audience",
+		ConciergeEndpoint: "https://impersonation-proxy-endpoint.test",
+		ConciergeMode:     "ImpersonationProxy",
+		AuthenticatorType: "jwt",
+		AuthenticatorName: "test-authenticator",
+	}, result)
+}
+
+func TestNewKubeconfigParams(t *testing.T) {
+	t.Run("defaults match the flags", func(t *testing.T) {
+		params, err := NewKubeconfigParams()
+		require.NoError(t, err)
+		require.Equal(t, os.Getenv("KUBECONFIG"), params.kubeconfigPath)
+		require.Equal(t, 10*time.Minute, params.timeout)
+		require.Equal(t, "pinniped-cli", params.oidc.clientID)
+		require.Equal(t, []string{"offline_access", "openid", "pinniped:request-audience"}, params.oidc.scopes)
+		require.Equal(t, "pinniped.dev", params.concierge.apiGroupSuffix)
+		require.Equal(t, modeUnknown, params.concierge.mode)
+	})
+
+	t.Run("options are applied in order", func(t *testing.T) {
+		params, err := NewKubeconfigParams(
+			WithKubeconfigPath("./testdata/kubeconfig.yaml"),
+			WithKubeconfigContext("some-other-context"),
+			WithTimeout(time.Minute),
+			WithKubeconfigFlag("concierge-mode", "ImpersonationProxy"),
+			WithKubeconfigFlag("exec-env", "KEY1=VALUE1"),
+			WithKubeconfigFlag("exec-env", "KEY2=VALUE2"),
+			WithKubeconfigFlag("oidc-issuer", "https://example.com/first"),
+			WithKubeconfigFlag("oidc-issuer", "https://example.com/second"),
+		)
+		require.NoError(t, err)
+		require.Equal(t, "./testdata/kubeconfig.yaml", params.kubeconfigPath)
+		require.Equal(t, "some-other-context", params.kubeconfigContextOverride)
+		require.Equal(t, time.Minute, params.timeout)
+		require.Equal(t, modeImpersonationProxy, params.concierge.mode)
+		require.Equal(t, []string{"KEY1=VALUE1", "KEY2=VALUE2"}, params.execEnv)
+		require.Equal(t, "https://example.com/second", params.oidc.issuer)
+	})
+
+	t.Run("unknown flag", func(t *testing.T) {
+		_, err := NewKubeconfigParams(WithKubeconfigFlag("not-a-flag", "value"))
+		require.EqualError(t, err, "no such flag -not-a-flag")
+	})
+
+	t.Run("invalid flag value", func(t *testing.T) {
+		_, err := NewKubeconfigParams(WithKubeconfigFlag("concierge-mode", "invalid-mode"))
+		require.EqualError(t, err, `invalid argument "invalid-mode" for "--concierge-mode" flag: invalid mode "invalid-mode", valid modes are TokenCredentialRequestAPI and ImpersonationProxy`)
+	})
+}
+
+func TestGenerateKubeConfigAudit(t *testing.T) {
+	deps := KubeconfigDeps{
+		selfPath: SelfPathResolverFunc(func() (string, error) { return ".../path/to/pinniped", nil }),
+		getClientset: func(clientConfig clientcmd.ClientConfig, apiGroupSuffix string) (conciergeclientset.Interface, error) {
+			return fakeconciergeclientset.NewSimpleClientset(
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.ImpersonationProxyStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.ListeningStrategyReason,
+							Frontend: &configv1alpha1.CredentialIssuerFrontend{
+								Type: configv1alpha1.ImpersonationProxyFrontendType,
+								ImpersonationProxyInfo: &configv1alpha1.ImpersonationProxyInfo{
+									Endpoint:                 "https://impersonation-proxy-endpoint.test",
+									CertificateAuthorityData: base64.StdEncoding.EncodeToString([]byte("test-ca")),
+								},
+							},
+						}},
+					},
+				},
+				&conciergev1alpha1.JWTAuthenticator{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"},
+					Spec: conciergev1alpha1.JWTAuthenticatorSpec{
+						Issuer:   "https://example.com/issuer",
+						Audience: "test-audience",
+					},
+				},
+			), nil
+		},
+		getenv: func(string) string { return "" },
+		log:    testlogger.New(t),
+	}
+	var records []AuditRecord
+	deps = deps.WithAudit(func(record AuditRecord) { records = append(records, record) })
+
+	params, err := NewKubeconfigParams(
+		WithKubeconfigPath("./testdata/kubeconfig.yaml"),
+		WithTimeout(time.Minute),
+		WithKubeconfigFlag("skip-validation", "true"),
+		WithKubeconfigFlag("oidc-scopes", "openid"),
+	)
+	require.NoError(t, err)
+
+	before := time.Now()
+	_, result, err := GenerateKubeConfig(context.Background(), params, deps)
+	require.NoError(t, err)
+	after := time.Now()
+
+	require.Len(t, records, 1)
+	record := records[0]
+	require.Equal(t, *result, record.GenerationResult)
+	require.Equal(t, GenerationResult{
+		Issuer:            "https://example.com/issuer",
+		Audience:          "test-audience",
+		ConciergeEndpoint: "https://impersonation-proxy-endpoint.test",
+		ConciergeMode:     "ImpersonationProxy",
+		AuthenticatorType: "jwt",
+		AuthenticatorName: "test-authenticator",
+	}, record.GenerationResult)
+	require.Equal(t, "https://impersonation-proxy-endpoint.test", record.ClusterServer)
+	require.False(t, record.Timestamp.Before(before))
+	require.False(t, record.Timestamp.After(after))
+
+	// Failed generations are not audited.
+	params.concierge.apiGroupSuffix = "invalid suffix"
+	_, _, err = GenerateKubeConfig(context.Background(), params, deps)
+	require.Error(t, err)
+	require.Len(t, records, 1)
+}
+
+func TestGenerateKubeConfigRequestTimeout(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestTimeout time.Duration
+		wantTimeout    time.Duration
+		wantError      string
+	}{
+		{
+			name: "no per-request timeout by default",
+		},
+		{
+			name:           "per-request timeout",
+			requestTimeout: 15 * time.Second,
+			wantTimeout:    15 * time.Second,
+		},
+		{
+			name:           "negative per-request timeout",
+			requestTimeout: -time.Second,
+			wantError:      "invalid --request-timeout -1s, must not be negative",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var gotTimeouts []time.Duration
+			deps := KubeconfigDeps{
+				selfPath: SelfPathResolverFunc(func() (string, error) { return ".../path/to/pinniped", nil }),
+				getClientset: func(clientConfig clientcmd.ClientConfig, apiGroupSuffix string) (conciergeclientset.Interface, error) {
+					restConfig, err := clientConfig.ClientConfig()
+					require.NoError(t, err)
+					gotTimeouts = append(gotTimeouts, restConfig.Timeout)
+					return fakeconciergeclientset.NewSimpleClientset(), nil
+				},
+				getenv: func(string) string { return "" },
+				log:    testlogger.New(t),
+			}
+			params := KubeconfigParams{
+				kubeconfigPath: "./testdata/kubeconfig.yaml",
+				skipValidate:   true,
+				timeout:        time.Minute,
+				requestTimeout: tt.requestTimeout,
+				staticToken:    "test-token",
+				concierge:      getKubeconfigConciergeParams{disabled: true, apiGroupSuffix: "pinniped.dev"},
+			}
+
+			_, _, err := GenerateKubeConfig(context.Background(), params, deps)
+			if tt.wantError != "" {
+				require.EqualError(t, err, tt.wantError)
+				require.Empty(t, gotTimeouts)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, []time.Duration{tt.wantTimeout}, gotTimeouts)
+		})
+	}
+}
+
+func TestPurgeKubeconfig(t *testing.T) {
+	startingKubeconfig := here.Doc(`
+		apiVersion: v1
+		kind: Config
+		clusters:
+		- name: pinniped
+		  cluster:
+		    server: https://pinniped-server
+		- name: other-cluster
+		  cluster:
+		    server: https://other-server
+		users:
+		- name: pinniped
+		  user:
+		    exec:
+		      apiVersion: client.authentication.k8s.io/v1beta1
+		      command: pinniped
+		      args: [login, static, --token=test-token]
+		- name: other-user
+		  user:
+		    token: other-token
+		contexts:
+		- name: pinniped
+		  context:
+		    cluster: pinniped
+		    user: pinniped
+		- name: other-context-using-pinniped-user
+		  context:
+		    cluster: other-cluster
+		    user: pinniped
+		- name: other-context
+		  context:
+		    cluster: other-cluster
+		    user: other-user
+		current-context: %s
+	`)
+
+	tests := []struct {
+		name               string
+		kubeconfigYAML     string
+		wantError          string
+		wantLogs           []string
+		wantCurrentContext string
+		wantClusters       []string
+		wantUsers          []string
+		wantContexts       []string
+	}{
+		{
+			name:           "current context was removed",
+			kubeconfigYAML: fmt.Sprintf(startingKubeconfig, "pinniped"),
+			wantLogs: []string{
+				`"level"=0 "msg"="removed Pinniped entries from kubeconfig"  "path"="KUBECONFIG"`,
+			},
+			wantCurrentContext: "",
+			wantClusters:       []string{"other-cluster"},
+			wantUsers:          []string{"other-user"},
+			wantContexts:       []string{"other-context"},
+		},
+		{
+			name:           "current context was not removed",
+			kubeconfigYAML: fmt.Sprintf(startingKubeconfig, "other-context"),
+			wantLogs: []string{
+				`"level"=0 "msg"="removed Pinniped entries from kubeconfig"  "path"="KUBECONFIG"`,
+			},
+			wantCurrentContext: "other-context",
+			wantClusters:       []string{"other-cluster"},
+			wantUsers:          []string{"other-user"},
+			wantContexts:       []string{"other-context"},
+		},
+		{
+			name: "no Pinniped entries",
+			kubeconfigYAML: here.Doc(`
+				apiVersion: v1
+				kind: Config
+				clusters:
+				- name: other-cluster
+				  cluster:
+				    server: https://other-server
+				users:
+				- name: other-user
+				  user:
+				    token: other-token
+				contexts:
+				- name: other-context
+				  context:
+				    cluster: other-cluster
+				    user: other-user
+				current-context: other-context
+			`),
+			wantLogs: []string{
+				`"level"=0 "msg"="no Pinniped entries found in kubeconfig"  "path"="KUBECONFIG"`,
+			},
+			wantCurrentContext: "other-context",
+			wantClusters:       []string{"other-cluster"},
+			wantUsers:          []string{"other-user"},
+			wantContexts:       []string{"other-context"},
+		},
+		{
+			name:      "kubeconfig does not exist",
+			wantError: "could not load --kubeconfig: open KUBECONFIG: no such file or directory",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			kubeconfigPath := filepath.Join(t.TempDir(), "kubeconfig.yaml")
+			if tt.kubeconfigYAML != "" {
+				require.NoError(t, ioutil.WriteFile(kubeconfigPath, []byte(tt.kubeconfigYAML), 0600))
+			}
+
+			testLog := testlogger.New(t)
+			cmd := kubeconfigCommand(KubeconfigDeps{
+				selfPath: SelfPathResolverFunc(func() (string, error) {
+					require.FailNow(t, "should not generate a kubeconfig when purging")
+					return "", nil
+				}),
+				getenv: func(string) string { return "" },
+				log:    testLog,
+			})
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs([]string{"--purge", "--kubeconfig", kubeconfigPath})
+			err := cmd.Execute()
+			if tt.wantError != "" {
+				require.EqualError(t, err, strings.ReplaceAll(tt.wantError, "KUBECONFIG", kubeconfigPath))
+				return
+			}
+			require.NoError(t, err)
+			require.Empty(t, stdout.String())
+			for i := range tt.wantLogs {
+				tt.wantLogs[i] = strings.ReplaceAll(tt.wantLogs[i], "KUBECONFIG", kubeconfigPath)
+			}
+			testLog.Expect(tt.wantLogs)
+
+			purged, err := clientcmd.LoadFromFile(kubeconfigPath)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantCurrentContext, purged.CurrentContext)
+			require.ElementsMatch(t, tt.wantClusters, keysOf(purged.Clusters))
+			require.ElementsMatch(t, tt.wantUsers, keysOf(purged.AuthInfos))
+			require.ElementsMatch(t, tt.wantContexts, keysOf(purged.Contexts))
+		})
+	}
+}
+
+func TestGetKubeconfigOutputSecret(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		kubeObjects []runtime.Object
+		wantError   string
+		wantLogs    []string
+		wantData    map[string]string
+		wantLabels  map[string]string
+	}{
+		{
+			name: "creates a new Secret",
+			args: []string{"--output-secret", "test-namespace/test-secret"},
+			wantLogs: []string{
+				`"level"=0 "msg"="created Secret with kubeconfig"  "name"="test-secret" "namespace"="test-namespace"`,
+			},
+			wantData: map[string]string{"value": "KUBECONFIG"},
+		},
+		{
+			name: "updates an existing Secret, preserving its other keys",
+			args: []string{"--output-secret", "test-namespace/test-secret"},
+			kubeObjects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "test-namespace", Name: "test-secret"},
+					Data:       map[string][]byte{"value": []byte("old-value"), "other": []byte("other-value")},
+				},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="updated Secret with kubeconfig"  "name"="test-secret" "namespace"="test-namespace"`,
+			},
+			wantData: map[string]string{"value": "KUBECONFIG", "other": "other-value"},
+		},
+		{
+			name: "creates a new Secret with labels",
+			args: []string{
+				"--output-secret", "test-namespace/test-secret",
+				"--output-secret-label", "app=pinniped",
+				"--output-secret-label", "example.com/owner=",
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="created Secret with kubeconfig"  "name"="test-secret" "namespace"="test-namespace"`,
+			},
+			wantData:   map[string]string{"value": "KUBECONFIG"},
+			wantLabels: map[string]string{"app": "pinniped", "example.com/owner": ""},
+		},
+		{
+			name: "updates an existing Secret with labels, preserving its other labels",
+			args: []string{
+				"--output-secret", "test-namespace/test-secret",
+				"--output-secret-label", "app=pinniped",
+			},
+			kubeObjects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "test-namespace",
+						Name:      "test-secret",
+						Labels:    map[string]string{"app": "old-app", "other": "other-value"},
+					},
+					Data: map[string][]byte{"value": []byte("old-value")},
+				},
+			},
+			wantLogs: []string{
+				`"level"=0 "msg"="updated Secret with kubeconfig"  "name"="test-secret" "namespace"="test-namespace"`,
+			},
+			wantData:   map[string]string{"value": "KUBECONFIG"},
+			wantLabels: map[string]string{"app": "pinniped", "other": "other-value"},
+		},
+		{
+			name:      "invalid --output-secret-label format",
+			args:      []string{"--output-secret", "test-namespace/test-secret", "--output-secret-label", "app"},
+			wantError: `invalid --output-secret-label "app", expected KEY=VALUE`,
+		},
+		{
+			name:      "invalid --output-secret-label key",
+			args:      []string{"--output-secret", "test-namespace/test-secret", "--output-secret-label", "-app=pinniped"},
+			wantError: `invalid --output-secret-label "-app=pinniped": name part must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')`,
+		},
+		{
+			name:      "--output-secret-label without --output-secret",
+			args:      []string{"--output-secret-label", "app=pinniped"},
+			wantError: "--output-secret-label can only be used with --output-secret",
+		},
+		{
+			name:      "invalid --output-secret",
+			args:      []string{"--output-secret", "test-secret"},
+			wantError: `invalid --output-secret "test-secret", expected NAMESPACE/NAME`,
+		},
+		{
+			name:      "--output and --output-secret",
+			args:      []string{"--output-secret", "test-namespace/test-secret", "--output", "/path/to/kubeconfig"},
+			wantError: "--output and --output-secret cannot be used together",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := kubernetesfake.NewSimpleClientset(tt.kubeObjects...)
+			testLog := testlogger.New(t)
+			cmd := kubeconfigCommand(KubeconfigDeps{
+				selfPath: SelfPathResolverFunc(func() (string, error) { return ".../path/to/pinniped", nil }),
+				getClientset: func(clientConfig clientcmd.ClientConfig, apiGroupSuffix string) (conciergeclientset.Interface, error) {
+					return fakeconciergeclientset.NewSimpleClientset(), nil
+				},
+				getKubeClientset: func(clientConfig clientcmd.ClientConfig) (kubernetes.Interface, error) {
+					return kubeClient, nil
+				},
+				getenv: func(string) string { return "" },
+				log:    testLog,
+			})
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(append([]string{
+				"--kubeconfig", "./testdata/kubeconfig.yaml",
+				"--no-concierge",
+				"--static-token", "test-token",
+				"--skip-validation",
+			}, tt.args...))
+			err := cmd.Execute()
+			if tt.wantError != "" {
+				require.EqualError(t, err, tt.wantError)
+				require.Empty(t, kubeClient.Actions())
+				return
+			}
+			require.NoError(t, err)
+			require.Empty(t, stdout.String())
+			testLog.Expect(tt.wantLogs)
+
+			secret, err := kubeClient.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+			require.NoError(t, err)
+			kubeconfig, err := clientcmd.Load(secret.Data["value"])
+			require.NoError(t, err)
+			require.Equal(t, "https://fake-server-url-value", kubeconfig.Clusters["pinniped"].Server)
+			require.Equal(t, []string{"login", "static", "--token=test-token"}, kubeconfig.AuthInfos["pinniped"].Exec.Args)
+
+			gotData := map[string]string{}
+			for key, value := range secret.Data {
+				gotData[key] = string(value)
+			}
+			gotData["value"] = "KUBECONFIG"
+			require.Equal(t, tt.wantData, gotData)
+			require.Equal(t, tt.wantLabels, secret.Labels)
+		})
+	}
+}
+
+func keysOf(m interface{}) []string {
+	var keys []string
+	for _, key := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, key.String())
+	}
+	return keys
+}
+
+func TestGetKubeconfigInterrupted(t *testing.T) {
+	pendingCredentialIssuer := &configv1alpha1.CredentialIssuer{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+		Status: configv1alpha1.CredentialIssuerStatus{
+			Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+				Type:   configv1alpha1.ImpersonationProxyStrategyType,
+				Status: configv1alpha1.ErrorStrategyStatus,
+				Reason: configv1alpha1.PendingStrategyReason,
+			}},
+		},
+	}
+	cmd := kubeconfigCommand(KubeconfigDeps{
+		selfPath: SelfPathResolverFunc(func() (string, error) { return ".../path/to/pinniped", nil }),
+		getClientset: func(clientConfig clientcmd.ClientConfig, apiGroupSuffix string) (conciergeclientset.Interface, error) {
+			return fakeconciergeclientset.NewSimpleClientset(pendingCredentialIssuer), nil
+		},
+		getenv: func(string) string { return "" },
+		log:    testlogger.New(t),
+	})
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SetArgs([]string{
+		"--kubeconfig", "./testdata/kubeconfig.yaml",
+		"--static-token", "test-token",
+		"--timeout", "10m",
+	})
+
+	// Cancel the context while the command is waiting for the pending strategy, just like an interrupt would.
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := cmd.ExecuteContext(ctx)
+	require.EqualError(t, err, "interrupted before the kubeconfig could be generated")
+	require.Less(t, int64(time.Since(start)), int64(5*time.Second), "command should return promptly after being interrupted")
+	require.Empty(t, stdout.String())
+}
+
+func TestNoSuchContextError(t *testing.T) {
+	contexts := map[string]*clientcmdapi.Context{
+		"kind-kind":          {},
+		"kind-other":         {},
+		"some-other-context": {},
+	}
+	require.EqualError(t, noSuchContextError("kind-knd", contexts),
+		`no such context "kind-knd", did you mean "kind-kind"? (available contexts: kind-kind, kind-other, some-other-context)`)
+	require.EqualError(t, noSuchContextError("context", map[string]*clientcmdapi.Context{"context-b": {}, "context-a": {}}),
+		`no such context "context", did you mean "context-a"? (available contexts: context-a, context-b)`,
+		"ties should be broken alphabetically")
+	require.EqualError(t, noSuchContextError("kind-kind", nil), `no such context "kind-kind"`)
+}
+
+func TestLevenshteinDistance(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"kind-kind", "kind-kind", 0},
+		{"kind-knd", "kind-kind", 1},
+		{"héllo", "hello", 1},
+	} {
+		require.Equal(t, tt.want, levenshteinDistance(tt.a, tt.b), "%q -> %q", tt.a, tt.b)
+		require.Equal(t, tt.want, levenshteinDistance(tt.b, tt.a), "%q -> %q", tt.b, tt.a)
+	}
+}
+
+func TestAutodiscoveryRetriesTransientListErrors(t *testing.T) {
+	// failFirstList makes the first List call for the resource fail with a transient error.
+	failFirstList := func(clientset *fakeconciergeclientset.Clientset, resource string, err error) *int {
+		calls := 0
+		clientset.PrependReactor("list", resource, func(kubetesting.Action) (bool, runtime.Object, error) {
+			calls++
+			return calls == 1, nil, err
+		})
+		return &calls
+	}
+
+	t.Run("CredentialIssuer", func(t *testing.T) {
+		clientset := fakeconciergeclientset.NewSimpleClientset(
+			&configv1alpha1.CredentialIssuer{ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"}},
+		)
+		calls := failFirstList(clientset, "credentialissuers", apierrors.NewInternalError(fmt.Errorf("some transient error")))
+		testLog := testlogger.New(t)
+
+		credentialIssuer, err := lookupCredentialIssuer(context.Background(), clientset, "", nil, testLog)
+		require.NoError(t, err)
+		require.Equal(t, "test-credential-issuer", credentialIssuer.Name)
+		require.Equal(t, 2, *calls)
+		testLog.Expect([]string{
+			`"msg"="transient error while listing objects for autodiscovery, retrying" "error"="Internal error occurred: some transient error"  "kind"="CredentialIssuer"`,
+			`"level"=0 "msg"="discovered CredentialIssuer"  "name"="test-credential-issuer"`,
+		})
+	})
+
+	t.Run("JWTAuthenticator", func(t *testing.T) {
+		clientset := fakeconciergeclientset.NewSimpleClientset(
+			&conciergev1alpha1.JWTAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"}},
+		)
+		calls := failFirstList(clientset, "jwtauthenticators", apierrors.NewServiceUnavailable("some transient error"))
+		testLog := testlogger.New(t)
+
+		authenticator, err := lookupAuthenticator(context.Background(), clientset, "", "", "", "", testLog)
+		require.NoError(t, err)
+		require.Equal(t, "test-authenticator", authenticator.GetName())
+		require.Equal(t, 2, *calls)
+		testLog.Expect([]string{
+			`"msg"="transient error while listing objects for autodiscovery, retrying" "error"="some transient error"  "kind"="JWTAuthenticator"`,
+		})
+	})
+
+	t.Run("errors which are not transient are not retried", func(t *testing.T) {
+		clientset := fakeconciergeclientset.NewSimpleClientset()
+		calls := 0
+		clientset.PrependReactor("list", "webhookauthenticators", func(kubetesting.Action) (bool, runtime.Object, error) {
+			calls++
+			return true, nil, apierrors.NewBadRequest("some permanent error")
+		})
+		testLog := testlogger.New(t)
+
+		_, err := lookupAuthenticator(context.Background(), clientset, "", "", "", "", testLog)
+		require.EqualError(t, err, "failed to list WebhookAuthenticator objects for autodiscovery: some permanent error")
+		require.Equal(t, 1, calls)
+		testLog.Expect(nil)
+	})
+
+	t.Run("transient errors stop being retried once the context is done", func(t *testing.T) {
+		clientset := fakeconciergeclientset.NewSimpleClientset()
+		calls := 0
+		clientset.PrependReactor("list", "credentialissuers", func(kubetesting.Action) (bool, runtime.Object, error) {
+			calls++
+			return true, nil, apierrors.NewTooManyRequests("some transient error", 0)
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+		defer cancel()
+		_, err := lookupCredentialIssuer(ctx, clientset, "", nil, testlogger.New(t))
+		require.EqualError(t, err, "failed to list CredentialIssuer objects for autodiscovery: some transient error")
+		require.Equal(t, 2, calls, "should stop retrying once the context is done")
+	})
+}
+
+func TestValidateKubeconfigImpersonation(t *testing.T) {
+	var gotPath string
+	var gotHeaders http.Header
+	caBundle, serverURL := testutil.TLSTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotHeaders = r.Header.Clone()
+		switch {
+		case r.Header.Get("Authorization") != "Bearer test-admin-token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.Header.Get("Impersonate-User") == "forbidden-user":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+	kubeconfig := newExecKubeconfig(
+		&clientcmdapi.Cluster{Server: serverURL, CertificateAuthorityData: []byte(caBundle)},
+		&clientcmdapi.ExecConfig{Command: ".../path/to/pinniped"},
+	)
+
+	// writeAdminKubeconfig writes a --kubeconfig for the same cluster as the generated kubeconfig, whose user has the
+	// given token.
+	writeAdminKubeconfig := func(t *testing.T, token string) string {
+		path := filepath.Join(testutil.TempDir(t), "kubeconfig.yaml")
+		require.NoError(t, clientcmd.WriteToFile(clientcmdapi.Config{
+			Clusters:       map[string]*clientcmdapi.Cluster{"admin-cluster": {Server: serverURL, CertificateAuthorityData: []byte(caBundle)}},
+			AuthInfos:      map[string]*clientcmdapi.AuthInfo{"admin-user": {Token: token}},
+			Contexts:       map[string]*clientcmdapi.Context{"admin-context": {Cluster: "admin-cluster", AuthInfo: "admin-user"}},
+			CurrentContext: "admin-context",
+		}, path))
+		return path
+	}
+
+	t.Run("without impersonation", func(t *testing.T) {
+		gotHeaders = nil
+		require.NoError(t, validateKubeconfig(context.Background(), KubeconfigParams{}, kubeconfig, testlogger.New(t)))
+		require.Empty(t, gotHeaders.Values("Authorization"))
+		require.Empty(t, gotHeaders.Values("Impersonate-User"))
+		require.Empty(t, gotHeaders.Values("Impersonate-Group"))
+	})
+
+	t.Run("with --validate-as-user and --validate-as-group", func(t *testing.T) {
+		gotPath, gotHeaders = "", nil
+		flags := KubeconfigParams{
+			kubeconfigPath:   writeAdminKubeconfig(t, "test-admin-token"),
+			validateAsUser:   "test-user",
+			validateAsGroups: []string{"test-group-1", "test-group-2"},
+		}
+		require.NoError(t, validateKubeconfig(context.Background(), flags, kubeconfig, testlogger.New(t)))
+		require.Equal(t, "/api", gotPath)
+		require.Equal(t, []string{"Bearer test-admin-token"}, gotHeaders.Values("Authorization"))
+		require.Equal(t, []string{"test-user"}, gotHeaders.Values("Impersonate-User"))
+		require.Equal(t, []string{"test-group-1", "test-group-2"}, gotHeaders.Values("Impersonate-Group"))
+
+		// The generated kubeconfig itself is left untouched.
+		require.Empty(t, kubeconfig.AuthInfos["pinniped"].Impersonate)
+		require.Empty(t, kubeconfig.AuthInfos["pinniped"].ImpersonateGroups)
+	})
+
+	t.Run("impersonated user is forbidden", func(t *testing.T) {
+		flags := KubeconfigParams{
+			kubeconfigPath: writeAdminKubeconfig(t, "test-admin-token"),
+			validateAsUser: "forbidden-user",
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		err := validateKubeconfig(ctx, flags, kubeconfig, testlogger.New(t))
+		require.EqualError(t, err, `could not validate the kubeconfig: the cluster rejected the request as user "forbidden-user" with status code 403`)
+		require.Equal(t, []string{"forbidden-user"}, gotHeaders.Values("Impersonate-User"))
+	})
+
+	t.Run("--kubeconfig credentials are unauthorized", func(t *testing.T) {
+		flags := KubeconfigParams{
+			kubeconfigPath: writeAdminKubeconfig(t, "wrong-token"),
+			validateAsUser: "test-user",
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		err := validateKubeconfig(ctx, flags, kubeconfig, testlogger.New(t))
+		require.EqualError(t, err, `could not validate the kubeconfig: the cluster rejected the request as user "test-user" with status code 401`)
+	})
+
+	t.Run("generated kubeconfig uses a different server", func(t *testing.T) {
+		gotHeaders = nil
+		var otherServerHeaders []http.Header
+		otherCABundle, otherServerURL := testutil.TLSTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			otherServerHeaders = append(otherServerHeaders, r.Header.Clone())
+			w.WriteHeader(http.StatusOK)
+		})
+		otherKubeconfig := newExecKubeconfig(
+			&clientcmdapi.Cluster{Server: otherServerURL, CertificateAuthorityData: []byte(otherCABundle)},
+			&clientcmdapi.ExecConfig{Command: ".../path/to/pinniped"},
+		)
+		flags := KubeconfigParams{
+			kubeconfigPath: writeAdminKubeconfig(t, "test-admin-token"),
+			validateAsUser: "test-user",
+		}
+		err := validateKubeconfig(context.Background(), flags, otherKubeconfig, testlogger.New(t))
+		require.EqualError(t, err, fmt.Sprintf(
+			`could not configure validation as user "test-user": the generated kubeconfig uses server %q, but impersonated validation is only supported for the server of the --kubeconfig (%q)`,
+			otherServerURL, serverURL,
+		))
+
+		// Neither the credentials of the --kubeconfig user nor the impersonation headers were sent anywhere.
+		for _, headers := range otherServerHeaders {
+			require.Empty(t, headers.Values("Authorization"))
+			require.Empty(t, headers.Values("Impersonate-User"))
+			require.Empty(t, headers.Values("Impersonate-Group"))
+		}
+		require.Nil(t, gotHeaders)
+	})
+
+	t.Run("--validate-as-group without --validate-as-user", func(t *testing.T) {
+		params := KubeconfigParams{
+			kubeconfigPath:   "./testdata/kubeconfig.yaml",
+			timeout:          time.Minute,
+			staticToken:      "test-token",
+			validateAsGroups: []string{"test-group"},
+			concierge:        getKubeconfigConciergeParams{disabled: true, apiGroupSuffix: "pinniped.dev"},
+		}
+		_, _, err := GenerateKubeConfig(context.Background(), params, KubeconfigDeps{log: testlogger.New(t)})
+		require.EqualError(t, err, "--validate-as-group requires --validate-as-user")
+	})
+}
+
+func TestGetKubeconfigWriteDiscoveryAnnotations(t *testing.T) {
+	cmd := kubeconfigCommand(KubeconfigDeps{
+		selfPath: SelfPathResolverFunc(func() (string, error) { return ".../path/to/pinniped", nil }),
+		getClientset: func(clientConfig clientcmd.ClientConfig, apiGroupSuffix string) (conciergeclientset.Interface, error) {
+			return fakeconciergeclientset.NewSimpleClientset(
+				&configv1alpha1.CredentialIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+					Status: configv1alpha1.CredentialIssuerStatus{
+						KubeConfigInfo: &configv1alpha1.CredentialIssuerKubeConfigInfo{
+							Server:                   "https://concierge-endpoint",
+							CertificateAuthorityData: "ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==",
+						},
+						Strategies: []configv1alpha1.CredentialIssuerStrategy{{
+							Type:   configv1alpha1.KubeClusterSigningCertificateStrategyType,
+							Status: configv1alpha1.SuccessStrategyStatus,
+							Reason: configv1alpha1.FetchedKeyStrategyReason,
+						}},
+					},
+				},
+				&conciergev1alpha1.JWTAuthenticator{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-authenticator"},
+					Spec: conciergev1alpha1.JWTAuthenticatorSpec{
+						Issuer:   "https://example.com/issuer",
+						Audience: "test-audience",
+					},
+				},
+			), nil
+		},
+		getenv: func(string) string { return "" },
+		log:    testlogger.New(t),
+	})
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SetArgs([]string{
+		"--kubeconfig", "./testdata/kubeconfig.yaml",
+		"--skip-validation",
+		"--write-discovery-annotations",
+	})
+	require.NoError(t, cmd.Execute())
+	require.Empty(t, stderr.String())
+
+	require.Equal(t, here.Doc(`
+		apiVersion: v1
+		clusters:
+		- cluster:
+		    certificate-authority-data: ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+		    server: https://fake-server-url-value
+		  name: pinniped
+		contexts:
+		- context:
+		    cluster: pinniped
+		    user: pinniped
+		  name: pinniped
+		current-context: pinniped
+		kind: Config
+		preferences: {}
+		users:
+		- name: pinniped
+		  user:
+		    exec:
+		      apiVersion: client.authentication.k8s.io/v1beta1
+		      args:
+		      - login
+		      - oidc
+		      - --enable-concierge
+		      - --concierge-api-group-suffix=pinniped.dev
+		      - --concierge-authenticator-name=test-authenticator
+		      - --concierge-authenticator-type=jwt
+		      - --concierge-endpoint=https://fake-server-url-value
+		      - --concierge-ca-bundle-data=ZmFrZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YS12YWx1ZQ==
+		      - --issuer=https://example.com/issuer
+		      - --client-id=pinniped-cli
+		      - --scopes=offline_access,openid,pinniped:request-audience
+		      - --request-audience=test-audience
+		      command: '.../path/to/pinniped'
+		      env: []
+		      provideClusterInfo: true
+		    extensions:
+		    - extension:
+		        authenticatorName: test-authenticator
+		        authenticatorType: jwt
+		        issuer: https://example.com/issuer
+		      name: discovery.pinniped.dev
+	`), stdout.String())
+
+	kubeconfig, err := clientcmd.Load(stdout.Bytes())
+	require.NoError(t, err)
+	extension, ok := kubeconfig.AuthInfos["pinniped"].Extensions["discovery.pinniped.dev"].(*runtime.Unknown)
+	require.True(t, ok, "extension should be present on the user entry")
+	require.JSONEq(t, `{"authenticatorType":"jwt","authenticatorName":"test-authenticator","issuer":"https://example.com/issuer"}`, string(extension.Raw))
+	require.Empty(t, kubeconfig.Clusters["pinniped"].Extensions)
+	require.Empty(t, kubeconfig.Contexts["pinniped"].Extensions)
+}
+
+func TestGetKubeconfigSingleRequestAudience(t *testing.T) {
+	cmd := kubeconfigCommand(KubeconfigDeps{
+		selfPath: SelfPathResolverFunc(func() (string, error) { return ".../path/to/pinniped", nil }),
+		getClientset: func(clientConfig clientcmd.ClientConfig, apiGroupSuffix string) (conciergeclientset.Interface, error) {
+			return fakeconciergeclientset.NewSimpleClientset(), nil
+		},
+		getenv: func(string) string { return "" },
+		log:    testlogger.New(t),
+	})
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SetArgs([]string{
+		"--kubeconfig", "./testdata/kubeconfig.yaml",
+		"--no-concierge",
+		"--skip-validation",
+		"--oidc-issuer", "https://example.com/issuer",
+		"--oidc-request-audience", "first-audience",
+		"--oidc-request-audience", "second-audience",
+	})
+	require.NoError(t, cmd.Execute())
+	require.Empty(t, stderr.String())
+
+	kubeconfig, err := clientcmd.Load(stdout.Bytes())
+	require.NoError(t, err)
+
+	// Only one audience can be requested, so the last one given wins.
+	var requestAudienceArgs []string
+	for _, arg := range kubeconfig.AuthInfos["pinniped"].Exec.Args {
+		if strings.HasPrefix(arg, "--request-audience=") {
+			requestAudienceArgs = append(requestAudienceArgs, arg)
+		}
+	}
+	require.Equal(t, []string{"--request-audience=second-audience"}, requestAudienceArgs)
+}
+
+func TestGetKubeconfigOIDCSessionCacheCreate(t *testing.T) {
+	run := func(t *testing.T, args ...string) (string, error) {
+		cmd := kubeconfigCommand(KubeconfigDeps{
+			selfPath: SelfPathResolverFunc(func() (string, error) { return ".../path/to/pinniped", nil }),
+			getClientset: func(clientConfig clientcmd.ClientConfig, apiGroupSuffix string) (conciergeclientset.Interface, error) {
+				return fakeconciergeclientset.NewSimpleClientset(), nil
+			},
+			getenv: func(string) string { return "" },
+			log:    testlogger.New(t),
+		})
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs(append([]string{
+			"--kubeconfig", "./testdata/kubeconfig.yaml",
+			"--skip-validation",
+			"--no-concierge",
+			"--oidc-issuer", "https://example.com/issuer",
+		}, args...))
+		err := cmd.Execute()
+		return stdout.String(), err
+	}
+
+	t.Run("creates the parent directory when the flag is set", func(t *testing.T) {
+		sessionCacheDir := filepath.Join(testutil.TempDir(t), "nested", "cache")
+		sessionCachePath := filepath.Join(sessionCacheDir, "sessions.yaml")
+
+		stdout, err := run(t, "--oidc-session-cache", sessionCachePath, "--oidc-session-cache-create")
+		require.NoError(t, err)
+		require.Contains(t, stdout, "--session-cache="+sessionCachePath)
+
+		info, err := os.Stat(sessionCacheDir)
+		require.NoError(t, err)
+		require.True(t, info.IsDir())
+		require.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+		// Only the directory is created, the session cache file itself is left to the login command.
+		_, err = os.Stat(sessionCachePath)
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("does not create the parent directory by default", func(t *testing.T) {
+		sessionCacheDir := filepath.Join(testutil.TempDir(t), "nested", "cache")
+		sessionCachePath := filepath.Join(sessionCacheDir, "sessions.yaml")
+
+		stdout, err := run(t, "--oidc-session-cache", sessionCachePath)
+		require.NoError(t, err)
+		require.Contains(t, stdout, "--session-cache="+sessionCachePath)
+
+		_, err = os.Stat(sessionCacheDir)
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("flag requires --oidc-session-cache", func(t *testing.T) {
+		_, err := run(t, "--oidc-session-cache-create")
+		require.EqualError(t, err, "--oidc-session-cache-create requires --oidc-session-cache")
+	})
+}
+
+func TestGetKubeconfigSupervisorFederationDomain(t *testing.T) {
+	federationDomain := func(issuer string) *supervisorconfigv1alpha1.FederationDomain {
+		return &supervisorconfigv1alpha1.FederationDomain{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test-namespace", Name: "test-federation-domain"},
+			Spec:       supervisorconfigv1alpha1.FederationDomainSpec{Issuer: issuer},
+		}
+	}
+
+	tests := []struct {
+		name              string
+		args              []string
+		supervisorObjects []runtime.Object
+		wantLogs          []string
+		wantIssuerArg     string
+		wantError         string
+	}{
+		{
+			name: "issuer from FederationDomain",
+			args: []string{
+				"--no-concierge",
+				"--supervisor-federationdomain", "test-namespace/test-federation-domain",
+			},
+			supervisorObjects: []runtime.Object{federationDomain("https://supervisor.example.com/issuer")},
+			wantLogs: []string{
+				`"level"=0 "msg"="discovered OIDC issuer from FederationDomain"  "issuer"="https://supervisor.example.com/issuer" "name"="test-federation-domain" "namespace"="test-namespace"`,
+			},
+			wantIssuerArg: "--issuer=https://supervisor.example.com/issuer",
+		},
+		{
+			name: "explicit --oidc-issuer takes precedence",
+			args: []string{
+				"--no-concierge",
+				"--oidc-issuer", "https://example.com/issuer",
+				"--supervisor-federationdomain", "test-namespace/test-federation-domain",
+			},
+			wantIssuerArg: "--issuer=https://example.com/issuer",
+		},
+		{
+			name: "FederationDomain not found",
+			args: []string{
+				"--no-concierge",
+				"--supervisor-federationdomain", "test-namespace/test-federation-domain",
+			},
+			wantError: `could not get --supervisor-federationdomain test-namespace/test-federation-domain: federationdomains.config.supervisor.pinniped.dev "test-federation-domain" not found`,
+		},
+		{
+			name: "FederationDomain with a non-https issuer",
+			args: []string{
+				"--no-concierge",
+				"--supervisor-federationdomain", "test-namespace/test-federation-domain",
+			},
+			supervisorObjects: []runtime.Object{federationDomain("http://supervisor.example.com/issuer")},
+			wantError:         `FederationDomain test-namespace/test-federation-domain has spec.issuer "http://supervisor.example.com/issuer", but it must be an absolute https URL so that tokens are not sent over plaintext`,
+		},
+		{
+			name: "invalid --supervisor-federationdomain",
+			args: []string{
+				"--no-concierge",
+				"--supervisor-federationdomain", "test-federation-domain",
+			},
+			wantError: `invalid --supervisor-federationdomain "test-federation-domain", expected NAMESPACE/NAME`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			testLog := testlogger.New(t)
+			cmd := kubeconfigCommand(KubeconfigDeps{
+				selfPath: SelfPathResolverFunc(func() (string, error) { return ".../path/to/pinniped", nil }),
+				getClientset: func(clientConfig clientcmd.ClientConfig, apiGroupSuffix string) (conciergeclientset.Interface, error) {
+					return fakeconciergeclientset.NewSimpleClientset(), nil
+				},
+				getSupervisorClientset: func(clientConfig clientcmd.ClientConfig, apiGroupSuffix string) (supervisorclientset.Interface, error) {
+					require.Equal(t, "pinniped.dev", apiGroupSuffix)
+					return fakesupervisorclientset.NewSimpleClientset(tt.supervisorObjects...), nil
+				},
+				getenv: func(string) string { return "" },
+				log:    testLog,
+			})
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(append([]string{"--kubeconfig", "./testdata/kubeconfig.yaml", "--skip-validation"}, tt.args...))
+			err := cmd.Execute()
+			if tt.wantError != "" {
+				require.EqualError(t, err, tt.wantError)
+				return
+			}
+			require.NoError(t, err)
+			require.Contains(t, stdout.String(), "- "+tt.wantIssuerArg+"\n")
+			testLog.Expect(tt.wantLogs)
 		})
 	}
 }
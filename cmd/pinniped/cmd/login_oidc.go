@@ -13,7 +13,9 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
@@ -49,22 +51,23 @@ func oidcLoginCommandRealDeps() oidcLoginCommandDeps {
 }
 
 type oidcLoginFlags struct {
-	issuer                     string
-	clientID                   string
-	listenPort                 uint16
-	scopes                     []string
-	skipBrowser                bool
-	sessionCachePath           string
-	caBundlePaths              []string
-	caBundleData               []string
-	debugSessionCache          bool
-	requestAudience            string
-	conciergeEnabled           bool
-	conciergeAuthenticatorType string
-	conciergeAuthenticatorName string
-	conciergeEndpoint          string
-	conciergeCABundle          string
-	conciergeAPIGroupSuffix    string
+	issuer                       string
+	clientID                     string
+	listenPort                   uint16
+	redirectURIPath              string
+	upstreamIdentityProviderName string
+	upstreamIdentityProviderType string
+	scopes                       []string
+	skipBrowser                  bool
+	browserCommand               string
+	sessionCachePath             string
+	caBundlePaths                []string
+	caBundleData                 []string
+	debugSessionCache            bool
+	requestAudience              string
+	usernameClaim                string
+	groupsClaim                  string
+	conciergeLoginFlags
 }
 
 func oidcLoginCommand(deps oidcLoginCommandDeps) *cobra.Command {
@@ -81,13 +84,19 @@ func oidcLoginCommand(deps oidcLoginCommandDeps) *cobra.Command {
 	cmd.Flags().StringVar(&flags.issuer, "issuer", "", "OpenID Connect issuer URL")
 	cmd.Flags().StringVar(&flags.clientID, "client-id", "pinniped-cli", "OpenID Connect client ID")
 	cmd.Flags().Uint16Var(&flags.listenPort, "listen-port", 0, "TCP port for localhost listener (authorization code flow only)")
+	cmd.Flags().StringVar(&flags.redirectURIPath, "redirect-uri-path", "", "Path of the localhost redirect URI (authorization code flow only) (default: /callback)")
 	cmd.Flags().StringSliceVar(&flags.scopes, "scopes", []string{oidc.ScopeOfflineAccess, oidc.ScopeOpenID, "pinniped:request-audience"}, "OIDC scopes to request during login")
 	cmd.Flags().BoolVar(&flags.skipBrowser, "skip-browser", false, "Skip opening the browser (just print the URL)")
+	cmd.Flags().StringVar(&flags.browserCommand, "browser-command", "", "Command used to open the login URL, which is passed the URL as its last argument (e.g., 'wslview') (default: the system browser)")
 	cmd.Flags().StringVar(&flags.sessionCachePath, "session-cache", filepath.Join(mustGetConfigDir(), "sessions.yaml"), "Path to session cache file")
 	cmd.Flags().StringSliceVar(&flags.caBundlePaths, "ca-bundle", nil, "Path to TLS certificate authority bundle (PEM format, optional, can be repeated)")
 	cmd.Flags().StringSliceVar(&flags.caBundleData, "ca-bundle-data", nil, "Base64 encoded TLS certificate authority bundle (base64 encoded PEM format, optional, can be repeated)")
 	cmd.Flags().BoolVar(&flags.debugSessionCache, "debug-session-cache", false, "Print debug logs related to the session cache")
-	cmd.Flags().StringVar(&flags.requestAudience, "request-audience", "", "Request a token with an alternate audience using RFC8693 token exchange")
+	cmd.Flags().StringVar(&flags.requestAudience, "request-audience", "", "Request a token with an alternate audience using RFC8693 token exchange (only one audience can be requested)")
+	cmd.Flags().StringVar(&flags.upstreamIdentityProviderName, "upstream-identity-provider-name", "", "The name of the upstream identity provider used during login with a Supervisor")
+	cmd.Flags().StringVar(&flags.upstreamIdentityProviderType, "upstream-identity-provider-type", "", "The type of the upstream identity provider used during login with a Supervisor (e.g. 'oidc', 'ldap')")
+	cmd.Flags().StringVar(&flags.usernameClaim, "username-claim", "", "The ID token claim from which the Concierge JWTAuthenticator reads the username")
+	cmd.Flags().StringVar(&flags.groupsClaim, "groups-claim", "", "The ID token claim from which the Concierge JWTAuthenticator reads the groups")
 	cmd.Flags().BoolVar(&flags.conciergeEnabled, "enable-concierge", false, "Use the Concierge to login")
 	cmd.Flags().StringVar(&conciergeNamespace, "concierge-namespace", "pinniped-concierge", "Namespace in which the Concierge was installed")
 	cmd.Flags().StringVar(&flags.conciergeAuthenticatorType, "concierge-authenticator-type", "", "Concierge authenticator type (e.g., 'webhook', 'jwt')")
@@ -95,6 +104,7 @@ func oidcLoginCommand(deps oidcLoginCommandDeps) *cobra.Command {
 	cmd.Flags().StringVar(&flags.conciergeEndpoint, "concierge-endpoint", "", "API base for the Concierge endpoint")
 	cmd.Flags().StringVar(&flags.conciergeCABundle, "concierge-ca-bundle-data", "", "CA bundle to use when connecting to the Concierge")
 	cmd.Flags().StringVar(&flags.conciergeAPIGroupSuffix, "concierge-api-group-suffix", groupsuffix.PinnipedDefaultSuffix, "Concierge API group suffix")
+	cmd.Flags().StringVar(&flags.conciergeConfig, "concierge-config", "", "Base64 encoded JSON Concierge settings, as generated by 'pinniped get kubeconfig --concierge-packed-config' (implies --enable-concierge and replaces the other --concierge-* flags)")
 
 	mustMarkHidden(cmd, "debug-session-cache")
 	mustMarkRequired(cmd, "issuer")
@@ -130,10 +140,22 @@ func runOIDCLogin(cmd *cobra.Command, deps oidcLoginCommandDeps, flags oidcLogin
 		opts = append(opts, oidcclient.WithListenPort(flags.listenPort))
 	}
 
+	if flags.redirectURIPath != "" {
+		opts = append(opts, oidcclient.WithRedirectURIPath(flags.redirectURIPath))
+	}
+
 	if flags.requestAudience != "" {
 		opts = append(opts, oidcclient.WithRequestAudience(flags.requestAudience))
 	}
 
+	if flags.upstreamIdentityProviderName != "" || flags.upstreamIdentityProviderType != "" {
+		opts = append(opts, oidcclient.WithUpstreamIdentityProvider(flags.upstreamIdentityProviderName, flags.upstreamIdentityProviderType))
+	}
+
+	if err := flags.applyPackedConciergeConfig(); err != nil {
+		return err
+	}
+
 	var concierge *conciergeclient.Client
 	if flags.conciergeEnabled {
 		var err error
@@ -148,6 +170,11 @@ func runOIDCLogin(cmd *cobra.Command, deps oidcLoginCommandDeps, flags oidcLogin
 		}
 	}
 
+	// --browser-command replaces the default "browser open" function with one that runs the given command.
+	if flags.browserCommand != "" {
+		opts = append(opts, oidcclient.WithBrowserOpen(browserCommandOpener(flags.browserCommand)))
+	}
+
 	// --skip-browser replaces the default "browser open" function with one that prints to stderr.
 	if flags.skipBrowser {
 		opts = append(opts, oidcclient.WithBrowserOpen(func(url string) error {
@@ -170,6 +197,7 @@ func runOIDCLogin(cmd *cobra.Command, deps oidcLoginCommandDeps, flags oidcLogin
 		return fmt.Errorf("could not complete Pinniped login: %w", err)
 	}
 	cred := tokenCredential(token)
+	warnOnMismatchedClaims(cmd, token, flags)
 
 	// If the concierge was configured, exchange the credential for a separate short-lived, cluster-specific credential.
 	if concierge != nil {
@@ -184,6 +212,23 @@ func runOIDCLogin(cmd *cobra.Command, deps oidcLoginCommandDeps, flags oidcLogin
 	return json.NewEncoder(cmd.OutOrStdout()).Encode(cred)
 }
 
+// browserCommandOpener returns a "browser open" function which starts the command, split on whitespace, with the
+// URL appended as its last argument. It does not wait for the command to exit, since some browsers stay running.
+func browserCommandOpener(command string) func(url string) error {
+	return func(url string) error {
+		fields := strings.Fields(command)
+		if len(fields) == 0 {
+			return fmt.Errorf("invalid --browser-command %q", command)
+		}
+		browser := exec.Command(fields[0], append(fields[1:], url)...) //nolint:gosec // the command comes from the user's own kubeconfig
+		if err := browser.Start(); err != nil {
+			return err
+		}
+		go func() { _ = browser.Wait() }()
+		return nil
+	}
+}
+
 func makeClient(caBundlePaths []string, caBundleData []string) (*http.Client, error) {
 	pool := x509.NewCertPool()
 	for _, p := range caBundlePaths {
@@ -211,6 +256,26 @@ func makeClient(caBundlePaths []string, caBundleData []string) (*http.Client, er
 	}, nil
 }
 
+// warnOnMismatchedClaims prints a warning when the ID token is missing the claims that the Concierge JWTAuthenticator
+// is configured to read, since the cluster would likely reject the resulting credential.
+func warnOnMismatchedClaims(cmd *cobra.Command, token *oidctypes.Token, flags oidcLoginFlags) {
+	if token.IDToken == nil || token.IDToken.Claims == nil {
+		return
+	}
+	if flags.usernameClaim != "" {
+		if _, ok := token.IDToken.Claims[flags.usernameClaim].(string); !ok {
+			cmd.PrintErrf("warning: ID token does not contain a string %q claim, which is required as the username\n", flags.usernameClaim)
+		}
+	}
+	if flags.groupsClaim != "" {
+		switch token.IDToken.Claims[flags.groupsClaim].(type) {
+		case nil, string, []interface{}:
+		default:
+			cmd.PrintErrf("warning: ID token %q claim is not a string or a list of strings, so it cannot be used as the groups\n", flags.groupsClaim)
+		}
+	}
+}
+
 func tokenCredential(token *oidctypes.Token) *clientauthv1beta1.ExecCredential {
 	cred := clientauthv1beta1.ExecCredential{
 		TypeMeta: metav1.TypeMeta{
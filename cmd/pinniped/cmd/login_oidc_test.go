@@ -41,6 +41,7 @@ func TestLoginOIDCCommand(t *testing.T) {
 		args             []string
 		loginErr         error
 		conciergeErr     error
+		idTokenClaims    map[string]interface{}
 		wantError        bool
 		wantStdout       string
 		wantStderr       string
@@ -56,22 +57,29 @@ func TestLoginOIDCCommand(t *testing.T) {
 				  oidc --issuer ISSUER [flags]
 
 				Flags:
-				      --ca-bundle strings                     Path to TLS certificate authority bundle (PEM format, optional, can be repeated)
-				      --ca-bundle-data strings                Base64 encoded TLS certificate authority bundle (base64 encoded PEM format, optional, can be repeated)
-				      --client-id string                      OpenID Connect client ID (default "pinniped-cli")
-				      --concierge-api-group-suffix string     Concierge API group suffix (default "pinniped.dev")
-				      --concierge-authenticator-name string   Concierge authenticator name
-				      --concierge-authenticator-type string   Concierge authenticator type (e.g., 'webhook', 'jwt')
-				      --concierge-ca-bundle-data string       CA bundle to use when connecting to the Concierge
-				      --concierge-endpoint string             API base for the Concierge endpoint
-				      --enable-concierge                      Use the Concierge to login
-				  -h, --help                                  help for oidc
-				      --issuer string                         OpenID Connect issuer URL
-				      --listen-port uint16                    TCP port for localhost listener (authorization code flow only)
-				      --request-audience string               Request a token with an alternate audience using RFC8693 token exchange
-				      --scopes strings                        OIDC scopes to request during login (default [offline_access,openid,pinniped:request-audience])
-				      --session-cache string                  Path to session cache file (default "` + cfgDir + `/sessions.yaml")
-				      --skip-browser                          Skip opening the browser (just print the URL)
+				      --browser-command string                   Command used to open the login URL, which is passed the URL as its last argument (e.g., 'wslview') (default: the system browser)
+				      --ca-bundle strings                        Path to TLS certificate authority bundle (PEM format, optional, can be repeated)
+				      --ca-bundle-data strings                   Base64 encoded TLS certificate authority bundle (base64 encoded PEM format, optional, can be repeated)
+				      --client-id string                         OpenID Connect client ID (default "pinniped-cli")
+				      --concierge-api-group-suffix string        Concierge API group suffix (default "pinniped.dev")
+				      --concierge-authenticator-name string      Concierge authenticator name
+				      --concierge-authenticator-type string      Concierge authenticator type (e.g., 'webhook', 'jwt')
+				      --concierge-ca-bundle-data string          CA bundle to use when connecting to the Concierge
+				      --concierge-config string                  Base64 encoded JSON Concierge settings, as generated by 'pinniped get kubeconfig --concierge-packed-config' (implies --enable-concierge and replaces the other --concierge-* flags)
+				      --concierge-endpoint string                API base for the Concierge endpoint
+				      --enable-concierge                         Use the Concierge to login
+				      --groups-claim string                      The ID token claim from which the Concierge JWTAuthenticator reads the groups
+				  -h, --help                                     help for oidc
+				      --issuer string                            OpenID Connect issuer URL
+				      --listen-port uint16                       TCP port for localhost listener (authorization code flow only)
+				      --redirect-uri-path string                 Path of the localhost redirect URI (authorization code flow only) (default: /callback)
+				      --request-audience string                  Request a token with an alternate audience using RFC8693 token exchange (only one audience can be requested)
+				      --scopes strings                           OIDC scopes to request during login (default [offline_access,openid,pinniped:request-audience])
+				      --session-cache string                     Path to session cache file (default "` + cfgDir + `/sessions.yaml")
+				      --skip-browser                             Skip opening the browser (just print the URL)
+				      --upstream-identity-provider-name string   The name of the upstream identity provider used during login with a Supervisor
+				      --upstream-identity-provider-type string   The type of the upstream identity provider used during login with a Supervisor (e.g. 'oidc', 'ldap')
+				      --username-claim string                    The ID token claim from which the Concierge JWTAuthenticator reads the username
 			`),
 		},
 		{
@@ -172,6 +180,54 @@ func TestLoginOIDCCommand(t *testing.T) {
 			wantOptionsCount: 3,
 			wantStdout:       `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1beta1","spec":{},"status":{"expirationTimestamp":"3020-10-12T13:14:15Z","token":"test-id-token"}}` + "\n",
 		},
+		{
+			name: "success with matching claims",
+			args: []string{
+				"--client-id", "test-client-id",
+				"--issuer", "test-issuer",
+				"--username-claim", "email",
+				"--groups-claim", "roles",
+			},
+			idTokenClaims:    map[string]interface{}{"email": "test-user@example.com", "roles": []interface{}{"a", "b"}},
+			wantOptionsCount: 3,
+			wantStdout:       `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1beta1","spec":{},"status":{"expirationTimestamp":"3020-10-12T13:14:15Z","token":"test-id-token"}}` + "\n",
+		},
+		{
+			name: "success with mismatched claims",
+			args: []string{
+				"--client-id", "test-client-id",
+				"--issuer", "test-issuer",
+				"--username-claim", "email",
+				"--groups-claim", "roles",
+			},
+			idTokenClaims:    map[string]interface{}{"sub": "some-subject", "roles": 42},
+			wantOptionsCount: 3,
+			wantStdout:       `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1beta1","spec":{},"status":{"expirationTimestamp":"3020-10-12T13:14:15Z","token":"test-id-token"}}` + "\n",
+			wantStderr: here.Doc(`
+				warning: ID token does not contain a string "email" claim, which is required as the username
+				warning: ID token "roles" claim is not a string or a list of strings, so it cannot be used as the groups
+			`),
+		},
+		{
+			name: "success with a requested audience which contains a comma",
+			args: []string{
+				"--client-id", "test-client-id",
+				"--issuer", "test-issuer",
+				"--request-audience", "cluster-1234,cluster-5678",
+			},
+			wantOptionsCount: 4,
+			wantStdout:       `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1beta1","spec":{},"status":{"expirationTimestamp":"3020-10-12T13:14:15Z","token":"test-id-token"}}` + "\n",
+		},
+		{
+			name: "success with --browser-command",
+			args: []string{
+				"--client-id", "test-client-id",
+				"--issuer", "test-issuer",
+				"--browser-command", "wslview",
+			},
+			wantOptionsCount: 4,
+			wantStdout:       `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1beta1","spec":{},"status":{"expirationTimestamp":"3020-10-12T13:14:15Z","token":"test-id-token"}}` + "\n",
+		},
 		{
 			name: "success with all options",
 			args: []string{
@@ -179,8 +235,11 @@ func TestLoginOIDCCommand(t *testing.T) {
 				"--issuer", "test-issuer",
 				"--skip-browser",
 				"--listen-port", "1234",
+				"--redirect-uri-path", "/some/callback",
 				"--debug-session-cache",
 				"--request-audience", "cluster-1234",
+				"--upstream-identity-provider-name", "some-ldap-idp",
+				"--upstream-identity-provider-type", "ldap",
 				"--ca-bundle-data", base64.StdEncoding.EncodeToString(testCA.Bundle()),
 				"--ca-bundle", testCABundlePath,
 				"--enable-concierge",
@@ -190,7 +249,7 @@ func TestLoginOIDCCommand(t *testing.T) {
 				"--concierge-ca-bundle-data", base64.StdEncoding.EncodeToString(testCA.Bundle()),
 				"--concierge-api-group-suffix", "some.suffix.com",
 			},
-			wantOptionsCount: 7,
+			wantOptionsCount: 9,
 			wantStdout:       `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1beta1","spec":{},"status":{"token":"exchanged-token"}}` + "\n",
 		},
 	}
@@ -212,6 +271,7 @@ func TestLoginOIDCCommand(t *testing.T) {
 						IDToken: &oidctypes.IDToken{
 							Token:  "test-id-token",
 							Expiry: metav1.NewTime(time1),
+							Claims: tt.idTokenClaims,
 						},
 					}, nil
 				},
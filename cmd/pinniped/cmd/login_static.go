@@ -39,14 +39,9 @@ func staticLoginRealDeps() staticLoginDeps {
 }
 
 type staticLoginParams struct {
-	staticToken                string
-	staticTokenEnvName         string
-	conciergeEnabled           bool
-	conciergeAuthenticatorType string
-	conciergeAuthenticatorName string
-	conciergeEndpoint          string
-	conciergeCABundle          string
-	conciergeAPIGroupSuffix    string
+	staticToken        string
+	staticTokenEnvName string
+	conciergeLoginFlags
 }
 
 func staticLoginCommand(deps staticLoginDeps) *cobra.Command {
@@ -69,6 +64,7 @@ func staticLoginCommand(deps staticLoginDeps) *cobra.Command {
 	cmd.Flags().StringVar(&flags.conciergeEndpoint, "concierge-endpoint", "", "API base for the Concierge endpoint")
 	cmd.Flags().StringVar(&flags.conciergeCABundle, "concierge-ca-bundle-data", "", "CA bundle to use when connecting to the Concierge")
 	cmd.Flags().StringVar(&flags.conciergeAPIGroupSuffix, "concierge-api-group-suffix", groupsuffix.PinnipedDefaultSuffix, "Concierge API group suffix")
+	cmd.Flags().StringVar(&flags.conciergeConfig, "concierge-config", "", "Base64 encoded JSON Concierge settings, as generated by 'pinniped get kubeconfig --concierge-packed-config' (implies --enable-concierge and replaces the other --concierge-* flags)")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error { return runStaticLogin(cmd.OutOrStdout(), deps, flags) }
 
@@ -83,6 +79,10 @@ func runStaticLogin(out io.Writer, deps staticLoginDeps, flags staticLoginParams
 		return fmt.Errorf("one of --token or --token-env must be set")
 	}
 
+	if err := flags.applyPackedConciergeConfig(); err != nil {
+		return err
+	}
+
 	var concierge *conciergeclient.Client
 	if flags.conciergeEnabled {
 		var err error
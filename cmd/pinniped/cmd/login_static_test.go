@@ -54,6 +54,7 @@ func TestLoginStaticCommand(t *testing.T) {
 				      --concierge-authenticator-name string   Concierge authenticator name
 				      --concierge-authenticator-type string   Concierge authenticator type (e.g., 'webhook', 'jwt')
 				      --concierge-ca-bundle-data string       CA bundle to use when connecting to the Concierge
+				      --concierge-config string               Base64 encoded JSON Concierge settings, as generated by 'pinniped get kubeconfig --concierge-packed-config' (implies --enable-concierge and replaces the other --concierge-* flags)
 				      --concierge-endpoint string             API base for the Concierge endpoint
 				      --enable-concierge                      Use the Concierge to login
 				  -h, --help                                  help for static
@@ -143,6 +144,46 @@ func TestLoginStaticCommand(t *testing.T) {
 				Error: invalid Concierge parameters: invalid API group suffix: a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')
 			`),
 		},
+		{
+			name: "invalid --concierge-config",
+			args: []string{
+				"--token", "test-token",
+				"--concierge-config", "invalid-base64",
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: invalid --concierge-config: illegal base64 data at input byte 7
+			`),
+		},
+		{
+			name: "--concierge-config with invalid API group suffix",
+			args: []string{
+				"--token", "test-token",
+				"--concierge-config", mustEncodePackedConciergeConfig(t, &conciergeLoginArgs{
+					apiGroupSuffix:    ".starts.with.dot",
+					authenticatorName: "test-authenticator",
+					authenticatorType: "jwt",
+					endpoint:          "https://127.0.0.1:1234/",
+				}),
+			},
+			wantError: true,
+			wantStderr: here.Doc(`
+				Error: invalid Concierge parameters: invalid API group suffix: a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')
+			`),
+		},
+		{
+			name: "--concierge-config success",
+			args: []string{
+				"--token", "test-token",
+				"--concierge-config", mustEncodePackedConciergeConfig(t, &conciergeLoginArgs{
+					apiGroupSuffix:    "pinniped.dev",
+					authenticatorName: "test-authenticator",
+					authenticatorType: "webhook",
+					endpoint:          "https://127.0.0.1/",
+				}),
+			},
+			wantStdout: `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1beta1","spec":{},"status":{"token":"exchanged-token"}}` + "\n",
+		},
 		{
 			name: "static token success",
 			args: []string{
@@ -0,0 +1,49 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"sync"
+)
+
+// SelfPathResolver finds the path to the currently running Pinniped executable, e.g. so that it can be used
+// as the exec credential plugin command in a generated kubeconfig.
+type SelfPathResolver interface {
+	PathToSelf() (string, error)
+}
+
+// SelfPathResolverFunc is an adapter which allows an ordinary function (such as a test stub) to be used as a
+// SelfPathResolver.
+type SelfPathResolverFunc func() (string, error)
+
+// PathToSelf calls f().
+func (f SelfPathResolverFunc) PathToSelf() (string, error) { return f() }
+
+// executableSelfPathResolver is the real implementation of SelfPathResolver, backed by os.Executable.
+type executableSelfPathResolver struct{}
+
+func (executableSelfPathResolver) PathToSelf() (string, error) { return os.Executable() }
+
+// processSelfPathResolver is the SelfPathResolver used by the real commands. The path to the executable does not
+// change while the process is running, so it is resolved at most once per process.
+//nolint: gochecknoglobals
+var processSelfPathResolver = newCachingSelfPathResolver(executableSelfPathResolver{})
+
+// cachingSelfPathResolver wraps another SelfPathResolver and remembers the result of its first call.
+type cachingSelfPathResolver struct {
+	delegate SelfPathResolver
+	once     sync.Once
+	path     string
+	err      error
+}
+
+func newCachingSelfPathResolver(delegate SelfPathResolver) *cachingSelfPathResolver {
+	return &cachingSelfPathResolver{delegate: delegate}
+}
+
+func (r *cachingSelfPathResolver) PathToSelf() (string, error) {
+	r.once.Do(func() { r.path, r.err = r.delegate.PathToSelf() })
+	return r.path, r.err
+}
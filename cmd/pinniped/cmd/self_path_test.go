@@ -0,0 +1,78 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutableSelfPathResolver(t *testing.T) {
+	want, err := os.Executable()
+	require.NoError(t, err)
+
+	got, err := executableSelfPathResolver{}.PathToSelf()
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
+
+func TestSelfPathResolverFunc(t *testing.T) {
+	var resolver SelfPathResolver = SelfPathResolverFunc(func() (string, error) { return "/some/path/to/pinniped", nil })
+	got, err := resolver.PathToSelf()
+	require.NoError(t, err)
+	require.Equal(t, "/some/path/to/pinniped", got)
+
+	resolver = SelfPathResolverFunc(func() (string, error) { return "", fmt.Errorf("some error") })
+	got, err = resolver.PathToSelf()
+	require.EqualError(t, err, "some error")
+	require.Empty(t, got)
+}
+
+func TestCachingSelfPathResolver(t *testing.T) {
+	calls := 0
+	resolver := newCachingSelfPathResolver(SelfPathResolverFunc(func() (string, error) {
+		calls++
+		return "/some/path/to/pinniped", nil
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := resolver.PathToSelf()
+			require.NoError(t, err)
+			require.Equal(t, "/some/path/to/pinniped", got)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 1, calls, "the wrapped resolver should only be called once")
+
+	failingCalls := 0
+	failing := newCachingSelfPathResolver(SelfPathResolverFunc(func() (string, error) {
+		failingCalls++
+		return "", fmt.Errorf("some error")
+	}))
+	for i := 0; i < 3; i++ {
+		got, err := failing.PathToSelf()
+		require.EqualError(t, err, "some error")
+		require.Empty(t, got)
+	}
+	require.Equal(t, 1, failingCalls, "errors should be cached too")
+}
+
+func TestProcessSelfPathResolver(t *testing.T) {
+	want, err := os.Executable()
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		got, err := processSelfPathResolver.PathToSelf()
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+}
@@ -1,28 +1,65 @@
-// Copyright 2020 the Pinniped contributors. All Rights Reserved.
+// Copyright 2020-2021 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
+	apimachineryversion "k8s.io/apimachinery/pkg/version"
 	"k8s.io/component-base/version"
+
+	"go.pinniped.dev/internal/groupsuffix"
+	"go.pinniped.dev/internal/here"
 )
 
 //nolint: gochecknoinits
 func init() {
-	rootCmd.AddCommand(newVersionCommand())
+	rootCmd.AddCommand(newVersionCommand(version.Get))
+}
+
+// versionInfo is the output of the version command. The build metadata is injected at build time via
+// ldflags (see hack/get-ldflags.sh).
+type versionInfo struct {
+	apimachineryversion.Info
+	APIGroupSuffix string `json:"apiGroupSuffix"`
 }
 
-func newVersionCommand() *cobra.Command {
-	return &cobra.Command{
+func newVersionCommand(getVersion func() apimachineryversion.Info) *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			fmt.Fprintf(cmd.OutOrStdout(), "%#v\n", version.Get())
-			return nil
+			info := versionInfo{Info: getVersion(), APIGroupSuffix: groupsuffix.PinnipedDefaultSuffix}
+			return writeVersionOutput(cmd.OutOrStdout(), outputFormat, info)
 		},
 		Args:  cobra.NoArgs, // do not accept positional arguments for this command
 		Use:   "version",
 		Short: "Print the version of this Pinniped CLI",
 	}
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format (e.g., 'json', 'text')")
+	return cmd
+}
+
+func writeVersionOutput(out io.Writer, outputFormat string, info versionInfo) error {
+	switch outputFormat {
+	case "text":
+		_, err := fmt.Fprint(out, here.Docf(`
+			Version: %s
+			Git commit: %s
+			Build date: %s
+			Go version: %s
+			Platform: %s
+			Default API group suffix: %s
+		`, info.GitVersion, info.GitCommit, info.BuildDate, info.GoVersion, info.Platform, info.APIGroupSuffix))
+		return err
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	default:
+		return fmt.Errorf("unknown output format: %q", outputFormat)
+	}
 }
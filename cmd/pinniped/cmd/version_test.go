@@ -1,4 +1,4 @@
-// Copyright 2020 the Pinniped contributors. All Rights Reserved.
+// Copyright 2020-2021 the Pinniped contributors. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
 package cmd
@@ -9,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	apimachineryversion "k8s.io/apimachinery/pkg/version"
 
 	"go.pinniped.dev/internal/here"
 )
@@ -19,7 +20,8 @@ var (
 		  version \[flags\]
 
 		Flags:
-		  -h, --help   help for version
+		  -h, --help            help for version
+		  -o, --output string   Output format \(e.g., 'json', 'text'\) \(default "text"\)
 
 		`)
 
@@ -30,13 +32,24 @@ var (
 		  version \[flags\]
 
 		Flags:
-		  -h, --help   help for version
+		  -h, --help            help for version
+		  -o, --output string   Output format \(e.g., 'json', 'text'\) \(default "text"\)
 		`)
-
-	emptyVersionRegexp = `version.Info{Major:"", Minor:"", GitVersion:".*", GitCommit:".*", GitTreeState:"", BuildDate:".*", GoVersion:".*", Compiler:".*", Platform:".*/.*"}`
 )
 
 func TestNewVersionCmd(t *testing.T) {
+	testVersion := apimachineryversion.Info{
+		Major:        "0",
+		Minor:        "7",
+		GitVersion:   "v0.7.0",
+		GitCommit:    "abc123",
+		GitTreeState: "clean",
+		BuildDate:    "2021-04-01T00:00:00Z",
+		GoVersion:    "go1.16.2",
+		Compiler:     "gc",
+		Platform:     "linux/amd64",
+	}
+
 	tests := []struct {
 		name             string
 		args             []string
@@ -45,9 +58,40 @@ func TestNewVersionCmd(t *testing.T) {
 		wantStderrRegexp string
 	}{
 		{
-			name:             "no flags",
-			args:             []string{},
-			wantStdoutRegexp: emptyVersionRegexp + "\n",
+			name: "no flags",
+			args: []string{},
+			wantStdoutRegexp: `^` + here.Doc(`
+				Version: v0\.7\.0
+				Git commit: abc123
+				Build date: 2021-04-01T00:00:00Z
+				Go version: go1\.16\.2
+				Platform: linux/amd64
+				Default API group suffix: pinniped\.dev
+			`) + `$`,
+		},
+		{
+			name: "json output",
+			args: []string{"--output", "json"},
+			wantStdoutRegexp: `^` + here.Doc(`
+				\{
+				  "major": "0",
+				  "minor": "7",
+				  "gitVersion": "v0\.7\.0",
+				  "gitCommit": "abc123",
+				  "gitTreeState": "clean",
+				  "buildDate": "2021-04-01T00:00:00Z",
+				  "goVersion": "go1\.16\.2",
+				  "compiler": "gc",
+				  "platform": "linux/amd64",
+				  "apiGroupSuffix": "pinniped\.dev"
+				\}
+			`) + `$`,
+		},
+		{
+			name:             "invalid output format",
+			args:             []string{"--output", "yaml"},
+			wantError:        true,
+			wantStderrRegexp: `Error: unknown output format: "yaml"`,
 		},
 		{
 			name:             "help flag passed",
@@ -65,7 +109,7 @@ func TestNewVersionCmd(t *testing.T) {
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			cmd := newVersionCommand()
+			cmd := newVersionCommand(func() apimachineryversion.Info { return testVersion })
 			require.NotNil(t, cmd)
 
 			var stdout, stderr bytes.Buffer
@@ -89,6 +89,8 @@ func runWhoami(output io.Writer, getClientset getConciergeClientsetFunc, flags *
 		return fmt.Errorf("could not complete WhoAmIRequest%s: %w", hint, err)
 	}
 
+	whoAmI = filterWhoAmIGroups(whoAmI, passthroughGroups)
+
 	if err := writeWhoamiOutput(output, flags, clusterInfo, whoAmI); err != nil {
 		return fmt.Errorf("could not write output: %w", err)
 	}
@@ -96,6 +98,32 @@ func runWhoami(output io.Writer, getClientset getConciergeClientsetFunc, flags *
 	return nil
 }
 
+// groupFilterFunc strips or renames the groups resolved by a WhoAmIRequest.
+type groupFilterFunc func(groups []string) []string
+
+// passthroughGroups is a groupFilterFunc which returns the groups unchanged.
+func passthroughGroups(groups []string) []string {
+	return groups
+}
+
+// withoutSystemGroups is a groupFilterFunc which drops the "system:" prefixed groups, e.g. "system:authenticated".
+func withoutSystemGroups(groups []string) []string {
+	filtered := make([]string, 0, len(groups))
+	for _, group := range groups {
+		if !strings.HasPrefix(group, "system:") {
+			filtered = append(filtered, group)
+		}
+	}
+	return filtered
+}
+
+// filterWhoAmIGroups returns a copy of the WhoAmIRequest with the filter applied to the resolved groups.
+func filterWhoAmIGroups(whoAmI *identityv1alpha1.WhoAmIRequest, filter groupFilterFunc) *identityv1alpha1.WhoAmIRequest {
+	filtered := whoAmI.DeepCopy()
+	filtered.Status.KubernetesUserInfo.User.Groups = filter(filtered.Status.KubernetesUserInfo.User.Groups)
+	return filtered
+}
+
 func getCurrentCluster(clientConfig clientcmd.ClientConfig, currentContextNameOverride string) (*clusterInfo, error) {
 	currentKubeConfig, err := clientConfig.RawConfig()
 	if err != nil {
@@ -325,3 +325,55 @@ func TestWhoami(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterWhoAmIGroups(t *testing.T) {
+	whoAmI := &identityv1alpha1.WhoAmIRequest{
+		Status: identityv1alpha1.WhoAmIRequestStatus{
+			KubernetesUserInfo: identityv1alpha1.KubernetesUserInfo{
+				User: identityv1alpha1.UserInfo{
+					Username: "some-username",
+					Groups:   []string{"some-group-0", "system:authenticated", "some-group-1", "system:masters"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		filter     groupFilterFunc
+		wantGroups []string
+	}{
+		{
+			name:       "passthrough",
+			filter:     passthroughGroups,
+			wantGroups: []string{"some-group-0", "system:authenticated", "some-group-1", "system:masters"},
+		},
+		{
+			name:       "without system groups",
+			filter:     withoutSystemGroups,
+			wantGroups: []string{"some-group-0", "some-group-1"},
+		},
+		{
+			name: "custom rename",
+			filter: func(groups []string) []string {
+				renamed := make([]string, 0, len(groups))
+				for _, group := range groups {
+					renamed = append(renamed, "prefix:"+group)
+				}
+				return renamed
+			},
+			wantGroups: []string{"prefix:some-group-0", "prefix:system:authenticated", "prefix:some-group-1", "prefix:system:masters"},
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			filtered := filterWhoAmIGroups(whoAmI, test.filter)
+			require.Equal(t, test.wantGroups, filtered.Status.KubernetesUserInfo.User.Groups)
+			require.Equal(t, "some-username", filtered.Status.KubernetesUserInfo.User.Username)
+
+			// The original request is left untouched.
+			require.Equal(t, []string{"some-group-0", "system:authenticated", "some-group-1", "system:masters"}, whoAmI.Status.KubernetesUserInfo.User.Groups)
+		})
+	}
+}
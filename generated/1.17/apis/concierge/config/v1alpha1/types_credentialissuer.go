@@ -36,8 +36,28 @@ const (
 	FetchedKeyStrategyReason             = StrategyReason("FetchedKey")
 )
 
+// +kubebuilder:validation:Enum=Pending;Ready;Error
+type CredentialIssuerPhase string
+
+const (
+	// PendingCredentialIssuerPhase is the phase when no preferred strategy has succeeded yet and no strategy has
+	// failed, e.g. while the strategies are still being set up.
+	PendingCredentialIssuerPhase = CredentialIssuerPhase("Pending")
+
+	// ReadyCredentialIssuerPhase is the phase when at least one preferred strategy has succeeded.
+	ReadyCredentialIssuerPhase = CredentialIssuerPhase("Ready")
+
+	// ErrorCredentialIssuerPhase is the phase when no preferred strategy has succeeded and at least one has failed.
+	ErrorCredentialIssuerPhase = CredentialIssuerPhase("Error")
+)
+
 // Status of a credential issuer.
 type CredentialIssuerStatus struct {
+	// Phase summarizes the overall status of the CredentialIssuer across all of its strategies.
+	// +kubebuilder:default=Pending
+	// +optional
+	Phase CredentialIssuerPhase `json:"phase,omitempty"`
+
 	// List of integration strategies that were attempted by Pinniped.
 	Strategies []CredentialIssuerStrategy `json:"strategies"`
 
@@ -123,6 +143,8 @@ type ImpersonationProxyInfo struct {
 // +genclient:nonNamespaced
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:resource:categories=pinniped,scope=Cluster
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 // +kubebuilder:subresource:status
 type CredentialIssuer struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -18,6 +18,8 @@ import (
 	"io"
 	"math/big"
 	"net"
+	"sort"
+	"sync"
 	"time"
 
 	"go.pinniped.dev/internal/constable"
@@ -59,6 +61,10 @@ type CA struct {
 
 	// env is our reference to the outside world (clocks and random number generation).
 	env env
+
+	// certsOnce guards the lazy parsing of caCertBytes into certs, which is done at most once per CA.
+	certsOnce sync.Once
+	certs     []*x509.Certificate
 }
 
 // secureEnv is the "real" environment using secure RNGs and the real system clock.
@@ -162,13 +168,52 @@ func (c *CA) PrivateKeyToPEM() ([]byte, error) {
 	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: derKey}), nil
 }
 
+// Certificates returns the current CA signing bundle as parsed certificates. The certificates are parsed on the
+// first call and cached, so callers must not modify them.
+func (c *CA) Certificates() []*x509.Certificate {
+	c.certsOnce.Do(func() {
+		cert, err := x509.ParseCertificate(c.caCertBytes)
+		if err != nil {
+			return // the bundle is empty if the CA somehow holds an invalid certificate
+		}
+		c.certs = []*x509.Certificate{cert}
+	})
+	return c.certs
+}
+
 // Pool returns the current CA signing bundle as a *x509.CertPool.
 func (c *CA) Pool() *x509.CertPool {
 	pool := x509.NewCertPool()
-	pool.AppendCertsFromPEM(c.Bundle())
+	for _, cert := range c.Certificates() {
+		pool.AddCert(cert)
+	}
 	return pool
 }
 
+// PoolsEqual returns whether the two pools contain the same set of certificate subjects, regardless of the order in
+// which the certificates were added. A nil pool is considered to be equal to an empty pool.
+func PoolsEqual(a, b *x509.CertPool) bool {
+	aSubjects, bSubjects := sortedSubjects(a), sortedSubjects(b)
+	if len(aSubjects) != len(bSubjects) {
+		return false
+	}
+	for i := range aSubjects {
+		if !bytes.Equal(aSubjects[i], bSubjects[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedSubjects(pool *x509.CertPool) [][]byte {
+	if pool == nil {
+		return nil
+	}
+	subjects := pool.Subjects()
+	sort.Slice(subjects, func(i, j int) bool { return bytes.Compare(subjects[i], subjects[j]) < 0 })
+	return subjects
+}
+
 // IssueClientCert issues a new client certificate with username and groups included in the Kube-style
 // certificate subject for the given identity and duration.
 func (c *CA) IssueClientCert(username string, groups []string, ttl time.Duration) (*tls.Certificate, error) {
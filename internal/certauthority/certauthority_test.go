@@ -209,6 +209,61 @@ func TestPool(t *testing.T) {
 	require.Len(t, pool.Subjects(), 1)
 }
 
+func TestCertificates(t *testing.T) {
+	ca, err := New("test-common-name", 1*time.Hour)
+	require.NoError(t, err)
+
+	certs := ca.Certificates()
+	require.Len(t, certs, 1)
+	require.Equal(t, "test-common-name", certs[0].Subject.CommonName)
+	require.True(t, certs[0].IsCA)
+	require.Equal(t, certs[0].Raw, ca.caCertBytes)
+
+	// the parsed certificates are cached after the first call
+	require.Same(t, certs[0], ca.Certificates()[0])
+
+	// an unparsable certificate results in an empty bundle
+	invalid := CA{caCertBytes: []byte{1, 2, 3, 4, 5, 6, 7, 8}}
+	require.Empty(t, invalid.Certificates())
+	require.Empty(t, invalid.Pool().Subjects())
+}
+
+func TestPoolsEqual(t *testing.T) {
+	ca1, err := New("test-ca-1", 1*time.Hour)
+	require.NoError(t, err)
+	ca2, err := New("test-ca-2", 1*time.Hour)
+	require.NoError(t, err)
+
+	poolOf := func(cas ...*CA) *x509.CertPool {
+		pool := x509.NewCertPool()
+		for _, ca := range cas {
+			require.True(t, pool.AppendCertsFromPEM(ca.Bundle()))
+		}
+		return pool
+	}
+
+	tests := []struct {
+		name string
+		a, b *x509.CertPool
+		want bool
+	}{
+		{name: "both nil", a: nil, b: nil, want: true},
+		{name: "nil and empty", a: nil, b: x509.NewCertPool(), want: true},
+		{name: "nil and non-empty", a: nil, b: poolOf(ca1), want: false},
+		{name: "equal", a: poolOf(ca1), b: ca1.Pool(), want: true},
+		{name: "equal but differently ordered", a: poolOf(ca1, ca2), b: poolOf(ca2, ca1), want: true},
+		{name: "different subjects", a: poolOf(ca1), b: poolOf(ca2), want: false},
+		{name: "different lengths", a: poolOf(ca1), b: poolOf(ca1, ca2), want: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, PoolsEqual(tt.a, tt.b))
+			require.Equal(t, tt.want, PoolsEqual(tt.b, tt.a))
+		})
+	}
+}
+
 type errSigner struct {
 	pubkey crypto.PublicKey
 	err    error
@@ -230,12 +285,12 @@ func TestIssue(t *testing.T) {
 
 	tests := []struct {
 		name    string
-		ca      CA
+		ca      *CA
 		wantErr string
 	}{
 		{
 			name: "failed to generate serial",
-			ca: CA{
+			ca: &CA{
 				env: env{
 					serialRNG: strings.NewReader(""),
 				},
@@ -244,7 +299,7 @@ func TestIssue(t *testing.T) {
 		},
 		{
 			name: "failed to generate keypair",
-			ca: CA{
+			ca: &CA{
 				env: env{
 					serialRNG: strings.NewReader(strings.Repeat("x", numRandBytes)),
 					keygenRNG: strings.NewReader(""),
@@ -254,7 +309,7 @@ func TestIssue(t *testing.T) {
 		},
 		{
 			name: "invalid CA certificate",
-			ca: CA{
+			ca: &CA{
 				env: env{
 					serialRNG: strings.NewReader(strings.Repeat("x", numRandBytes)),
 					keygenRNG: strings.NewReader(strings.Repeat("x", numRandBytes)),
@@ -265,7 +320,7 @@ func TestIssue(t *testing.T) {
 		},
 		{
 			name: "signing error",
-			ca: CA{
+			ca: &CA{
 				env: env{
 					serialRNG: strings.NewReader(strings.Repeat("x", numRandBytes)),
 					keygenRNG: strings.NewReader(strings.Repeat("x", numRandBytes)),
@@ -281,7 +336,7 @@ func TestIssue(t *testing.T) {
 		},
 		{
 			name: "parse certificate error",
-			ca: CA{
+			ca: &CA{
 				env: env{
 					serialRNG: strings.NewReader(strings.Repeat("x", numRandBytes)),
 					keygenRNG: strings.NewReader(strings.Repeat("x", numRandBytes)),
@@ -297,7 +352,7 @@ func TestIssue(t *testing.T) {
 		},
 		{
 			name: "success",
-			ca: CA{
+			ca: &CA{
 				env: env{
 					serialRNG: strings.NewReader(strings.Repeat("x", numRandBytes)),
 					keygenRNG: strings.NewReader(strings.Repeat("x", numRandBytes)),
@@ -29,11 +29,12 @@ type Config struct {
 type ExtraConfig struct {
 	Authenticator                 credentialrequest.TokenCredentialRequestAuthenticator
 	Issuer                        issuer.ClientCertIssuer
-	StartControllersPostStartHook func(ctx context.Context)
+	StartControllersPostStartHook func(ctx context.Context) error
 	Scheme                        *runtime.Scheme
 	NegotiatedSerializer          runtime.NegotiatedSerializer
 	LoginConciergeGroupVersion    schema.GroupVersion
 	IdentityConciergeGroupVersion schema.GroupVersion
+	TableColumns                  []string
 }
 
 type PinnipedServer struct {
@@ -78,12 +79,12 @@ func (c completedConfig) New() (*PinnipedServer, error) {
 	for _, f := range []func() (schema.GroupVersionResource, rest.Storage){
 		func() (schema.GroupVersionResource, rest.Storage) {
 			tokenCredReqGVR := c.ExtraConfig.LoginConciergeGroupVersion.WithResource("tokencredentialrequests")
-			tokenCredStorage := credentialrequest.NewREST(c.ExtraConfig.Authenticator, c.ExtraConfig.Issuer, tokenCredReqGVR.GroupResource())
+			tokenCredStorage := credentialrequest.NewREST(c.ExtraConfig.Authenticator, c.ExtraConfig.Issuer, tokenCredReqGVR.GroupResource(), c.ExtraConfig.TableColumns)
 			return tokenCredReqGVR, tokenCredStorage
 		},
 		func() (schema.GroupVersionResource, rest.Storage) {
 			whoAmIReqGVR := c.ExtraConfig.IdentityConciergeGroupVersion.WithResource("whoamirequests")
-			whoAmIStorage := whoamirequest.NewREST(whoAmIReqGVR.GroupResource())
+			whoAmIStorage := whoamirequest.NewREST(whoAmIReqGVR.GroupResource(), c.ExtraConfig.TableColumns)
 			return whoAmIReqGVR, whoAmIStorage
 		},
 	} {
@@ -114,9 +115,7 @@ func (c completedConfig) New() (*PinnipedServer, error) {
 				<-postStartContext.StopCh
 				cancel()
 			}()
-			c.ExtraConfig.StartControllersPostStartHook(ctx)
-
-			return nil
+			return c.ExtraConfig.StartControllersPostStartHook(ctx)
 		},
 	)
 
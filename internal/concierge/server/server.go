@@ -14,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/wait"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	genericoptions "k8s.io/apiserver/pkg/server/options"
 
@@ -128,6 +129,12 @@ func (a *App) runServer(ctx context.Context) error {
 	// injected suffix).
 	scheme, loginGV, identityGV := conciergescheme.New(*cfg.APIGroupSuffix)
 
+	// This was already validated when the config was loaded.
+	servingCertIPs, err := concierge.IPAddresses(cfg.APIConfig.ServingCertificateConfig.IPAddresses)
+	if err != nil {
+		return fmt.Errorf("could not load config: %w", err)
+	}
+
 	// Prepare to start the controllers, but defer actually starting them until the
 	// post start hook of the aggregated API server.
 	startControllersFunc, err := controllermanager.PrepareControllers(
@@ -142,25 +149,53 @@ func (a *App) runServer(ctx context.Context) error {
 			DynamicSigningCertProvider:       dynamicSigningCertProvider,
 			ImpersonationSigningCertProvider: impersonationProxySigningCertProvider,
 			ServingCertDuration:              time.Duration(*cfg.APIConfig.ServingCertificateConfig.DurationSeconds) * time.Second,
-			ServingCertRenewBefore:           time.Duration(*cfg.APIConfig.ServingCertificateConfig.RenewBeforeSeconds) * time.Second,
+			ServingCertRenewBefore:           concierge.RenewBefore(cfg.APIConfig.ServingCertificateConfig),
+			ServingCertDNSNames:              cfg.APIConfig.ServingCertificateConfig.DNSNames,
+			ServingCertIPAddresses:           servingCertIPs,
 			AuthenticatorCache:               authenticators,
+			DisableKubeConfigInfoMirroring:   cfg.DisableKubeConfigInfoMirroring,
 		},
 	)
 	if err != nil {
 		return fmt.Errorf("could not prepare controllers: %w", err)
 	}
 
+	// This was already validated when the config was loaded.
+	minTLSVersion, err := concierge.TLSVersion(*cfg.APIConfig.ServingCertificateConfig.MinTLSVersion)
+	if err != nil {
+		return fmt.Errorf("could not load config: %w", err)
+	}
+
 	certIssuer := issuer.ClientCertIssuers{
 		dynamiccertauthority.New(dynamicSigningCertProvider),            // attempt to use the real Kube CA if possible
 		dynamiccertauthority.New(impersonationProxySigningCertProvider), // fallback to our internal CA if we need to
 	}
 
+	// Start the controllers, and then make sure that they were able to populate the serving cert with a usable
+	// cert and key. Failing here causes the post start hook to fail, which stops the process, rather than letting
+	// it silently serve nothing.
+	startControllersAndCheckServingCertFunc := func(ctx context.Context) error {
+		startControllersFunc(ctx)
+		if err := waitForCertContent(ctx, dynamicServingCertProvider, initialCertContentTimeout); err != nil {
+			return err
+		}
+		// The aggregated API server builds its own TLS config from the same provider (see getAggregatedAPIServerConfig),
+		// so this config is only used to load the initial content exactly the way that server will load it.
+		if _, _, err := dynamiccert.NewServingTLSConfig(nil, dynamicServingCertProvider); err != nil {
+			plog.Error("could not load the initial serving cert", err, "name", dynamicServingCertProvider.Name())
+			return err
+		}
+		return nil
+	}
+
 	// Get the aggregated API server config.
 	aggregatedAPIServerConfig, err := getAggregatedAPIServerConfig(
 		dynamicServingCertProvider,
+		minTLSVersion,
+		cfg.APIConfig.TableColumns,
 		authenticators,
 		certIssuer,
-		startControllersFunc,
+		startControllersAndCheckServingCertFunc,
 		*cfg.APIGroupSuffix,
 		scheme,
 		loginGV,
@@ -176,16 +211,49 @@ func (a *App) runServer(ctx context.Context) error {
 		return fmt.Errorf("could not create aggregated API server: %w", err)
 	}
 
+	// Stop notifying the serving cert controller of cert changes once shutdown begins. Pre-shutdown hooks run
+	// before the server stops its listeners and drains in-flight requests, so closing the providers here keeps
+	// the TLS config stable for any handshakes that are still in progress while the server drains.
+	server.GenericAPIServer.AddPreShutdownHookOrDie("close-dynamic-cert-providers",
+		func() error {
+			dynamicServingCertProvider.Close()
+			dynamicSigningCertProvider.Close()
+			impersonationProxySigningCertProvider.Close()
+			return nil
+		},
+	)
+
 	// Run the server. Its post-start hook will start the controllers.
 	return server.GenericAPIServer.PrepareRun().Run(ctx.Done())
 }
 
+// initialCertContentTimeout is how long the controllers are given to populate the serving cert after they start.
+const initialCertContentTimeout = 2 * time.Minute
+
+// waitForCertContent waits for the provider to have cert content, returning an error if it is still unset after the
+// timeout has elapsed.
+func waitForCertContent(ctx context.Context, provider dynamiccert.Private, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := wait.PollImmediateUntil(time.Second, func() (bool, error) {
+		return provider.HasContent(), nil
+	}, ctx.Done())
+	if err != nil {
+		plog.Error("cert content was not populated after starting the controllers", err, "name", provider.Name(), "timeout", timeout)
+		return fmt.Errorf("%s: cert content was not populated within %s: %w", provider.Name(), timeout, err)
+	}
+	return nil
+}
+
 // Create a configuration for the aggregated API server.
 func getAggregatedAPIServerConfig(
 	dynamicCertProvider dynamiccert.Private,
+	minTLSVersion uint16,
+	tableColumns []string,
 	authenticator credentialrequest.TokenCredentialRequestAuthenticator,
 	issuer issuer.ClientCertIssuer,
-	startControllersPostStartHook func(context.Context),
+	startControllersPostStartHook func(context.Context) error,
 	apiGroupSuffix string,
 	scheme *runtime.Scheme,
 	loginConciergeGroupVersion, identityConciergeGroupVersion schema.GroupVersion,
@@ -217,6 +285,9 @@ func getAggregatedAPIServerConfig(
 		return nil, err
 	}
 
+	// This becomes the MinVersion of the tls.Config used by the running server.
+	serverConfig.SecureServing.MinTLSVersion = minTLSVersion
+
 	apiServerConfig := &apiserver.Config{
 		GenericConfig: serverConfig,
 		ExtraConfig: apiserver.ExtraConfig{
@@ -227,6 +298,7 @@ func getAggregatedAPIServerConfig(
 			NegotiatedSerializer:          codecs,
 			LoginConciergeGroupVersion:    loginConciergeGroupVersion,
 			IdentityConciergeGroupVersion: identityConciergeGroupVersion,
+			TableColumns:                  tableColumns,
 		},
 	}
 	return apiServerConfig, nil
@@ -6,22 +6,37 @@
 package concierge
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"strings"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/yaml"
 
 	"go.pinniped.dev/internal/constable"
 	"go.pinniped.dev/internal/groupsuffix"
 	"go.pinniped.dev/internal/plog"
+	"go.pinniped.dev/internal/registry/tablecolumns"
 )
 
 const (
 	aboutAYear   = 60 * 60 * 24 * 365
 	about9Months = 60 * 60 * 24 * 30 * 9
+
+	defaultMinTLSVersion = "1.2"
 )
 
+// tlsVersions maps the supported values of ServingCertificateConfigSpec.MinTLSVersion to their crypto/tls constants.
+//nolint: gochecknoglobals
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
 // FromPath loads an Config from a provided local file path, inserts any
 // defaults (from the Config documentation), and verifies that the config is
 // valid (per the Config documentation).
@@ -40,9 +55,7 @@ func FromPath(path string) (*Config, error) {
 		return nil, fmt.Errorf("decode yaml: %w", err)
 	}
 
-	maybeSetAPIDefaults(&config.APIConfig)
-	maybeSetAPIGroupSuffixDefault(&config.APIGroupSuffix)
-	maybeSetKubeCertAgentDefaults(&config.KubeCertAgentConfig)
+	SetDefaults(&config)
 
 	if err := validateAPI(&config.APIConfig); err != nil {
 		return nil, fmt.Errorf("validate api: %w", err)
@@ -56,17 +69,34 @@ func FromPath(path string) (*Config, error) {
 		return nil, fmt.Errorf("validate names: %w", err)
 	}
 
+	if err := validateKubeCertAgent(&config.KubeCertAgentConfig); err != nil {
+		return nil, fmt.Errorf("validate kubeCertAgent: %w", err)
+	}
+
 	if err := plog.ValidateAndSetLogLevelGlobally(config.LogLevel); err != nil {
 		return nil, fmt.Errorf("validate log level: %w", err)
 	}
 
-	if config.Labels == nil {
-		config.Labels = make(map[string]string)
+	// Check the file as it was written, since decoding it silently drops any unknown (e.g. misspelled) fields.
+	if err := ValidateYAML(data); err != nil {
+		return nil, fmt.Errorf("validate schema: %w", err)
 	}
 
 	return &config, nil
 }
 
+// SetDefaults sets the documented default value of each field of the Config which is unset. Fields which are
+// already set are left alone, so calling it more than once has no further effect.
+func SetDefaults(config *Config) {
+	maybeSetAPIDefaults(&config.APIConfig)
+	maybeSetAPIGroupSuffixDefault(&config.APIGroupSuffix)
+	maybeSetKubeCertAgentDefaults(&config.KubeCertAgentConfig)
+
+	if config.Labels == nil {
+		config.Labels = make(map[string]string)
+	}
+}
+
 func maybeSetAPIDefaults(apiConfig *APIConfigSpec) {
 	if apiConfig.ServingCertificateConfig.DurationSeconds == nil {
 		apiConfig.ServingCertificateConfig.DurationSeconds = int64Ptr(aboutAYear)
@@ -75,6 +105,10 @@ func maybeSetAPIDefaults(apiConfig *APIConfigSpec) {
 	if apiConfig.ServingCertificateConfig.RenewBeforeSeconds == nil {
 		apiConfig.ServingCertificateConfig.RenewBeforeSeconds = int64Ptr(about9Months)
 	}
+
+	if apiConfig.ServingCertificateConfig.MinTLSVersion == nil {
+		apiConfig.ServingCertificateConfig.MinTLSVersion = stringPtr(defaultMinTLSVersion)
+	}
 }
 
 func maybeSetAPIGroupSuffixDefault(apiGroupSuffix **string) {
@@ -91,6 +125,14 @@ func maybeSetKubeCertAgentDefaults(cfg *KubeCertAgentSpec) {
 	if cfg.Image == nil {
 		cfg.Image = stringPtr("debian:latest")
 	}
+
+	if cfg.ImagePullPolicy == nil {
+		cfg.ImagePullPolicy = stringPtr(string(corev1.PullIfNotPresent))
+	}
+
+	if cfg.MaxConcurrentPodCreates == nil {
+		cfg.MaxConcurrentPodCreates = intPtr(1)
+	}
 }
 
 func validateNames(names *NamesConfigSpec) error {
@@ -137,9 +179,111 @@ func validateAPI(apiConfig *APIConfigSpec) error {
 		return constable.Error("renewBefore must be positive")
 	}
 
+	if _, err := TLSVersion(*apiConfig.ServingCertificateConfig.MinTLSVersion); err != nil {
+		return err
+	}
+
+	if err := validateServingCertificateSANs(&apiConfig.ServingCertificateConfig); err != nil {
+		return err
+	}
+
+	if apiConfig.TableColumns != nil && len(apiConfig.TableColumns) == 0 {
+		return constable.Error("tableColumns cannot be empty")
+	}
+
+	for _, column := range apiConfig.TableColumns {
+		if !tablecolumns.Supported(column) {
+			return fmt.Errorf(`invalid tableColumns entry %q, supported values are "Name" and "Created At"`, column)
+		}
+	}
+
 	return nil
 }
 
+func validateServingCertificateSANs(servingCertConfig *ServingCertificateConfigSpec) error {
+	if servingCertConfig.DNSNames != nil && len(servingCertConfig.DNSNames) == 0 {
+		return constable.Error("dnsNames cannot be empty")
+	}
+
+	if servingCertConfig.IPAddresses != nil && len(servingCertConfig.IPAddresses) == 0 {
+		return constable.Error("ipAddresses cannot be empty")
+	}
+
+	for _, dnsName := range servingCertConfig.DNSNames {
+		// Allow wildcard names such as *.example.com, since they are valid in a serving certificate.
+		if errs := validation.IsDNS1123Subdomain(strings.TrimPrefix(dnsName, "*.")); len(errs) > 0 {
+			return fmt.Errorf("invalid dnsNames entry %q: %s", dnsName, strings.Join(errs, ", "))
+		}
+	}
+
+	if _, err := IPAddresses(servingCertConfig.IPAddresses); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func validateKubeCertAgent(agentConfig *KubeCertAgentSpec) error {
+	if *agentConfig.MaxConcurrentPodCreates < 1 {
+		return constable.Error("maxConcurrentPodCreates must be positive")
+	}
+
+	switch corev1.PullPolicy(*agentConfig.ImagePullPolicy) {
+	case corev1.PullAlways, corev1.PullIfNotPresent, corev1.PullNever:
+		return nil
+	default:
+		return fmt.Errorf(`invalid imagePullPolicy %q, supported values are "Always", "IfNotPresent", and "Never"`, *agentConfig.ImagePullPolicy)
+	}
+}
+
+// TLSVersion returns the crypto/tls version constant (suitable for use as a tls.Config.MinVersion)
+// for a ServingCertificateConfigSpec.MinTLSVersion value such as "1.2" or "1.3".
+func TLSVersion(version string) (uint16, error) {
+	tlsVersion, ok := tlsVersions[version]
+	if !ok {
+		return 0, fmt.Errorf(`invalid minTLSVersion %q, supported values are "1.2" and "1.3"`, version)
+	}
+	return tlsVersion, nil
+}
+
+// RenewBefore returns the period of time, starting upon issuance of the serving certificate, that Pinniped waits
+// before rotating it. Unset fields of the spec use their documented defaults, and the result is never longer than
+// the validity period of the certificate, since there is no point in waiting until after it has expired.
+func RenewBefore(spec ServingCertificateConfigSpec) time.Duration {
+	durationSeconds, renewBeforeSeconds := int64(aboutAYear), int64(about9Months)
+	if spec.DurationSeconds != nil {
+		durationSeconds = *spec.DurationSeconds
+	}
+	if spec.RenewBeforeSeconds != nil {
+		renewBeforeSeconds = *spec.RenewBeforeSeconds
+	}
+	if renewBeforeSeconds > durationSeconds {
+		renewBeforeSeconds = durationSeconds
+	}
+	return time.Duration(renewBeforeSeconds) * time.Second
+}
+
+// RotateAfter returns the time after which a serving certificate which was issued at issuedAt should be rotated.
+func RotateAfter(spec ServingCertificateConfigSpec, issuedAt time.Time) time.Time {
+	return issuedAt.Add(RenewBefore(spec))
+}
+
+// IPAddresses parses the ServingCertificateConfigSpec.IPAddresses values into net.IPs.
+func IPAddresses(addresses []string) ([]net.IP, error) {
+	if addresses == nil {
+		return nil, nil
+	}
+	ips := make([]net.IP, 0, len(addresses))
+	for _, address := range addresses {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid ipAddresses entry %q, must be an IPv4 or IPv6 address", address)
+		}
+		ips = append(ips, ip)
+	}
+	return ips, nil
+}
+
 func validateAPIGroupSuffix(apiGroupSuffix string) error {
 	return groupsuffix.Validate(apiGroupSuffix)
 }
@@ -148,6 +292,10 @@ func int64Ptr(i int64) *int64 {
 	return &i
 }
 
+func intPtr(i int) *int {
+	return &i
+}
+
 func stringPtr(s string) *string {
 	return &s
 }
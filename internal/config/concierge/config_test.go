@@ -4,9 +4,11 @@
 package concierge
 
 import (
+	"crypto/tls"
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -31,12 +33,15 @@ func TestFromPath(t *testing.T) {
 				  servingCertificate:
 					durationSeconds: 3600
 					renewBeforeSeconds: 2400
+					minTLSVersion: "1.3"
+					dnsNames: [pinniped.example.com, "*.pinniped.example.com"]
+					ipAddresses: [10.0.0.1, "::1"]
+				  tableColumns: [Name]
 				apiGroupSuffix: some.suffix.com
 				names:
 				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
 				  credentialIssuer: pinniped-config
 				  apiService: pinniped-api
-				  kubeCertAgentPrefix: kube-cert-agent-prefix
 				  impersonationConfigMap: impersonationConfigMap-value
 				  impersonationLoadBalancerService: impersonationLoadBalancerService-value
 				  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
@@ -49,8 +54,11 @@ func TestFromPath(t *testing.T) {
 				kubeCertAgent:
 				  namePrefix: kube-cert-agent-name-prefix-
 				  image: kube-cert-agent-image
+				  imagePullPolicy: Always
 				  imagePullSecrets: [kube-cert-agent-image-pull-secret]
+				  maxConcurrentPodCreates: 3
 				logLevel: debug
+				disableKubeConfigInfoMirroring: true
 			`),
 			wantConfig: &Config{
 				DiscoveryInfo: DiscoveryInfoSpec{
@@ -60,7 +68,11 @@ func TestFromPath(t *testing.T) {
 					ServingCertificateConfig: ServingCertificateConfigSpec{
 						DurationSeconds:    int64Ptr(3600),
 						RenewBeforeSeconds: int64Ptr(2400),
+						MinTLSVersion:      stringPtr("1.3"),
+						DNSNames:           []string{"pinniped.example.com", "*.pinniped.example.com"},
+						IPAddresses:        []string{"10.0.0.1", "::1"},
 					},
+					TableColumns: []string{"Name"},
 				},
 				APIGroupSuffix: stringPtr("some.suffix.com"),
 				NamesConfig: NamesConfigSpec{
@@ -78,11 +90,14 @@ func TestFromPath(t *testing.T) {
 					"myLabelKey2": "myLabelValue2",
 				},
 				KubeCertAgentConfig: KubeCertAgentSpec{
-					NamePrefix:       stringPtr("kube-cert-agent-name-prefix-"),
-					Image:            stringPtr("kube-cert-agent-image"),
-					ImagePullSecrets: []string{"kube-cert-agent-image-pull-secret"},
+					NamePrefix:              stringPtr("kube-cert-agent-name-prefix-"),
+					Image:                   stringPtr("kube-cert-agent-image"),
+					ImagePullPolicy:         stringPtr("Always"),
+					ImagePullSecrets:        []string{"kube-cert-agent-image-pull-secret"},
+					MaxConcurrentPodCreates: intPtr(3),
 				},
-				LogLevel: plog.LevelDebug,
+				LogLevel:                       plog.LevelDebug,
+				DisableKubeConfigInfoMirroring: true,
 			},
 		},
 		{
@@ -108,6 +123,7 @@ func TestFromPath(t *testing.T) {
 					ServingCertificateConfig: ServingCertificateConfigSpec{
 						DurationSeconds:    int64Ptr(60 * 60 * 24 * 365),    // about a year
 						RenewBeforeSeconds: int64Ptr(60 * 60 * 24 * 30 * 9), // about 9 months
+						MinTLSVersion:      stringPtr("1.2"),
 					},
 				},
 				NamesConfig: NamesConfigSpec{
@@ -122,8 +138,10 @@ func TestFromPath(t *testing.T) {
 				},
 				Labels: map[string]string{},
 				KubeCertAgentConfig: KubeCertAgentSpec{
-					NamePrefix: stringPtr("pinniped-kube-cert-agent-"),
-					Image:      stringPtr("debian:latest"),
+					NamePrefix:              stringPtr("pinniped-kube-cert-agent-"),
+					Image:                   stringPtr("debian:latest"),
+					ImagePullPolicy:         stringPtr("IfNotPresent"),
+					MaxConcurrentPodCreates: intPtr(1),
 				},
 			},
 		},
@@ -329,6 +347,173 @@ func TestFromPath(t *testing.T) {
 			`),
 			wantError: "validate api: renewBefore must be positive",
 		},
+		{
+			name: "InvalidMinTLSVersion",
+			yaml: here.Doc(`
+				---
+				api:
+				  servingCertificate:
+					minTLSVersion: "1.1"
+				names:
+				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+				  credentialIssuer: pinniped-config
+				  apiService: pinniped-api
+				  impersonationConfigMap: impersonationConfigMap-value
+				  impersonationLoadBalancerService: impersonationLoadBalancerService-value
+				  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
+				  impersonationCACertificateSecret: impersonationCACertificateSecret-value
+				  impersonationSignerSecret: impersonationSignerSecret-value
+			`),
+			wantError: `validate api: invalid minTLSVersion "1.1", supported values are "1.2" and "1.3"`,
+		},
+		{
+			name: "InvalidTableColumn",
+			yaml: here.Doc(`
+				---
+				api:
+				  tableColumns: [Name, Age]
+				names:
+				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+				  credentialIssuer: pinniped-config
+				  apiService: pinniped-api
+				  impersonationConfigMap: impersonationConfigMap-value
+				  impersonationLoadBalancerService: impersonationLoadBalancerService-value
+				  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
+				  impersonationCACertificateSecret: impersonationCACertificateSecret-value
+				  impersonationSignerSecret: impersonationSignerSecret-value
+			`),
+			wantError: `validate api: invalid tableColumns entry "Age", supported values are "Name" and "Created At"`,
+		},
+		{
+			name: "EmptyTableColumns",
+			yaml: here.Doc(`
+				---
+				api:
+				  tableColumns: []
+				names:
+				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+				  credentialIssuer: pinniped-config
+				  apiService: pinniped-api
+				  impersonationConfigMap: impersonationConfigMap-value
+				  impersonationLoadBalancerService: impersonationLoadBalancerService-value
+				  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
+				  impersonationCACertificateSecret: impersonationCACertificateSecret-value
+				  impersonationSignerSecret: impersonationSignerSecret-value
+			`),
+			wantError: "validate api: tableColumns cannot be empty",
+		},
+		{
+			name: "EmptyServingCertificateDNSNames",
+			yaml: here.Doc(`
+				---
+				api:
+				  servingCertificate:
+				    dnsNames: []
+				names:
+				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+				  credentialIssuer: pinniped-config
+				  apiService: pinniped-api
+				  impersonationConfigMap: impersonationConfigMap-value
+				  impersonationLoadBalancerService: impersonationLoadBalancerService-value
+				  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
+				  impersonationCACertificateSecret: impersonationCACertificateSecret-value
+				  impersonationSignerSecret: impersonationSignerSecret-value
+			`),
+			wantError: `validate api: dnsNames cannot be empty`,
+		},
+		{
+			name: "EmptyServingCertificateIPAddresses",
+			yaml: here.Doc(`
+				---
+				api:
+				  servingCertificate:
+				    ipAddresses: []
+				names:
+				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+				  credentialIssuer: pinniped-config
+				  apiService: pinniped-api
+				  impersonationConfigMap: impersonationConfigMap-value
+				  impersonationLoadBalancerService: impersonationLoadBalancerService-value
+				  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
+				  impersonationCACertificateSecret: impersonationCACertificateSecret-value
+				  impersonationSignerSecret: impersonationSignerSecret-value
+			`),
+			wantError: `validate api: ipAddresses cannot be empty`,
+		},
+		{
+			name: "InvalidServingCertificateDNSName",
+			yaml: here.Doc(`
+				---
+				api:
+				  servingCertificate:
+				    dnsNames: [Not_A_DNS_Name]
+				names:
+				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+				  credentialIssuer: pinniped-config
+				  apiService: pinniped-api
+				  impersonationConfigMap: impersonationConfigMap-value
+				  impersonationLoadBalancerService: impersonationLoadBalancerService-value
+				  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
+				  impersonationCACertificateSecret: impersonationCACertificateSecret-value
+				  impersonationSignerSecret: impersonationSignerSecret-value
+			`),
+			wantError: `validate api: invalid dnsNames entry "Not_A_DNS_Name": a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')`,
+		},
+		{
+			name: "InvalidServingCertificateIPAddress",
+			yaml: here.Doc(`
+				---
+				api:
+				  servingCertificate:
+				    ipAddresses: [10.0.0.256]
+				names:
+				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+				  credentialIssuer: pinniped-config
+				  apiService: pinniped-api
+				  impersonationConfigMap: impersonationConfigMap-value
+				  impersonationLoadBalancerService: impersonationLoadBalancerService-value
+				  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
+				  impersonationCACertificateSecret: impersonationCACertificateSecret-value
+				  impersonationSignerSecret: impersonationSignerSecret-value
+			`),
+			wantError: `validate api: invalid ipAddresses entry "10.0.0.256", must be an IPv4 or IPv6 address`,
+		},
+		{
+			name: "InvalidKubeCertAgentImagePullPolicy",
+			yaml: here.Doc(`
+				---
+				names:
+				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+				  credentialIssuer: pinniped-config
+				  apiService: pinniped-api
+				  impersonationConfigMap: impersonationConfigMap-value
+				  impersonationLoadBalancerService: impersonationLoadBalancerService-value
+				  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
+				  impersonationCACertificateSecret: impersonationCACertificateSecret-value
+				  impersonationSignerSecret: impersonationSignerSecret-value
+				kubeCertAgent:
+				  imagePullPolicy: Sometimes
+			`),
+			wantError: `validate kubeCertAgent: invalid imagePullPolicy "Sometimes", supported values are "Always", "IfNotPresent", and "Never"`,
+		},
+		{
+			name: "InvalidKubeCertAgentMaxConcurrentPodCreates",
+			yaml: here.Doc(`
+				---
+				names:
+				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+				  credentialIssuer: pinniped-config
+				  apiService: pinniped-api
+				  impersonationConfigMap: impersonationConfigMap-value
+				  impersonationLoadBalancerService: impersonationLoadBalancerService-value
+				  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
+				  impersonationCACertificateSecret: impersonationCACertificateSecret-value
+				  impersonationSignerSecret: impersonationSignerSecret-value
+				kubeCertAgent:
+				  maxConcurrentPodCreates: 0
+			`),
+			wantError: "validate kubeCertAgent: maxConcurrentPodCreates must be positive",
+		},
 		{
 			name: "InvalidAPIGroupSuffix",
 			yaml: here.Doc(`
@@ -350,6 +535,24 @@ func TestFromPath(t *testing.T) {
 			`),
 			wantError: "validate apiGroupSuffix: a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')",
 		},
+		{
+			name: "UnknownField",
+			yaml: here.Doc(`
+				---
+				names:
+				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+				  credentialIssuer: pinniped-config
+				  apiService: pinniped-api
+				  impersonationConfigMap: impersonationConfigMap-value
+				  impersonationLoadBalancerService: impersonationLoadBalancerService-value
+				  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
+				  impersonationCACertificateSecret: impersonationCACertificateSecret-value
+				  impersonationSignerSecret: impersonationSignerSecret-value
+				kubeCertAgnet:
+				  namePrefix: kube-cert-agent-name-prefix-
+			`),
+			wantError: "validate schema: validation failure list:\n.kubeCertAgnet in body is a forbidden property",
+		},
 	}
 	for _, test := range tests {
 		test := test
@@ -378,3 +581,125 @@ func TestFromPath(t *testing.T) {
 		})
 	}
 }
+
+func TestTLSVersion(t *testing.T) {
+	tests := []struct {
+		version   string
+		want      uint16
+		wantError string
+	}{
+		{version: "1.2", want: tls.VersionTLS12},
+		{version: "1.3", want: tls.VersionTLS13},
+		{version: "1.1", wantError: `invalid minTLSVersion "1.1", supported values are "1.2" and "1.3"`},
+		{version: "", wantError: `invalid minTLSVersion "", supported values are "1.2" and "1.3"`},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.version, func(t *testing.T) {
+			got, err := TLSVersion(test.version)
+			if test.wantError != "" {
+				require.EqualError(t, err, test.wantError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestRotateAfter(t *testing.T) {
+	issuedAt := time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		spec ServingCertificateConfigSpec
+		want time.Duration
+	}{
+		{
+			name: "defaults",
+			want: 60 * 60 * 24 * 30 * 9 * time.Second, // about 9 months
+		},
+		{
+			name: "explicit renewBeforeSeconds",
+			spec: ServingCertificateConfigSpec{
+				DurationSeconds:    int64Ptr(3600),
+				RenewBeforeSeconds: int64Ptr(2400),
+			},
+			want: 40 * time.Minute,
+		},
+		{
+			name: "explicit renewBeforeSeconds with default durationSeconds",
+			spec: ServingCertificateConfigSpec{
+				RenewBeforeSeconds: int64Ptr(60),
+			},
+			want: time.Minute,
+		},
+		{
+			name: "default renewBeforeSeconds is capped by a shorter durationSeconds",
+			spec: ServingCertificateConfigSpec{
+				DurationSeconds: int64Ptr(3600),
+			},
+			want: time.Hour,
+		},
+		{
+			name: "explicit renewBeforeSeconds is capped by durationSeconds",
+			spec: ServingCertificateConfigSpec{
+				DurationSeconds:    int64Ptr(3600),
+				RenewBeforeSeconds: int64Ptr(7200),
+			},
+			want: time.Hour,
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.want, RenewBefore(test.spec))
+			require.Equal(t, issuedAt.Add(test.want), RotateAfter(test.spec, issuedAt))
+		})
+	}
+}
+
+func TestSetDefaults(t *testing.T) {
+	config := &Config{
+		APIConfig: APIConfigSpec{
+			ServingCertificateConfig: ServingCertificateConfigSpec{
+				DurationSeconds: int64Ptr(3600),
+			},
+		},
+		KubeCertAgentConfig: KubeCertAgentSpec{
+			Image: stringPtr("kube-cert-agent-image"),
+		},
+		Labels: map[string]string{"myLabelKey1": "myLabelValue1"},
+	}
+
+	SetDefaults(config)
+
+	want := &Config{
+		APIConfig: APIConfigSpec{
+			ServingCertificateConfig: ServingCertificateConfigSpec{
+				DurationSeconds:    int64Ptr(3600),
+				RenewBeforeSeconds: int64Ptr(60 * 60 * 24 * 30 * 9), // about 9 months
+				MinTLSVersion:      stringPtr("1.2"),
+			},
+		},
+		APIGroupSuffix: stringPtr("pinniped.dev"),
+		KubeCertAgentConfig: KubeCertAgentSpec{
+			NamePrefix:              stringPtr("pinniped-kube-cert-agent-"),
+			Image:                   stringPtr("kube-cert-agent-image"),
+			ImagePullPolicy:         stringPtr("IfNotPresent"),
+			MaxConcurrentPodCreates: intPtr(1),
+		},
+		Labels: map[string]string{"myLabelKey1": "myLabelValue1"},
+	}
+	require.Equal(t, want, config)
+
+	// Applying the defaults again does not change anything.
+	SetDefaults(config)
+	require.Equal(t, want, config)
+
+	// An empty config gets every default.
+	empty := &Config{}
+	SetDefaults(empty)
+	require.Equal(t, int64Ptr(60*60*24*365), empty.APIConfig.ServingCertificateConfig.DurationSeconds) // about a year
+	require.Equal(t, map[string]string{}, empty.Labels)
+}
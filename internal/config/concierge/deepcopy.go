@@ -0,0 +1,56 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package concierge
+
+// DeepCopy returns a copy of the Config which shares no pointers, slices, or maps with the original, so that
+// either one can be mutated (e.g. by SetDefaults) without affecting the other.
+func (c *Config) DeepCopy() *Config {
+	if c == nil {
+		return nil
+	}
+	out := *c
+	out.DiscoveryInfo.URL = copyStringPtr(c.DiscoveryInfo.URL)
+	out.APIConfig.ServingCertificateConfig.DurationSeconds = copyInt64Ptr(c.APIConfig.ServingCertificateConfig.DurationSeconds)
+	out.APIConfig.ServingCertificateConfig.RenewBeforeSeconds = copyInt64Ptr(c.APIConfig.ServingCertificateConfig.RenewBeforeSeconds)
+	out.APIConfig.ServingCertificateConfig.MinTLSVersion = copyStringPtr(c.APIConfig.ServingCertificateConfig.MinTLSVersion)
+	out.APIConfig.ServingCertificateConfig.DNSNames = copyStrings(c.APIConfig.ServingCertificateConfig.DNSNames)
+	out.APIConfig.ServingCertificateConfig.IPAddresses = copyStrings(c.APIConfig.ServingCertificateConfig.IPAddresses)
+	out.APIConfig.TableColumns = copyStrings(c.APIConfig.TableColumns)
+	out.APIGroupSuffix = copyStringPtr(c.APIGroupSuffix)
+	out.KubeCertAgentConfig.NamePrefix = copyStringPtr(c.KubeCertAgentConfig.NamePrefix)
+	out.KubeCertAgentConfig.Image = copyStringPtr(c.KubeCertAgentConfig.Image)
+	out.KubeCertAgentConfig.ImagePullPolicy = copyStringPtr(c.KubeCertAgentConfig.ImagePullPolicy)
+	out.KubeCertAgentConfig.ImagePullSecrets = copyStrings(c.KubeCertAgentConfig.ImagePullSecrets)
+	if c.KubeCertAgentConfig.MaxConcurrentPodCreates != nil {
+		out.KubeCertAgentConfig.MaxConcurrentPodCreates = intPtr(*c.KubeCertAgentConfig.MaxConcurrentPodCreates)
+	}
+	if c.Labels != nil {
+		out.Labels = make(map[string]string, len(c.Labels))
+		for k, v := range c.Labels {
+			out.Labels[k] = v
+		}
+	}
+	return &out
+}
+
+func copyStringPtr(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	return stringPtr(*s)
+}
+
+func copyInt64Ptr(i *int64) *int64 {
+	if i == nil {
+		return nil
+	}
+	return int64Ptr(*i)
+}
+
+func copyStrings(ss []string) []string {
+	if ss == nil {
+		return nil
+	}
+	return append([]string{}, ss...)
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package concierge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeepCopy(t *testing.T) {
+	require.Nil(t, (*Config)(nil).DeepCopy())
+
+	original := &Config{
+		DiscoveryInfo: DiscoveryInfoSpec{URL: stringPtr("https://some.discovery/url")},
+		APIConfig: APIConfigSpec{
+			ServingCertificateConfig: ServingCertificateConfigSpec{
+				DurationSeconds:    int64Ptr(3600),
+				RenewBeforeSeconds: int64Ptr(2400),
+				MinTLSVersion:      stringPtr("1.3"),
+				DNSNames:           []string{"pinniped.example.com"},
+				IPAddresses:        []string{"10.0.0.1"},
+			},
+			TableColumns: []string{"Name"},
+		},
+		APIGroupSuffix: stringPtr("some.suffix.com"),
+		NamesConfig:    NamesConfigSpec{CredentialIssuer: "pinniped-config"},
+		KubeCertAgentConfig: KubeCertAgentSpec{
+			NamePrefix:              stringPtr("kube-cert-agent-name-prefix-"),
+			Image:                   stringPtr("kube-cert-agent-image"),
+			ImagePullPolicy:         stringPtr("Always"),
+			ImagePullSecrets:        []string{"kube-cert-agent-image-pull-secret"},
+			MaxConcurrentPodCreates: intPtr(3),
+		},
+		Labels:   map[string]string{"myLabelKey1": "myLabelValue1"},
+		LogLevel: "debug",
+	}
+
+	copied := original.DeepCopy()
+	require.Equal(t, original, copied)
+
+	// Mutate everything reachable from the copy, and make sure that the original is unchanged.
+	*copied.DiscoveryInfo.URL = "https://other.discovery/url"
+	*copied.APIConfig.ServingCertificateConfig.DurationSeconds = 1
+	*copied.APIConfig.ServingCertificateConfig.RenewBeforeSeconds = 1
+	*copied.APIConfig.ServingCertificateConfig.MinTLSVersion = "1.2"
+	copied.APIConfig.ServingCertificateConfig.DNSNames[0] = "other.example.com"
+	copied.APIConfig.ServingCertificateConfig.IPAddresses[0] = "10.0.0.2"
+	copied.APIConfig.TableColumns[0] = "Created At"
+	*copied.APIGroupSuffix = "other.suffix.com"
+	copied.NamesConfig.CredentialIssuer = "other-config"
+	*copied.KubeCertAgentConfig.NamePrefix = "other-prefix-"
+	*copied.KubeCertAgentConfig.Image = "other-image"
+	*copied.KubeCertAgentConfig.ImagePullPolicy = "Never"
+	copied.KubeCertAgentConfig.ImagePullSecrets[0] = "other-secret"
+	*copied.KubeCertAgentConfig.MaxConcurrentPodCreates = 1
+	copied.Labels["myLabelKey1"] = "other-value"
+	copied.LogLevel = "trace"
+
+	require.Equal(t, &Config{
+		DiscoveryInfo: DiscoveryInfoSpec{URL: stringPtr("https://some.discovery/url")},
+		APIConfig: APIConfigSpec{
+			ServingCertificateConfig: ServingCertificateConfigSpec{
+				DurationSeconds:    int64Ptr(3600),
+				RenewBeforeSeconds: int64Ptr(2400),
+				MinTLSVersion:      stringPtr("1.3"),
+				DNSNames:           []string{"pinniped.example.com"},
+				IPAddresses:        []string{"10.0.0.1"},
+			},
+			TableColumns: []string{"Name"},
+		},
+		APIGroupSuffix: stringPtr("some.suffix.com"),
+		NamesConfig:    NamesConfigSpec{CredentialIssuer: "pinniped-config"},
+		KubeCertAgentConfig: KubeCertAgentSpec{
+			NamePrefix:              stringPtr("kube-cert-agent-name-prefix-"),
+			Image:                   stringPtr("kube-cert-agent-image"),
+			ImagePullPolicy:         stringPtr("Always"),
+			ImagePullSecrets:        []string{"kube-cert-agent-image-pull-secret"},
+			MaxConcurrentPodCreates: intPtr(3),
+		},
+		Labels:   map[string]string{"myLabelKey1": "myLabelValue1"},
+		LogLevel: "debug",
+	}, original)
+
+	// Defaulting a copy of an empty config does not default the original.
+	empty := &Config{}
+	SetDefaults(empty.DeepCopy())
+	require.Equal(t, &Config{}, empty)
+}
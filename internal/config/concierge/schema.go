@@ -0,0 +1,140 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package concierge
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"k8s.io/kube-openapi/pkg/validation/strfmt"
+	"k8s.io/kube-openapi/pkg/validation/validate"
+	"sigs.k8s.io/yaml"
+)
+
+//go:generate go run go.pinniped.dev/internal/config/concierge/schemagen config.schema.json
+
+// JSONSchema returns a JSON Schema describing the Concierge config file, generated from the json and jsonschema
+// struct tags of Config. The jsonschema tag is a comma-separated list of options, where "required" means that the
+// field must be present (and non-empty, for strings) and "enum=a|b|c" means that the field must be one of the
+// listed values.
+func JSONSchema() *spec.Schema {
+	schema := schemaForType(reflect.TypeOf(Config{}))
+	schema.Title = "Pinniped Concierge config"
+	return &schema
+}
+
+// MarshalJSONSchema returns the indented JSON encoding of JSONSchema(), as written to config.schema.json.
+func MarshalJSONSchema() ([]byte, error) {
+	data, err := json.MarshalIndent(JSONSchema(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Validate checks that the Config conforms to JSONSchema(). Since the Config has already been decoded, any unknown
+// fields are already gone, so use ValidateYAML to check a config file.
+func (c *Config) Validate() error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	return validateJSON(data)
+}
+
+// ValidateYAML checks that the YAML (or JSON) contents of a config file conform to JSONSchema(), including rejecting
+// any fields which are not part of the Config.
+func ValidateYAML(data []byte) error {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return fmt.Errorf("decode yaml: %w", err)
+	}
+	return validateJSON(jsonData)
+}
+
+func validateJSON(data []byte) error {
+	var obj interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("decode config: %w", err)
+	}
+	return validate.AgainstSchema(JSONSchema(), obj, strfmt.Default)
+}
+
+func schemaForType(t reflect.Type) spec.Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return nullable(schemaForType(t.Elem()))
+	case reflect.String:
+		return *spec.StringProperty()
+	case reflect.Bool:
+		return *spec.BoolProperty()
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return *spec.Int64Property()
+	case reflect.Slice:
+		return nullable(*spec.ArrayProperty(refSchema(schemaForType(t.Elem()))))
+	case reflect.Map:
+		return nullable(*spec.MapProperty(refSchema(schemaForType(t.Elem()))))
+	case reflect.Struct:
+		return schemaForStruct(t)
+	default:
+		panic(fmt.Sprintf("unsupported config field type %s", t))
+	}
+}
+
+func schemaForStruct(t reflect.Type) spec.Schema {
+	schema := spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			Type:                 []string{"object"},
+			Properties:           map[string]spec.Schema{},
+			AdditionalProperties: &spec.SchemaOrBool{Allows: false},
+		},
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := schemaForType(field.Type)
+		for _, option := range strings.Split(field.Tag.Get("jsonschema"), ",") {
+			switch {
+			case option == "":
+			case option == "required":
+				schema.Required = append(schema.Required, name)
+				if field.Type.Kind() == reflect.String {
+					property.MinLength = int64Ptr(1)
+				}
+			case strings.HasPrefix(option, "enum="):
+				for _, value := range strings.Split(strings.TrimPrefix(option, "enum="), "|") {
+					property.Enum = append(property.Enum, value)
+				}
+				if property.Type.Contains("null") {
+					property.Enum = append(property.Enum, nil)
+				}
+			default:
+				panic(fmt.Sprintf("unsupported jsonschema tag option %q on field %s.%s", option, t.Name(), field.Name))
+			}
+		}
+		schema.Properties[name] = property
+	}
+	return schema
+}
+
+// nullable allows an explicit null in place of the value, since that is how unset pointers, slices and maps are
+// encoded (and written in config files, e.g. "url: null").
+func nullable(schema spec.Schema) spec.Schema {
+	schema.Type = append(schema.Type, "null")
+	return schema
+}
+
+func refSchema(schema spec.Schema) *spec.Schema {
+	return &schema
+}
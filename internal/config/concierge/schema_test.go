@@ -0,0 +1,138 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package concierge
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/kube-openapi/pkg/validation/strfmt"
+	"k8s.io/kube-openapi/pkg/validation/validate"
+	"sigs.k8s.io/yaml"
+
+	"go.pinniped.dev/internal/here"
+)
+
+const validConfigYAML = `
+discovery:
+  url: null
+api:
+  servingCertificate:
+    durationSeconds: 3600
+    renewBeforeSeconds: 2400
+    minTLSVersion: "1.3"
+apiGroupSuffix: some.suffix.com
+names:
+  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+  credentialIssuer: pinniped-config
+  apiService: pinniped-api
+  impersonationConfigMap: impersonationConfigMap-value
+  impersonationLoadBalancerService: impersonationLoadBalancerService-value
+  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
+  impersonationCACertificateSecret: impersonationCACertificateSecret-value
+  impersonationSignerSecret: impersonationSignerSecret-value
+labels:
+  myLabelKey1: myLabelValue1
+kubeCertAgent:
+  namePrefix: kube-cert-agent-prefix-
+  image: kube-cert-agent-image
+  imagePullPolicy: Never
+  imagePullSecrets: [kube-cert-agent-image-pull-secret]
+logLevel: debug
+`
+
+func TestJSONSchemaIsUpToDate(t *testing.T) {
+	want, err := MarshalJSONSchema()
+	require.NoError(t, err)
+
+	got, err := ioutil.ReadFile("config.schema.json")
+	require.NoError(t, err)
+	require.Equal(t, string(want), string(got), "config.schema.json is out of date, run `go generate ./internal/config/concierge/...`")
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		yaml      string
+		wantError string
+	}{
+		{
+			name: "known good config",
+			yaml: validConfigYAML,
+		},
+		{
+			name: "minimal config",
+			yaml: here.Doc(`
+				names:
+				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+				  credentialIssuer: pinniped-config
+				  apiService: pinniped-api
+				  impersonationConfigMap: impersonationConfigMap-value
+				  impersonationLoadBalancerService: impersonationLoadBalancerService-value
+				  impersonationTLSCertificateSecret: impersonationTLSCertificateSecret-value
+				  impersonationCACertificateSecret: impersonationCACertificateSecret-value
+				  impersonationSignerSecret: impersonationSignerSecret-value
+			`),
+		},
+		{
+			name: "missing names",
+			yaml: here.Doc(`
+				names:
+				  servingCertificateSecret: pinniped-concierge-api-tls-serving-certificate
+			`),
+			wantError: "names.credentialIssuer in body should be at least 1 chars long",
+		},
+		{
+			name: "invalid minTLSVersion",
+			yaml: here.Doc(`
+				api:
+				  servingCertificate:
+				    minTLSVersion: "1.1"
+			`),
+			wantError: "api.servingCertificate.minTLSVersion in body should be one of [1.2 1.3 <nil>]",
+		},
+		{
+			name: "invalid imagePullPolicy",
+			yaml: here.Doc(`
+				kubeCertAgent:
+				  imagePullPolicy: Sometimes
+			`),
+			wantError: "kubeCertAgent.imagePullPolicy in body should be one of [Always IfNotPresent Never <nil>]",
+		},
+		{
+			name: "invalid logLevel",
+			yaml: here.Doc(`
+				logLevel: loud
+			`),
+			wantError: "logLevel in body should be one of [ info debug trace all]",
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var config Config
+			require.NoError(t, yaml.Unmarshal([]byte(test.yaml), &config))
+
+			err := config.Validate()
+			if test.wantError == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), test.wantError)
+		})
+	}
+}
+
+func TestJSONSchemaRejectsUnknownFields(t *testing.T) {
+	var obj interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(validConfigYAML), &obj))
+	require.NoError(t, validate.AgainstSchema(JSONSchema(), obj, strfmt.Default))
+
+	require.NoError(t, yaml.Unmarshal([]byte(validConfigYAML+"kubeCertAgnet: {}\n"), &obj))
+	err := validate.AgainstSchema(JSONSchema(), obj, strfmt.Default)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "kubeCertAgnet in body is a forbidden property")
+}
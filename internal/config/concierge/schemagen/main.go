@@ -0,0 +1,32 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package main writes the JSON Schema for the Concierge config file to the path given as its only argument.
+// It is run via `go generate ./internal/config/concierge/...`.
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"go.pinniped.dev/internal/config/concierge"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s <output path>\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	data, err := concierge.MarshalJSONSchema()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not generate schema: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := ioutil.WriteFile(os.Args[1], data, 0644); err != nil { //nolint:gosec // this file is meant to be world-readable
+		fmt.Fprintf(os.Stderr, "could not write schema: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -13,7 +13,12 @@ type Config struct {
 	NamesConfig         NamesConfigSpec   `json:"names"`
 	KubeCertAgentConfig KubeCertAgentSpec `json:"kubeCertAgent"`
 	Labels              map[string]string `json:"labels"`
-	LogLevel            plog.LogLevel     `json:"logLevel"`
+	LogLevel            plog.LogLevel     `json:"logLevel" jsonschema:"enum=|info|debug|trace|all"`
+
+	// DisableKubeConfigInfoMirroring stops the Concierge from mirroring the "TokenCredentialRequestAPI" frontend of
+	// the CredentialIssuer into its deprecated status.kubeConfigInfo field. By default, the field is still mirrored
+	// for backwards compatibility with clients which read it.
+	DisableKubeConfigInfoMirroring bool `json:"disableKubeConfigInfoMirroring,omitempty"`
 }
 
 // DiscoveryInfoSpec contains configuration knobs specific to
@@ -29,18 +34,23 @@ type DiscoveryInfoSpec struct {
 //nolint: golint
 type APIConfigSpec struct {
 	ServingCertificateConfig ServingCertificateConfigSpec `json:"servingCertificate"`
+
+	// TableColumns is the set of columns which are shown when the TokenCredentialRequest and WhoAmIRequest
+	// resources are listed as a Table (e.g. by kubectl get). Supported values are "Name" and "Created At".
+	// By default, all of these columns are shown.
+	TableColumns []string `json:"tableColumns,omitempty"`
 }
 
 // NamesConfigSpec configures the names of some Kubernetes resources for the Concierge.
 type NamesConfigSpec struct {
-	ServingCertificateSecret          string `json:"servingCertificateSecret"`
-	CredentialIssuer                  string `json:"credentialIssuer"`
-	APIService                        string `json:"apiService"`
-	ImpersonationConfigMap            string `json:"impersonationConfigMap"`
-	ImpersonationLoadBalancerService  string `json:"impersonationLoadBalancerService"`
-	ImpersonationTLSCertificateSecret string `json:"impersonationTLSCertificateSecret"`
-	ImpersonationCACertificateSecret  string `json:"impersonationCACertificateSecret"`
-	ImpersonationSignerSecret         string `json:"impersonationSignerSecret"`
+	ServingCertificateSecret          string `json:"servingCertificateSecret" jsonschema:"required"`
+	CredentialIssuer                  string `json:"credentialIssuer" jsonschema:"required"`
+	APIService                        string `json:"apiService" jsonschema:"required"`
+	ImpersonationConfigMap            string `json:"impersonationConfigMap" jsonschema:"required"`
+	ImpersonationLoadBalancerService  string `json:"impersonationLoadBalancerService" jsonschema:"required"`
+	ImpersonationTLSCertificateSecret string `json:"impersonationTLSCertificateSecret" jsonschema:"required"`
+	ImpersonationCACertificateSecret  string `json:"impersonationCACertificateSecret" jsonschema:"required"`
+	ImpersonationSignerSecret         string `json:"impersonationSignerSecret" jsonschema:"required"`
 }
 
 // ServingCertificateConfigSpec contains the configuration knobs for the API's
@@ -59,6 +69,21 @@ type ServingCertificateConfigSpec struct {
 	// DurationSeconds. By default, Pinniped begins rotation after 23328000
 	// seconds (about 9 months).
 	RenewBeforeSeconds *int64 `json:"renewBeforeSeconds,omitempty"`
+
+	// MinTLSVersion is the minimum TLS version that the API will accept for
+	// inbound TLS connections. Supported values are "1.2" and "1.3". By
+	// default, the minimum TLS version is "1.2".
+	MinTLSVersion *string `json:"minTLSVersion,omitempty" jsonschema:"enum=1.2|1.3"`
+
+	// DNSNames are additional DNS subject alternative names for the API
+	// serving certificate, e.g. when the aggregated API is also exposed under
+	// a custom DNS name. The DNS name of the in-cluster Service is always
+	// included. When specified, this list cannot be empty.
+	DNSNames []string `json:"dnsNames,omitempty"`
+
+	// IPAddresses are additional IP subject alternative names for the API
+	// serving certificate. When specified, this list cannot be empty.
+	IPAddresses []string `json:"ipAddresses,omitempty"`
 }
 
 type KubeCertAgentSpec struct {
@@ -72,7 +97,17 @@ type KubeCertAgentSpec struct {
 	// for this value is "debian:latest".
 	Image *string `json:"image"`
 
+	// ImagePullPolicy is the pull policy that will be used for the kube-cert-agent pod's container
+	// image. Supported values are "Always", "IfNotPresent", and "Never". The default for this value
+	// is "IfNotPresent".
+	ImagePullPolicy *string `json:"imagePullPolicy,omitempty" jsonschema:"enum=Always|IfNotPresent|Never"`
+
 	// ImagePullSecrets is a list of names of Kubernetes Secret objects that will be used as
 	// ImagePullSecrets on the kube-cert-agent pods.
-	ImagePullSecrets []string
+	ImagePullSecrets []string `json:"imagePullSecrets"`
+
+	// MaxConcurrentPodCreates is the maximum number of kube-cert-agent pods which will be created at the
+	// same time, e.g. when there are many kube-controller-manager pods. Pod creations are also rate
+	// limited. The default for this value is 1.
+	MaxConcurrentPodCreates *int `json:"maxConcurrentPodCreates,omitempty"`
 }
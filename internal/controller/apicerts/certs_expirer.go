@@ -12,6 +12,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
@@ -32,6 +33,9 @@ type certsExpirerController struct {
 	renewBefore time.Duration
 
 	secretKey string
+
+	// clock is used to determine the current time when deciding whether the cert should be rotated.
+	clock clock.Clock
 }
 
 // NewCertsExpirerController returns a controllerlib.Controller that will delete a
@@ -45,6 +49,7 @@ func NewCertsExpirerController(
 	withInformer pinnipedcontroller.WithInformerOptionFunc,
 	renewBefore time.Duration,
 	secretKey string,
+	clock clock.Clock,
 ) controllerlib.Controller {
 	return controllerlib.New(
 		controllerlib.Config{
@@ -56,6 +61,7 @@ func NewCertsExpirerController(
 				secretInformer:          secretInformer,
 				renewBefore:             renewBefore,
 				secretKey:               secretKey,
+				clock:                   clock,
 			},
 		},
 		withInformer(
@@ -83,10 +89,11 @@ func (c *certsExpirerController) Sync(ctx controllerlib.Context) error {
 		return fmt.Errorf("failed to get cert bounds for secret %q with key %q: %w", secret.Name, c.secretKey, err)
 	}
 
-	certAge := time.Since(notBefore)
+	now := c.clock.Now()
+	certAge := now.Sub(notBefore)
 	renewDelta := certAge - c.renewBefore
 	klog.Infof("certsExpirerController Sync found a renew delta of %s", renewDelta)
-	if renewDelta >= 0 || time.Now().After(notAfter) {
+	if renewDelta >= 0 || now.After(notAfter) {
 		err := c.k8sClient.
 			CoreV1().
 			Secrets(c.namespace).
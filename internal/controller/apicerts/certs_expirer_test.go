@@ -18,6 +18,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/clock"
 	kubeinformers "k8s.io/client-go/informers"
 	kubernetesfake "k8s.io/client-go/kubernetes/fake"
 	kubetesting "k8s.io/client-go/testing"
@@ -98,8 +99,9 @@ func TestExpirerControllerFilters(t *testing.T) {
 				nil, // k8sClient, not needed
 				secretsInformer,
 				withInformer.WithInformer,
-				0,  // renewBefore, not needed
-				"", // not needed
+				0,   // renewBefore, not needed
+				"",  // not needed
+				nil, // clock, not needed
 			)
 
 			unrelated := corev1.Secret{}
@@ -121,6 +123,7 @@ func TestExpirerControllerSync(t *testing.T) {
 	tests := []struct {
 		name                string
 		renewBefore         time.Duration
+		advanceClock        time.Duration
 		fillSecretData      func(*testing.T, map[string][]byte)
 		configKubeAPIClient func(*kubernetesfake.Clientset)
 		wantDelete          bool
@@ -164,6 +167,36 @@ func TestExpirerControllerSync(t *testing.T) {
 			},
 			wantDelete: true,
 		},
+		{
+			name:         "lifetime above threshold after the clock advances",
+			renewBefore:  7 * time.Hour,
+			advanceClock: 3 * time.Hour,
+			fillSecretData: func(t *testing.T, m map[string][]byte) {
+				certPEM, _, err := testutil.CreateCertificate(
+					time.Now().Add(-5*time.Hour),
+					time.Now().Add(5*time.Hour),
+				)
+				require.NoError(t, err)
+
+				m[fakeTestKey] = certPEM
+			},
+			wantDelete: true,
+		},
+		{
+			name:         "cert expired after the clock advances",
+			renewBefore:  24 * time.Hour,
+			advanceClock: 6 * time.Hour,
+			fillSecretData: func(t *testing.T, m map[string][]byte) {
+				certPEM, _, err := testutil.CreateCertificate(
+					time.Now().Add(-1*time.Hour),
+					time.Now().Add(5*time.Hour),
+				)
+				require.NoError(t, err)
+
+				m[fakeTestKey] = certPEM
+			},
+			wantDelete: true,
+		},
 		{
 			name:        "cert expired",
 			renewBefore: 3 * time.Hour,
@@ -245,6 +278,9 @@ func TestExpirerControllerSync(t *testing.T) {
 				0,
 			)
 
+			fakeClock := clock.NewFakeClock(time.Now())
+			fakeClock.Step(test.advanceClock)
+
 			c := NewCertsExpirerController(
 				namespace,
 				certsSecretResourceName,
@@ -253,6 +289,7 @@ func TestExpirerControllerSync(t *testing.T) {
 				controllerlib.WithInformer,
 				test.renewBefore,
 				fakeTestKey,
+				fakeClock,
 			)
 
 			// Must start informers before calling TestRunSynchronously().
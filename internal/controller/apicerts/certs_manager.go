@@ -5,6 +5,7 @@ package apicerts
 
 import (
 	"fmt"
+	"net"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -19,11 +20,19 @@ import (
 	"go.pinniped.dev/internal/controllerlib"
 )
 
+// These are the keys of the data in the serving cert Secret managed by the certs manager controller.
 const (
-	CACertificateSecretKey           = "caCertificate"
+	// CACertificateSecretKey holds the PEM encoded CA certificate which signed the serving certificate.
+	CACertificateSecretKey = "caCertificate"
+
+	// CACertificatePrivateKeySecretKey holds the PEM encoded private key of the CA.
 	CACertificatePrivateKeySecretKey = "caCertificatePrivateKey"
-	tlsPrivateKeySecretKey           = "tlsPrivateKey"
-	TLSCertificateChainSecretKey     = "tlsCertificateChain"
+
+	// TLSPrivateKeySecretKey holds the PEM encoded private key of the serving certificate.
+	TLSPrivateKeySecretKey = "tlsPrivateKey"
+
+	// TLSCertificateChainSecretKey holds the PEM encoded serving certificate chain.
+	TLSCertificateChainSecretKey = "tlsCertificateChain"
 )
 
 type certsManagerController struct {
@@ -39,6 +48,11 @@ type certsManagerController struct {
 
 	generatedCACommonName                 string
 	serviceNameForGeneratedCertCommonName string
+
+	// additionalDNSNames and additionalIPs are added to the subject alternative names of the serving certificate,
+	// along with the DNS name of the Service.
+	additionalDNSNames []string
+	additionalIPs      []net.IP
 }
 
 func NewCertsManagerController(
@@ -52,6 +66,8 @@ func NewCertsManagerController(
 	certDuration time.Duration,
 	generatedCACommonName string,
 	serviceNameForGeneratedCertCommonName string,
+	additionalDNSNames []string,
+	additionalIPs []net.IP,
 ) controllerlib.Controller {
 	return controllerlib.New(
 		controllerlib.Config{
@@ -65,6 +81,8 @@ func NewCertsManagerController(
 				certDuration:                          certDuration,
 				generatedCACommonName:                 generatedCACommonName,
 				serviceNameForGeneratedCertCommonName: serviceNameForGeneratedCertCommonName,
+				additionalDNSNames:                    additionalDNSNames,
+				additionalIPs:                         additionalIPs,
 			},
 		},
 		withInformer(
@@ -119,7 +137,8 @@ func (c *certsManagerController) Sync(ctx controllerlib.Context) error {
 	// Using the CA from above, create a TLS server cert if we have service name.
 	if len(c.serviceNameForGeneratedCertCommonName) != 0 {
 		serviceEndpoint := c.serviceNameForGeneratedCertCommonName + "." + c.namespace + ".svc"
-		tlsCert, err := ca.IssueServerCert([]string{serviceEndpoint}, nil, c.certDuration)
+		dnsNames := append([]string{serviceEndpoint}, c.additionalDNSNames...)
+		tlsCert, err := ca.IssueServerCert(dnsNames, c.additionalIPs, c.certDuration)
 		if err != nil {
 			return fmt.Errorf("could not issue serving certificate: %w", err)
 		}
@@ -130,7 +149,7 @@ func (c *certsManagerController) Sync(ctx controllerlib.Context) error {
 			return fmt.Errorf("could not PEM encode serving certificate: %w", err)
 		}
 
-		secret.StringData[tlsPrivateKeySecretKey] = string(tlsPrivateKeyPEM)
+		secret.StringData[TLSPrivateKeySecretKey] = string(tlsPrivateKeyPEM)
 		secret.StringData[TLSCertificateChainSecretKey] = string(tlsCertChainPEM)
 	}
 
@@ -6,6 +6,7 @@ package apicerts
 import (
 	"context"
 	"errors"
+	"net"
 	"testing"
 	"time"
 
@@ -50,6 +51,8 @@ func TestManagerControllerOptions(t *testing.T) {
 				0,
 				"Pinniped CA",
 				"ignored",
+				nil,
+				nil,
 			)
 			secretsInformerFilter = observableWithInformerOption.GetFilterForInformer(secretsInformer)
 		})
@@ -129,6 +132,8 @@ func TestManagerControllerSync(t *testing.T) {
 		var cancelContext context.Context
 		var cancelContextCancelFunc context.CancelFunc
 		var syncContext *controllerlib.Context
+		var additionalDNSNames []string
+		var additionalIPs []net.IP
 
 		// Defer starting the informers until the last possible moment so that the
 		// nested Before's can keep adding things to the informer caches.
@@ -148,6 +153,8 @@ func TestManagerControllerSync(t *testing.T) {
 				certDuration,
 				"Pinniped CA",
 				serviceName,
+				additionalDNSNames,
+				additionalIPs,
 			)
 
 			// Set this at the last second to support calling subject.Name().
@@ -173,6 +180,8 @@ func TestManagerControllerSync(t *testing.T) {
 			kubeInformerClient = kubernetesfake.NewSimpleClientset()
 			kubeInformers = kubeinformers.NewSharedInformerFactory(kubeInformerClient, 0)
 			kubeAPIClient = kubernetesfake.NewSimpleClientset()
+			additionalDNSNames = nil
+			additionalIPs = nil
 		})
 
 		it.After(func() {
@@ -229,6 +238,27 @@ func TestManagerControllerSync(t *testing.T) {
 				validCert.RequireMatchesPrivateKey(actualPrivateKey)
 			})
 
+			it("adds the additional DNS names and IPs to the serving cert", func() {
+				additionalDNSNames = []string{"pinniped.example.com", "*.pinniped.example.com"}
+				additionalIPs = []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("::1")}
+				startInformersAndController(defaultServiceName)
+				err := controllerlib.TestSync(t, subject, *syncContext)
+				r.NoError(err)
+
+				r.Len(kubeAPIClient.Actions(), 1)
+				actualSecret := kubeAPIClient.Actions()[0].(coretesting.CreateActionImpl).GetObject().(*corev1.Secret)
+				actualCACert := actualSecret.StringData["caCertificate"]
+				actualCertChain := actualSecret.StringData["tlsCertificateChain"]
+
+				validCert := testutil.ValidateServerCertificate(t, actualCACert, actualCertChain)
+				validCert.RequireDNSNames([]string{
+					"pinniped-api." + installedInNamespace + ".svc",
+					"pinniped.example.com",
+					"*.pinniped.example.com",
+				})
+				validCert.RequireIPs([]net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("::1")})
+			})
+
 			it("creates the CA but not service when the service name is empty", func() {
 				startInformersAndController("")
 				err := controllerlib.TestSync(t, subject, *syncContext)
@@ -297,3 +327,12 @@ func TestManagerControllerSync(t *testing.T) {
 		})
 	}, spec.Parallel(), spec.Report(report.Terminal{}))
 }
+
+func TestSecretDataKeys(t *testing.T) {
+	// These keys are persisted in the serving cert Secret on the cluster, so changing them would break
+	// upgrades of existing installations.
+	require.Equal(t, "caCertificate", CACertificateSecretKey)
+	require.Equal(t, "caCertificatePrivateKey", CACertificatePrivateKeySecretKey)
+	require.Equal(t, "tlsPrivateKey", TLSPrivateKeySecretKey)
+	require.Equal(t, "tlsCertificateChain", TLSCertificateChainSecretKey)
+}
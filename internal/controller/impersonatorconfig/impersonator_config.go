@@ -24,6 +24,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/events"
 
 	"go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
 	pinnipedclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
@@ -70,6 +71,10 @@ type impersonatorConfigController struct {
 	impersonationSigningCertProvider dynamiccert.Provider
 	impersonatorFunc                 impersonator.FactoryFunc
 
+	// disableKubeConfigInfoMirroring is passed along to every CredentialIssuer strategy update, so that this
+	// controller and the kubecertagent controllers agree on whether status.kubeConfigInfo is maintained.
+	disableKubeConfigInfoMirroring bool
+
 	hasControlPlaneNodes              *bool
 	serverStopCh                      chan struct{}
 	errorCh                           chan error
@@ -80,8 +85,10 @@ func NewImpersonatorConfigController(
 	namespace string,
 	configMapResourceName string,
 	credentialIssuerResourceName string,
+	disableKubeConfigInfoMirroring bool,
 	k8sClient kubernetes.Interface,
 	pinnipedAPIClient pinnipedclientset.Interface,
+	recorder events.EventRecorder,
 	configMapsInformer corev1informers.ConfigMapInformer,
 	servicesInformer corev1informers.ServiceInformer,
 	secretsInformer corev1informers.SecretInformer,
@@ -104,6 +111,7 @@ func NewImpersonatorConfigController(
 				namespace:                         namespace,
 				configMapResourceName:             configMapResourceName,
 				credentialIssuerResourceName:      credentialIssuerResourceName,
+				disableKubeConfigInfoMirroring:    disableKubeConfigInfoMirroring,
 				generatedLoadBalancerServiceName:  generatedLoadBalancerServiceName,
 				tlsSecretName:                     tlsSecretName,
 				caSecretName:                      caSecretName,
@@ -120,6 +128,7 @@ func NewImpersonatorConfigController(
 				tlsServingCertDynamicCertProvider: dynamiccert.NewServingCert("impersonation-proxy-serving-cert"),
 			},
 		},
+		controllerlib.WithRecorder(recorder),
 		withInformer(
 			configMapsInformer,
 			pinnipedcontroller.NameAndNamespaceExactMatchFilterFactory(configMapResourceName, namespace),
@@ -164,7 +173,7 @@ func (c *impersonatorConfigController) Sync(syncCtx controllerlib.Context) error
 		c.clearSignerCA()
 	}
 
-	updateStrategyErr := c.updateStrategy(syncCtx.Context, strategy)
+	updateStrategyErr := c.updateStrategy(syncCtx, strategy)
 	if updateStrategyErr != nil {
 		plog.Error("error while updating the CredentialIssuer status", err)
 		if err == nil {
@@ -315,8 +324,8 @@ func (c *impersonatorConfigController) shouldHaveTLSSecret(config *impersonator.
 	return c.shouldHaveImpersonator(config)
 }
 
-func (c *impersonatorConfigController) updateStrategy(ctx context.Context, strategy *v1alpha1.CredentialIssuerStrategy) error {
-	return issuerconfig.UpdateStrategy(ctx, c.credentialIssuerResourceName, c.labels, c.pinnipedAPIClient, *strategy)
+func (c *impersonatorConfigController) updateStrategy(syncCtx controllerlib.Context, strategy *v1alpha1.CredentialIssuerStrategy) error {
+	return issuerconfig.UpdateStrategy(syncCtx.Context, c.credentialIssuerResourceName, c.labels, c.pinnipedAPIClient, syncCtx.Recorder, *strategy, c.disableKubeConfigInfoMirroring)
 }
 
 func (c *impersonatorConfigController) loadBalancerExists() (bool, error) {
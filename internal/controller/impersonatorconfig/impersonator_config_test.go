@@ -72,6 +72,8 @@ func TestImpersonatorConfigControllerOptions(t *testing.T) {
 				installedInNamespace,
 				configMapResourceName,
 				"",
+				false,
+				nil,
 				nil,
 				nil,
 				configMapsInformer,
@@ -535,8 +537,10 @@ func TestImpersonatorConfigControllerSync(t *testing.T) {
 				installedInNamespace,
 				configMapResourceName,
 				credentialIssuerResourceName,
+				false,
 				kubeAPIClient,
 				pinnipedAPIClient,
+				nil, // recorder, not needed for this test
 				kubeInformers.Core().V1().ConfigMaps(),
 				kubeInformers.Core().V1().Services(),
 				kubeInformers.Core().V1().Secrets(),
@@ -7,21 +7,26 @@ import (
 	"context"
 	"fmt"
 
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
-
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
 	"k8s.io/client-go/util/retry"
 
 	configv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
 	pinnipedclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
 )
 
+// strategyStatusChangedReason is the event reason used when a strategy's status changes.
+const strategyStatusChangedReason = "StrategyStatusChanged"
+
 func CreateOrUpdateCredentialIssuerStatus(
 	ctx context.Context,
 	credentialIssuerResourceName string,
 	credentialIssuerLabels map[string]string,
 	pinnipedClient pinnipedclientset.Interface,
+	recorder events.EventRecorder,
 	applyUpdatesToCredentialIssuerFunc func(configToUpdate *configv1alpha1.CredentialIssuerStatus),
 ) error {
 	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
@@ -52,16 +57,19 @@ func CreateOrUpdateCredentialIssuerStatus(
 		// check to see if we need to update the status
 		credentialIssuer := existingCredentialIssuer.DeepCopy()
 		applyUpdatesToCredentialIssuerFunc(&credentialIssuer.Status)
+		credentialIssuer.Status.Phase = credentialIssuerPhase(credentialIssuer.Status.Strategies)
 
 		if equality.Semantic.DeepEqual(existingCredentialIssuer, credentialIssuer) {
 			// Nothing interesting would change as a result of this update, so skip it
 			return nil
 		}
 
-		if _, err := credentialIssuersClient.UpdateStatus(ctx, credentialIssuer, metav1.UpdateOptions{}); err != nil {
+		updatedCredentialIssuer, err := credentialIssuersClient.UpdateStatus(ctx, credentialIssuer, metav1.UpdateOptions{})
+		if err != nil {
 			return err
 		}
 
+		recordStrategyTransitions(recorder, existingCredentialIssuer, updatedCredentialIssuer)
 		return nil
 	})
 
@@ -71,6 +79,38 @@ func CreateOrUpdateCredentialIssuerStatus(
 	return nil
 }
 
+// recordStrategyTransitions emits an event for each strategy whose status differs between the old and new
+// CredentialIssuer, including strategies which did not previously exist. A nil recorder records nothing.
+func recordStrategyTransitions(recorder events.EventRecorder, oldCredentialIssuer, newCredentialIssuer *configv1alpha1.CredentialIssuer) {
+	if recorder == nil {
+		return
+	}
+
+	oldStatuses := make(map[configv1alpha1.StrategyType]configv1alpha1.StrategyStatus, len(oldCredentialIssuer.Status.Strategies))
+	for _, strategy := range oldCredentialIssuer.Status.Strategies {
+		oldStatuses[strategy.Type] = strategy.Status
+	}
+
+	for _, strategy := range newCredentialIssuer.Status.Strategies {
+		oldStatus, found := oldStatuses[strategy.Type]
+		if found && oldStatus == strategy.Status {
+			continue
+		}
+
+		eventType := v1.EventTypeNormal
+		if strategy.Status != configv1alpha1.SuccessStrategyStatus {
+			eventType = v1.EventTypeWarning
+		}
+
+		if !found {
+			oldStatus = "None"
+		}
+
+		recorder.Eventf(newCredentialIssuer, nil, eventType, strategyStatusChangedReason, "UpdateStatus",
+			"strategy %s status changed from %s to %s: %s", strategy.Type, oldStatus, strategy.Status, strategy.Reason)
+	}
+}
+
 func minimalValidCredentialIssuer(
 	credentialIssuerName string,
 	credentialIssuerLabels map[string]string,
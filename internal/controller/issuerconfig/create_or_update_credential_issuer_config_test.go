@@ -52,6 +52,7 @@ func TestCreateOrUpdateCredentialIssuerStatus(t *testing.T) {
 						"myLabelKey2": "myLabelValue2",
 					},
 					pinnipedAPIClient,
+					nil,
 					func(configToUpdate *configv1alpha1.CredentialIssuerStatus) {
 						configToUpdate.KubeConfigInfo = &configv1alpha1.CredentialIssuerKubeConfigInfo{
 							CertificateAuthorityData: "some-ca-value",
@@ -87,6 +88,7 @@ func TestCreateOrUpdateCredentialIssuerStatus(t *testing.T) {
 							},
 						},
 						Status: configv1alpha1.CredentialIssuerStatus{
+							Phase: configv1alpha1.PendingCredentialIssuerPhase,
 							KubeConfigInfo: &configv1alpha1.CredentialIssuerKubeConfigInfo{
 								Server:                   "",
 								CertificateAuthorityData: "some-ca-value",
@@ -111,6 +113,7 @@ func TestCreateOrUpdateCredentialIssuerStatus(t *testing.T) {
 						credentialIssuerResourceName,
 						map[string]string{},
 						pinnipedAPIClient,
+						nil,
 						func(configToUpdate *configv1alpha1.CredentialIssuerStatus) {},
 					)
 					r.EqualError(err, "could not create or update credentialissuer: create failed: error on create")
@@ -131,6 +134,7 @@ func TestCreateOrUpdateCredentialIssuerStatus(t *testing.T) {
 						},
 					},
 					Status: configv1alpha1.CredentialIssuerStatus{
+						Phase: configv1alpha1.ReadyCredentialIssuerPhase,
 						Strategies: []configv1alpha1.CredentialIssuerStrategy{
 							{
 								Type:           configv1alpha1.KubeClusterSigningCertificateStrategyType,
@@ -158,6 +162,7 @@ func TestCreateOrUpdateCredentialIssuerStatus(t *testing.T) {
 						"myLabelKey2": "myLabelValue2",
 					},
 					pinnipedAPIClient,
+					nil,
 					func(configToUpdate *configv1alpha1.CredentialIssuerStatus) {
 						configToUpdate.KubeConfigInfo.CertificateAuthorityData = "new-ca-value"
 					},
@@ -180,6 +185,7 @@ func TestCreateOrUpdateCredentialIssuerStatus(t *testing.T) {
 					credentialIssuerResourceName,
 					map[string]string{},
 					pinnipedAPIClient,
+					nil,
 					func(configToUpdate *configv1alpha1.CredentialIssuerStatus) {
 						configToUpdate.KubeConfigInfo.CertificateAuthorityData = "initial-ca-value"
 
@@ -208,6 +214,7 @@ func TestCreateOrUpdateCredentialIssuerStatus(t *testing.T) {
 						credentialIssuerResourceName,
 						map[string]string{},
 						pinnipedAPIClient,
+						nil,
 						func(configToUpdate *configv1alpha1.CredentialIssuerStatus) {},
 					)
 					r.EqualError(err, "could not create or update credentialissuer: get failed: error on get")
@@ -227,6 +234,7 @@ func TestCreateOrUpdateCredentialIssuerStatus(t *testing.T) {
 						credentialIssuerResourceName,
 						map[string]string{},
 						pinnipedAPIClient,
+						nil,
 						func(configToUpdate *configv1alpha1.CredentialIssuerStatus) {
 							configToUpdate.KubeConfigInfo.CertificateAuthorityData = "new-ca-value"
 						},
@@ -268,6 +276,7 @@ func TestCreateOrUpdateCredentialIssuerStatus(t *testing.T) {
 							"myLabelKey2": "myLabelValue2",
 						},
 						pinnipedAPIClient,
+						nil,
 						func(configToUpdate *configv1alpha1.CredentialIssuerStatus) {
 							configToUpdate.KubeConfigInfo.CertificateAuthorityData = "new-ca-value"
 						},
@@ -7,28 +7,41 @@ import (
 	"context"
 	"sort"
 
+	"k8s.io/client-go/tools/events"
+
 	"go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
 	"go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
+	"go.pinniped.dev/internal/plog"
 )
 
 // UpdateStrategy creates or updates the desired strategy in the CredentialIssuer status.strategies field.
-// The CredentialIssuer will be created if it does not already exist.
+// The CredentialIssuer will be created if it does not already exist. When the update changes the status of
+// a strategy, an event is emitted via the recorder (which may be nil). Unless disableKubeConfigInfoMirroring
+// is true, the "TokenCredentialRequestAPI" frontend data is also mirrored into the deprecated
+// status.kubeConfigInfo field, for backwards compatibility with clients which still read it.
 func UpdateStrategy(ctx context.Context,
 	name string,
 	credentialIssuerLabels map[string]string,
 	pinnipedAPIClient versioned.Interface,
+	recorder events.EventRecorder,
 	strategy v1alpha1.CredentialIssuerStrategy,
+	disableKubeConfigInfoMirroring bool,
 ) error {
 	return CreateOrUpdateCredentialIssuerStatus(
 		ctx,
 		name,
 		credentialIssuerLabels,
 		pinnipedAPIClient,
-		func(configToUpdate *v1alpha1.CredentialIssuerStatus) { mergeStrategy(configToUpdate, strategy) },
+		recorder,
+		func(configToUpdate *v1alpha1.CredentialIssuerStatus) {
+			mergeStrategy(configToUpdate, strategy, disableKubeConfigInfoMirroring)
+		},
 	)
 }
 
-func mergeStrategy(configToUpdate *v1alpha1.CredentialIssuerStatus, strategy v1alpha1.CredentialIssuerStrategy) {
+func mergeStrategy(configToUpdate *v1alpha1.CredentialIssuerStatus, strategy v1alpha1.CredentialIssuerStrategy, disableKubeConfigInfoMirroring bool) {
+	configToUpdate.Strategies = dedupeStrategies(configToUpdate.Strategies)
+
 	var existing *v1alpha1.CredentialIssuerStrategy
 	for i := range configToUpdate.Strategies {
 		if configToUpdate.Strategies[i].Type == strategy.Type {
@@ -44,14 +57,44 @@ func mergeStrategy(configToUpdate *v1alpha1.CredentialIssuerStatus, strategy v1a
 	sort.Stable(sortableStrategies(configToUpdate.Strategies))
 
 	// Special case: the "TokenCredentialRequestAPI" data is mirrored into the deprecated status.kubeConfigInfo field.
-	if strategy.Frontend != nil && strategy.Frontend.Type == v1alpha1.TokenCredentialRequestAPIFrontendType {
+	if !disableKubeConfigInfoMirroring && strategy.Frontend != nil && strategy.Frontend.Type == v1alpha1.TokenCredentialRequestAPIFrontendType {
+		info := strategy.Frontend.TokenCredentialRequestAPIInfo
+		// Old clients cannot do anything useful without a CA, so clear the deprecated field rather than leaving
+		// behind a stale value from an earlier frontend.
+		if info == nil || info.CertificateAuthorityData == "" {
+			plog.Warning("clearing deprecated kubeConfigInfo because the TokenCredentialRequestAPI frontend has no certificateAuthorityData")
+			configToUpdate.KubeConfigInfo = nil
+			return
+		}
 		configToUpdate.KubeConfigInfo = &v1alpha1.CredentialIssuerKubeConfigInfo{
-			Server:                   strategy.Frontend.TokenCredentialRequestAPIInfo.Server,
-			CertificateAuthorityData: strategy.Frontend.TokenCredentialRequestAPIInfo.CertificateAuthorityData,
+			Server:                   info.Server,
+			CertificateAuthorityData: info.CertificateAuthorityData,
 		}
 	}
 }
 
+// dedupeStrategies collapses any strategies which share the same type (which should never happen, but could if the
+// status was corrupted) into a single entry, keeping the one with the most recent lastUpdateTime.
+func dedupeStrategies(strategies []v1alpha1.CredentialIssuerStrategy) []v1alpha1.CredentialIssuerStrategy {
+	indexByType := make(map[v1alpha1.StrategyType]int, len(strategies))
+	deduped := make([]v1alpha1.CredentialIssuerStrategy, 0, len(strategies))
+	for _, strategy := range strategies {
+		i, seen := indexByType[strategy.Type]
+		if !seen {
+			indexByType[strategy.Type] = len(deduped)
+			deduped = append(deduped, strategy)
+			continue
+		}
+		if deduped[i].LastUpdateTime.Before(&strategy.LastUpdateTime) {
+			deduped[i] = strategy
+		}
+	}
+	if len(deduped) == len(strategies) {
+		return strategies
+	}
+	return deduped
+}
+
 // weights are a set of priorities for each strategy type.
 //nolint: gochecknoglobals
 var weights = map[v1alpha1.StrategyType]int{
@@ -60,6 +103,22 @@ var weights = map[v1alpha1.StrategyType]int{
 	// unknown strategy types will have weight 0 by default
 }
 
+// credentialIssuerPhase summarizes the strategies into a single phase. The CredentialIssuer is Ready when any
+// strategy of a known (i.e. weighted) type has succeeded. Otherwise it is in Error when any strategy has failed for a
+// reason other than still being set up, and Pending when there are no strategies or they are all still pending.
+func credentialIssuerPhase(strategies []v1alpha1.CredentialIssuerStrategy) v1alpha1.CredentialIssuerPhase {
+	phase := v1alpha1.PendingCredentialIssuerPhase
+	for _, strategy := range strategies {
+		switch {
+		case strategy.Status == v1alpha1.SuccessStrategyStatus && weights[strategy.Type] > 0:
+			return v1alpha1.ReadyCredentialIssuerPhase
+		case strategy.Status == v1alpha1.ErrorStrategyStatus && strategy.Reason != v1alpha1.PendingStrategyReason:
+			phase = v1alpha1.ErrorCredentialIssuerPhase
+		}
+	}
+	return phase
+}
+
 type sortableStrategies []v1alpha1.CredentialIssuerStrategy
 
 func (s sortableStrategies) Len() int { return len(s) }
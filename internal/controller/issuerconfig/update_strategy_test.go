@@ -4,6 +4,8 @@
 package issuerconfig
 
 import (
+	"context"
+	"fmt"
 	"math/rand"
 	"sort"
 	"testing"
@@ -12,9 +14,15 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	coretesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/events"
 
 	"go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
+	pinnipedfake "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned/fake"
+	"go.pinniped.dev/internal/testutil"
 )
 
 func TestMergeStrategy(t *testing.T) {
@@ -22,10 +30,11 @@ func TestMergeStrategy(t *testing.T) {
 	t2 := metav1.NewTime(metav1.Now().Add(-1 * time.Hour))
 
 	tests := []struct {
-		name           string
-		configToUpdate v1alpha1.CredentialIssuerStatus
-		strategy       v1alpha1.CredentialIssuerStrategy
-		expected       v1alpha1.CredentialIssuerStatus
+		name             string
+		disableMirroring bool
+		configToUpdate   v1alpha1.CredentialIssuerStatus
+		strategy         v1alpha1.CredentialIssuerStrategy
+		expected         v1alpha1.CredentialIssuerStatus
 	}{
 		{
 			name: "new entry",
@@ -93,6 +102,121 @@ func TestMergeStrategy(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "new entry with empty CA data clears stale deprecated kubeConfigInfo",
+			configToUpdate: v1alpha1.CredentialIssuerStatus{
+				Strategies: nil,
+				KubeConfigInfo: &v1alpha1.CredentialIssuerKubeConfigInfo{
+					Server:                   "https://old-test-server",
+					CertificateAuthorityData: "old-test-ca-bundle",
+				},
+			},
+			strategy: v1alpha1.CredentialIssuerStrategy{
+				Type:           "Type1",
+				Status:         v1alpha1.SuccessStrategyStatus,
+				Reason:         "some reason",
+				Message:        "some message",
+				LastUpdateTime: t1,
+				Frontend: &v1alpha1.CredentialIssuerFrontend{
+					Type: "TokenCredentialRequestAPI",
+					TokenCredentialRequestAPIInfo: &v1alpha1.TokenCredentialRequestAPIInfo{
+						Server:                   "https://test-server",
+						CertificateAuthorityData: "",
+					},
+				},
+			},
+			expected: v1alpha1.CredentialIssuerStatus{
+				Strategies: []v1alpha1.CredentialIssuerStrategy{
+					{
+						Type:           "Type1",
+						Status:         v1alpha1.SuccessStrategyStatus,
+						Reason:         "some reason",
+						Message:        "some message",
+						LastUpdateTime: t1,
+						Frontend: &v1alpha1.CredentialIssuerFrontend{
+							Type: "TokenCredentialRequestAPI",
+							TokenCredentialRequestAPIInfo: &v1alpha1.TokenCredentialRequestAPIInfo{
+								Server:                   "https://test-server",
+								CertificateAuthorityData: "",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "new entry without TokenCredentialRequestAPI info clears stale deprecated kubeConfigInfo",
+			configToUpdate: v1alpha1.CredentialIssuerStatus{
+				Strategies: nil,
+				KubeConfigInfo: &v1alpha1.CredentialIssuerKubeConfigInfo{
+					Server:                   "https://old-test-server",
+					CertificateAuthorityData: "old-test-ca-bundle",
+				},
+			},
+			strategy: v1alpha1.CredentialIssuerStrategy{
+				Type:           "Type1",
+				Status:         v1alpha1.SuccessStrategyStatus,
+				Reason:         "some reason",
+				Message:        "some message",
+				LastUpdateTime: t1,
+				Frontend: &v1alpha1.CredentialIssuerFrontend{
+					Type: "TokenCredentialRequestAPI",
+				},
+			},
+			expected: v1alpha1.CredentialIssuerStatus{
+				Strategies: []v1alpha1.CredentialIssuerStrategy{
+					{
+						Type:           "Type1",
+						Status:         v1alpha1.SuccessStrategyStatus,
+						Reason:         "some reason",
+						Message:        "some message",
+						LastUpdateTime: t1,
+						Frontend: &v1alpha1.CredentialIssuerFrontend{
+							Type: "TokenCredentialRequestAPI",
+						},
+					},
+				},
+			},
+		},
+		{
+			name:             "new entry with deprecated kubeConfigInfo mirroring disabled",
+			disableMirroring: true,
+			configToUpdate: v1alpha1.CredentialIssuerStatus{
+				Strategies: nil,
+			},
+			strategy: v1alpha1.CredentialIssuerStrategy{
+				Type:           "Type1",
+				Status:         v1alpha1.SuccessStrategyStatus,
+				Reason:         "some reason",
+				Message:        "some message",
+				LastUpdateTime: t1,
+				Frontend: &v1alpha1.CredentialIssuerFrontend{
+					Type: "TokenCredentialRequestAPI",
+					TokenCredentialRequestAPIInfo: &v1alpha1.TokenCredentialRequestAPIInfo{
+						Server:                   "https://test-server",
+						CertificateAuthorityData: "test-ca-bundle",
+					},
+				},
+			},
+			expected: v1alpha1.CredentialIssuerStatus{
+				Strategies: []v1alpha1.CredentialIssuerStrategy{
+					{
+						Type:           "Type1",
+						Status:         v1alpha1.SuccessStrategyStatus,
+						Reason:         "some reason",
+						Message:        "some message",
+						LastUpdateTime: t1,
+						Frontend: &v1alpha1.CredentialIssuerFrontend{
+							Type: "TokenCredentialRequestAPI",
+							TokenCredentialRequestAPIInfo: &v1alpha1.TokenCredentialRequestAPIInfo{
+								Server:                   "https://test-server",
+								CertificateAuthorityData: "test-ca-bundle",
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "existing entry to update",
 			configToUpdate: v1alpha1.CredentialIssuerStatus{
@@ -179,17 +303,277 @@ func TestMergeStrategy(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "duplicate entries of the same type are collapsed to the newest",
+			configToUpdate: v1alpha1.CredentialIssuerStatus{
+				Strategies: []v1alpha1.CredentialIssuerStrategy{
+					{
+						Type:           "Type0",
+						Status:         v1alpha1.ErrorStrategyStatus,
+						Reason:         "some older reason",
+						Message:        "some older message",
+						LastUpdateTime: t2,
+					},
+					{
+						Type:           "Type0",
+						Status:         v1alpha1.SuccessStrategyStatus,
+						Reason:         "some newer reason",
+						Message:        "some newer message",
+						LastUpdateTime: t1,
+					},
+					{
+						Type:           "Type0",
+						Status:         v1alpha1.ErrorStrategyStatus,
+						Reason:         "some other older reason",
+						Message:        "some other older message",
+						LastUpdateTime: t2,
+					},
+				},
+			},
+			strategy: v1alpha1.CredentialIssuerStrategy{
+				Type:           "Type1",
+				Status:         v1alpha1.SuccessStrategyStatus,
+				Reason:         "some reason",
+				Message:        "some message",
+				LastUpdateTime: t1,
+			},
+			expected: v1alpha1.CredentialIssuerStatus{
+				Strategies: []v1alpha1.CredentialIssuerStrategy{
+					{
+						Type:           "Type0",
+						Status:         v1alpha1.SuccessStrategyStatus,
+						Reason:         "some newer reason",
+						Message:        "some newer message",
+						LastUpdateTime: t1,
+					},
+					{
+						Type:           "Type1",
+						Status:         v1alpha1.SuccessStrategyStatus,
+						Reason:         "some reason",
+						Message:        "some message",
+						LastUpdateTime: t1,
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			updated := tt.configToUpdate.DeepCopy()
-			mergeStrategy(updated, tt.strategy)
+			mergeStrategy(updated, tt.strategy, tt.disableMirroring)
+			require.Equal(t, &tt.expected, updated)
+
+			// Merging the same strategy again should not change anything, since it may be re-applied after a conflict.
+			mergeStrategy(updated, tt.strategy, tt.disableMirroring)
 			require.Equal(t, &tt.expected, updated)
 		})
 	}
 }
 
+func TestUpdateStrategy(t *testing.T) {
+	ctx := context.Background()
+	t1 := metav1.Now()
+	t2 := metav1.NewTime(metav1.Now().Add(-1 * time.Hour))
+	credentialIssuerGVR := v1alpha1.SchemeGroupVersion.WithResource("credentialissuers")
+
+	existing := &v1alpha1.CredentialIssuer{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"},
+		Status: v1alpha1.CredentialIssuerStatus{
+			Strategies: []v1alpha1.CredentialIssuerStrategy{
+				{
+					Type:           "Type1",
+					Status:         v1alpha1.ErrorStrategyStatus,
+					Reason:         "some starting reason",
+					Message:        "some starting message",
+					LastUpdateTime: t2,
+				},
+			},
+		},
+	}
+	strategy := v1alpha1.CredentialIssuerStrategy{
+		Type:           "Type1",
+		Status:         v1alpha1.SuccessStrategyStatus,
+		Reason:         "some reason",
+		Message:        "some message",
+		LastUpdateTime: t1,
+	}
+	conflictingStrategy := v1alpha1.CredentialIssuerStrategy{
+		Type:           "Type2",
+		Status:         v1alpha1.SuccessStrategyStatus,
+		Reason:         "some conflicting reason",
+		Message:        "some conflicting message",
+		LastUpdateTime: t2,
+	}
+
+	client := pinnipedfake.NewSimpleClientset(existing)
+	updates := 0
+	client.PrependReactor("update", "credentialissuers", func(_ coretesting.Action) (bool, runtime.Object, error) {
+		updates++
+		if updates > 1 {
+			// Fall through to the default (successful) response.
+			return false, nil, nil
+		}
+		// Before returning a conflict, simulate a concurrent writer adding another strategy.
+		concurrentlyUpdated := existing.DeepCopy()
+		concurrentlyUpdated.Status.Strategies = append(concurrentlyUpdated.Status.Strategies, conflictingStrategy)
+		require.NoError(t, client.Tracker().Update(credentialIssuerGVR, concurrentlyUpdated, ""))
+		return true, nil, apierrors.NewConflict(credentialIssuerGVR.GroupResource(), existing.Name, fmt.Errorf("there was a conflict"))
+	})
+
+	require.NoError(t, UpdateStrategy(ctx, existing.Name, nil, client, nil, strategy, false))
+	require.Equal(t, 2, updates)
+
+	actual, err := client.ConfigV1alpha1().CredentialIssuers().Get(ctx, existing.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []v1alpha1.CredentialIssuerStrategy{strategy, conflictingStrategy}, actual.Status.Strategies)
+}
+
+func TestUpdateStrategyRecordsTransitions(t *testing.T) {
+	ctx := context.Background()
+	now := metav1.Now()
+
+	tests := []struct {
+		name           string
+		existingStatus *v1alpha1.StrategyStatus
+		strategy       v1alpha1.CredentialIssuerStrategy
+		wantEvents     []string
+	}{
+		{
+			name:           "error to success",
+			existingStatus: statusPtr(v1alpha1.ErrorStrategyStatus),
+			strategy: v1alpha1.CredentialIssuerStrategy{
+				Type:           "Type1",
+				Status:         v1alpha1.SuccessStrategyStatus,
+				Reason:         "SomeReason",
+				LastUpdateTime: now,
+			},
+			wantEvents: []string{"Normal StrategyStatusChanged strategy Type1 status changed from Error to Success: SomeReason"},
+		},
+		{
+			name:           "success to error",
+			existingStatus: statusPtr(v1alpha1.SuccessStrategyStatus),
+			strategy: v1alpha1.CredentialIssuerStrategy{
+				Type:           "Type1",
+				Status:         v1alpha1.ErrorStrategyStatus,
+				Reason:         "SomeReason",
+				LastUpdateTime: now,
+			},
+			wantEvents: []string{"Warning StrategyStatusChanged strategy Type1 status changed from Success to Error: SomeReason"},
+		},
+		{
+			name: "new strategy",
+			strategy: v1alpha1.CredentialIssuerStrategy{
+				Type:           "Type1",
+				Status:         v1alpha1.SuccessStrategyStatus,
+				Reason:         "SomeReason",
+				LastUpdateTime: now,
+			},
+			wantEvents: []string{"Normal StrategyStatusChanged strategy Type1 status changed from None to Success: SomeReason"},
+		},
+		{
+			name:           "same status with a different message",
+			existingStatus: statusPtr(v1alpha1.ErrorStrategyStatus),
+			strategy: v1alpha1.CredentialIssuerStrategy{
+				Type:           "Type1",
+				Status:         v1alpha1.ErrorStrategyStatus,
+				Reason:         "SomeReason",
+				Message:        "some new message",
+				LastUpdateTime: now,
+			},
+		},
+		{
+			name:           "no-op update",
+			existingStatus: statusPtr(v1alpha1.ErrorStrategyStatus),
+			strategy: v1alpha1.CredentialIssuerStrategy{
+				Type:           "Type1",
+				Status:         v1alpha1.ErrorStrategyStatus,
+				Reason:         "SomeReason",
+				Message:        "some message",
+				LastUpdateTime: now,
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			existing := &v1alpha1.CredentialIssuer{ObjectMeta: metav1.ObjectMeta{Name: "test-credential-issuer"}}
+			if tt.existingStatus != nil {
+				existing.Status.Strategies = []v1alpha1.CredentialIssuerStrategy{{
+					Type:           "Type1",
+					Status:         *tt.existingStatus,
+					Reason:         "SomeReason",
+					Message:        "some message",
+					LastUpdateTime: now,
+				}}
+			}
+			client := pinnipedfake.NewSimpleClientset(existing)
+			recorder := events.NewFakeRecorder(10)
+
+			require.NoError(t, UpdateStrategy(ctx, existing.Name, nil, client, recorder, tt.strategy, false))
+
+			close(recorder.Events)
+			var gotEvents []string
+			for event := range recorder.Events {
+				gotEvents = append(gotEvents, event)
+			}
+			require.Equal(t, tt.wantEvents, gotEvents)
+		})
+	}
+}
+
+func TestUpdateStrategyStatusUpdateOrdering(t *testing.T) {
+	ctx := context.Background()
+	now := metav1.Now()
+	agentStrategy := v1alpha1.CredentialIssuerStrategy{
+		Type:           v1alpha1.KubeClusterSigningCertificateStrategyType,
+		Status:         v1alpha1.ErrorStrategyStatus,
+		Reason:         v1alpha1.CouldNotFetchKeyStrategyReason,
+		Message:        "some error",
+		LastUpdateTime: now,
+	}
+	impersonationStrategy := v1alpha1.CredentialIssuerStrategy{
+		Type:           v1alpha1.ImpersonationProxyStrategyType,
+		Status:         v1alpha1.SuccessStrategyStatus,
+		Reason:         v1alpha1.ListeningStrategyReason,
+		Message:        "some message",
+		LastUpdateTime: now,
+	}
+	fixedAgentStrategy := v1alpha1.CredentialIssuerStrategy{
+		Type:           v1alpha1.KubeClusterSigningCertificateStrategyType,
+		Status:         v1alpha1.SuccessStrategyStatus,
+		Reason:         v1alpha1.FetchedKeyStrategyReason,
+		Message:        "some message",
+		LastUpdateTime: now,
+	}
+
+	client, updates := testutil.RecordingCredentialIssuerClient()
+	require.NoError(t, UpdateStrategy(ctx, "test-credential-issuer", nil, client, nil, agentStrategy, false))
+	require.NoError(t, UpdateStrategy(ctx, "test-credential-issuer", nil, client, nil, impersonationStrategy, false))
+	require.NoError(t, UpdateStrategy(ctx, "test-credential-issuer", nil, client, nil, fixedAgentStrategy, false))
+	// Repeating the most recent update is a no-op, so it should not be recorded.
+	require.NoError(t, UpdateStrategy(ctx, "test-credential-issuer", nil, client, nil, fixedAgentStrategy, false))
+
+	require.Equal(t, []v1alpha1.CredentialIssuerStatus{
+		{
+			Phase:      v1alpha1.ErrorCredentialIssuerPhase,
+			Strategies: []v1alpha1.CredentialIssuerStrategy{agentStrategy},
+		},
+		{
+			Phase:      v1alpha1.ReadyCredentialIssuerPhase,
+			Strategies: []v1alpha1.CredentialIssuerStrategy{agentStrategy, impersonationStrategy},
+		},
+		{
+			Phase:      v1alpha1.ReadyCredentialIssuerPhase,
+			Strategies: []v1alpha1.CredentialIssuerStrategy{fixedAgentStrategy, impersonationStrategy},
+		},
+	}, updates.Statuses())
+}
+
+func statusPtr(status v1alpha1.StrategyStatus) *v1alpha1.StrategyStatus {
+	return &status
+}
+
 func TestStrategySorting(t *testing.T) {
 	expected := []v1alpha1.CredentialIssuerStrategy{
 		{Type: v1alpha1.KubeClusterSigningCertificateStrategyType},
@@ -216,3 +600,72 @@ func TestStrategySorting(t *testing.T) {
 		return assert.Equal(t, expected, output)
 	}, nil))
 }
+
+func TestCredentialIssuerPhase(t *testing.T) {
+	strategy := func(strategyType v1alpha1.StrategyType, status v1alpha1.StrategyStatus, reason v1alpha1.StrategyReason) v1alpha1.CredentialIssuerStrategy {
+		return v1alpha1.CredentialIssuerStrategy{Type: strategyType, Status: status, Reason: reason}
+	}
+	kubeCertAgentSuccess := strategy(v1alpha1.KubeClusterSigningCertificateStrategyType, v1alpha1.SuccessStrategyStatus, v1alpha1.FetchedKeyStrategyReason)
+	kubeCertAgentError := strategy(v1alpha1.KubeClusterSigningCertificateStrategyType, v1alpha1.ErrorStrategyStatus, v1alpha1.CouldNotFetchKeyStrategyReason)
+	impersonatorSuccess := strategy(v1alpha1.ImpersonationProxyStrategyType, v1alpha1.SuccessStrategyStatus, v1alpha1.ListeningStrategyReason)
+	impersonatorPending := strategy(v1alpha1.ImpersonationProxyStrategyType, v1alpha1.ErrorStrategyStatus, v1alpha1.PendingStrategyReason)
+	impersonatorDisabled := strategy(v1alpha1.ImpersonationProxyStrategyType, v1alpha1.ErrorStrategyStatus, v1alpha1.DisabledStrategyReason)
+	unknownSuccess := strategy("SomeUnknownType", v1alpha1.SuccessStrategyStatus, "SomeReason")
+
+	tests := []struct {
+		name       string
+		strategies []v1alpha1.CredentialIssuerStrategy
+		want       v1alpha1.CredentialIssuerPhase
+	}{
+		{
+			name: "no strategies",
+			want: v1alpha1.PendingCredentialIssuerPhase,
+		},
+		{
+			name:       "only pending strategies",
+			strategies: []v1alpha1.CredentialIssuerStrategy{impersonatorPending},
+			want:       v1alpha1.PendingCredentialIssuerPhase,
+		},
+		{
+			name:       "successful preferred strategy",
+			strategies: []v1alpha1.CredentialIssuerStrategy{kubeCertAgentSuccess},
+			want:       v1alpha1.ReadyCredentialIssuerPhase,
+		},
+		{
+			name:       "successful impersonator despite a failed kube cert agent",
+			strategies: []v1alpha1.CredentialIssuerStrategy{kubeCertAgentError, impersonatorSuccess},
+			want:       v1alpha1.ReadyCredentialIssuerPhase,
+		},
+		{
+			name:       "successful kube cert agent despite a disabled impersonator",
+			strategies: []v1alpha1.CredentialIssuerStrategy{kubeCertAgentSuccess, impersonatorDisabled},
+			want:       v1alpha1.ReadyCredentialIssuerPhase,
+		},
+		{
+			name:       "failed kube cert agent and pending impersonator",
+			strategies: []v1alpha1.CredentialIssuerStrategy{kubeCertAgentError, impersonatorPending},
+			want:       v1alpha1.ErrorCredentialIssuerPhase,
+		},
+		{
+			name:       "failed kube cert agent and disabled impersonator",
+			strategies: []v1alpha1.CredentialIssuerStrategy{kubeCertAgentError, impersonatorDisabled},
+			want:       v1alpha1.ErrorCredentialIssuerPhase,
+		},
+		{
+			name:       "only a successful strategy of an unknown type",
+			strategies: []v1alpha1.CredentialIssuerStrategy{unknownSuccess},
+			want:       v1alpha1.PendingCredentialIssuerPhase,
+		},
+		{
+			name:       "successful strategy of an unknown type and a failed kube cert agent",
+			strategies: []v1alpha1.CredentialIssuerStrategy{unknownSuccess, kubeCertAgentError},
+			want:       v1alpha1.ErrorCredentialIssuerPhase,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, credentialIssuerPhase(tt.strategies))
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/clock"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/events"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 
@@ -56,6 +57,7 @@ func NewAnnotaterController(
 	clock clock.Clock,
 	k8sClient kubernetes.Interface,
 	pinnipedAPIClient pinnipedclientset.Interface,
+	recorder events.EventRecorder,
 	kubeSystemPodInformer corev1informers.PodInformer,
 	agentPodInformer corev1informers.PodInformer,
 	withInformer pinnipedcontroller.WithInformerOptionFunc,
@@ -74,6 +76,7 @@ func NewAnnotaterController(
 				agentPodInformer:               agentPodInformer,
 			},
 		},
+		controllerlib.WithRecorder(recorder),
 		withInformer(
 			kubeSystemPodInformer,
 			pinnipedcontroller.SimpleFilterWithSingletonQueue(isControllerManagerPod),
@@ -130,7 +133,9 @@ func (c *annotaterController) Sync(ctx controllerlib.Context) error {
 				c.credentialIssuerLocationConfig.Name,
 				c.credentialIssuerLabels,
 				c.pinnipedAPIClient,
+				ctx.Recorder,
 				strategyError(c.clock, err),
+				c.credentialIssuerLocationConfig.DisableKubeConfigInfoMirroring,
 			)
 			if strategyResultUpdateErr != nil {
 				// If the CI update fails, then we probably want to try again. This controller will get
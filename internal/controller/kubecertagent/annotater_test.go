@@ -46,6 +46,7 @@ func TestAnnotaterControllerFilter(t *testing.T) {
 				nil, // clock, shouldn't matter
 				nil, // k8sClient, shouldn't matter
 				nil, // pinnipedClient, shouldn't matter
+				nil, // recorder, shouldn't matter
 				kubeSystemPodInformer,
 				agentPodInformer,
 				observableWithInformerOption.WithInformer,
@@ -109,6 +110,7 @@ func TestAnnotaterControllerSync(t *testing.T) {
 				clock.NewFakeClock(frozenNow),
 				kubeAPIClient,
 				pinnipedAPIClient,
+				nil, // recorder, not needed for this test
 				kubeSystemInformers.Core().V1().Pods(),
 				agentInformers.Core().V1().Pods(),
 				controllerlib.WithInformer,
@@ -260,6 +262,7 @@ func TestAnnotaterControllerSync(t *testing.T) {
 									LastUpdateTime: metav1.NewTime(frozenNow),
 								},
 							}
+							expectedCredentialIssuer.Status.Phase = configv1alpha1.ErrorCredentialIssuerPhase
 							expectedGetAction := coretesting.NewRootGetAction(
 								credentialIssuerGVR,
 								credentialIssuerResourceName,
@@ -323,6 +326,7 @@ func TestAnnotaterControllerSync(t *testing.T) {
 									Labels: map[string]string{"foo": "bar"},
 								},
 								Status: configv1alpha1.CredentialIssuerStatus{
+									Phase: configv1alpha1.ErrorCredentialIssuerPhase,
 									Strategies: []configv1alpha1.CredentialIssuerStrategy{
 										{
 											Type:           configv1alpha1.KubeClusterSigningCertificateStrategyType,
@@ -4,14 +4,19 @@
 package kubecertagent
 
 import (
+	"context"
 	"fmt"
+	"sync"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/clock"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/klog/v2"
 
 	pinnipedclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
@@ -31,8 +36,15 @@ type createrController struct {
 	pinnipedAPIClient              pinnipedclientset.Interface
 	kubeSystemPodInformer          corev1informers.PodInformer
 	agentPodInformer               corev1informers.PodInformer
+	podCreateRateLimiter           flowcontrol.RateLimiter
 }
 
+const (
+	// agentPodCreateQPS and agentPodCreateBurst rate limit the creation of agent pods.
+	agentPodCreateQPS   = 5
+	agentPodCreateBurst = 10
+)
+
 // NewCreaterController returns a controller that creates new kube-cert-agent pods for every known
 // kube-controller-manager pod.
 //
@@ -45,6 +57,7 @@ func NewCreaterController(
 	clock clock.Clock,
 	k8sClient kubernetes.Interface,
 	pinnipedAPIClient pinnipedclientset.Interface,
+	recorder events.EventRecorder,
 	kubeSystemPodInformer corev1informers.PodInformer,
 	agentPodInformer corev1informers.PodInformer,
 	withInformer pinnipedcontroller.WithInformerOptionFunc,
@@ -63,8 +76,10 @@ func NewCreaterController(
 				pinnipedAPIClient:              pinnipedAPIClient,
 				kubeSystemPodInformer:          kubeSystemPodInformer,
 				agentPodInformer:               agentPodInformer,
+				podCreateRateLimiter:           flowcontrol.NewTokenBucketRateLimiter(agentPodCreateQPS, agentPodCreateBurst),
 			},
 		},
+		controllerlib.WithRecorder(recorder),
 		withInformer(
 			kubeSystemPodInformer,
 			pinnipedcontroller.SimpleFilterWithSingletonQueue(isControllerManagerPod),
@@ -102,10 +117,13 @@ func (c *createrController) Sync(ctx controllerlib.Context) error {
 			c.credentialIssuerLocationConfig.Name,
 			c.credentialIssuerLabels,
 			c.pinnipedAPIClient,
+			ctx.Recorder,
 			strategyError(c.clock, constable.Error("did not find kube-controller-manager pod(s)")),
+			c.credentialIssuerLocationConfig.DisableKubeConfigInfoMirroring,
 		)
 	}
 
+	var controllerManagerPodsWithoutAgents []*corev1.Pod
 	for _, controllerManagerPod := range controllerManagerPods {
 		agentPod, err := findAgentPodForSpecificControllerManagerPod(
 			controllerManagerPod,
@@ -117,43 +135,83 @@ func (c *createrController) Sync(ctx controllerlib.Context) error {
 			return err
 		}
 		if agentPod == nil {
-			agentPod = c.agentPodConfig.newAgentPod(controllerManagerPod)
-
-			plog.Debug(
-				"creating agent pod",
-				"pod",
-				klog.KObj(agentPod),
-				"controller",
-				klog.KObj(controllerManagerPod),
-			)
-			_, err := c.k8sClient.CoreV1().
-				Pods(c.agentPodConfig.Namespace).
-				Create(ctx.Context, agentPod, metav1.CreateOptions{})
-			if err != nil {
-				err = fmt.Errorf("cannot create agent pod: %w", err)
-				strategyResultUpdateErr := issuerconfig.UpdateStrategy(
-					ctx.Context,
-					c.credentialIssuerLocationConfig.Name,
-					c.credentialIssuerLabels,
-					c.pinnipedAPIClient,
-					strategyError(c.clock, err),
-				)
-				if strategyResultUpdateErr != nil {
-					// If the CI update fails, then we probably want to try again. This controller will get
-					// called again because of the pod create failure, so just try the CI update again then.
-					klog.ErrorS(strategyResultUpdateErr, "could not create or update CredentialIssuer")
-				}
-
-				return err
-			}
+			controllerManagerPodsWithoutAgents = append(controllerManagerPodsWithoutAgents, controllerManagerPod)
 		}
 
 		// The deleter controller handles the case where the expected fields do not match in the agent pod.
 	}
 
+	if err := c.createAgentPods(ctx.Context, controllerManagerPodsWithoutAgents); err != nil {
+		strategyResultUpdateErr := issuerconfig.UpdateStrategy(
+			ctx.Context,
+			c.credentialIssuerLocationConfig.Name,
+			c.credentialIssuerLabels,
+			c.pinnipedAPIClient,
+			ctx.Recorder,
+			strategyError(c.clock, err),
+			c.credentialIssuerLocationConfig.DisableKubeConfigInfoMirroring,
+		)
+		if strategyResultUpdateErr != nil {
+			// If the CI update fails, then we probably want to try again. This controller will get
+			// called again because of the pod create failure, so just try the CI update again then.
+			klog.ErrorS(strategyResultUpdateErr, "could not create or update CredentialIssuer")
+		}
+
+		return err
+	}
+
 	return nil
 }
 
+// createAgentPods creates an agent pod for each of the controller manager pods, with at most
+// agentPodConfig.MaxConcurrentPodCreates creations in flight at once and each creation subject to the rate
+// limiter, so that clusters with many controller manager pods do not cause a burst of API requests.
+func (c *createrController) createAgentPods(ctx context.Context, controllerManagerPods []*corev1.Pod) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	semaphore := make(chan struct{}, c.agentPodConfig.maxConcurrentPodCreates())
+	for _, controllerManagerPod := range controllerManagerPods {
+		controllerManagerPod := controllerManagerPod
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			if err := c.createAgentPod(ctx, controllerManagerPod); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				errs = append(errs, fmt.Errorf("cannot create agent pod: %w", err))
+			}
+		}()
+	}
+	wg.Wait()
+	return utilerrors.NewAggregate(errs)
+}
+
+func (c *createrController) createAgentPod(ctx context.Context, controllerManagerPod *corev1.Pod) error {
+	if err := c.podCreateRateLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	agentPod := c.agentPodConfig.newAgentPod(controllerManagerPod)
+	plog.Debug(
+		"creating agent pod",
+		"pod",
+		klog.KObj(agentPod),
+		"controller",
+		klog.KObj(controllerManagerPod),
+	)
+	_, err := c.k8sClient.CoreV1().
+		Pods(c.agentPodConfig.Namespace).
+		Create(ctx, agentPod, metav1.CreateOptions{})
+	return err
+}
+
 func findAgentPodForSpecificControllerManagerPod(
 	controllerManagerPod *corev1.Pod,
 	kubeSystemPodInformer corev1informers.PodInformer,
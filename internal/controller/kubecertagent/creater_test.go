@@ -6,6 +6,8 @@ package kubecertagent
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -16,6 +18,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/clock"
 	kubeinformers "k8s.io/client-go/informers"
 	corev1informers "k8s.io/client-go/informers/core/v1"
@@ -46,6 +49,7 @@ func TestCreaterControllerFilter(t *testing.T) {
 				nil, // clock, shouldn't matter
 				nil, // k8sClient, shouldn't matter
 				nil, // pinnipedAPIClient, shouldn't matter
+				nil, // recorder, shouldn't matter
 				kubeSystemPodInformer,
 				agentPodInformer,
 				observableWithInformerOption.WithInformer,
@@ -71,6 +75,7 @@ func TestCreaterControllerInitialEvent(t *testing.T) {
 		nil, // clock, shouldn't matter
 		nil, // k8sClient, shouldn't matter
 		nil, // pinnipedAPIClient, shouldn't matter
+		nil, // recorder, shouldn't matter
 		kubeSystemInformers.Core().V1().Pods(),
 		agentInformers.Core().V1().Pods(),
 		controllerlib.WithInformer,
@@ -127,6 +132,7 @@ func TestCreaterControllerSync(t *testing.T) {
 				clock.NewFakeClock(frozenNow),
 				kubeAPIClient,
 				pinnipedAPIClient,
+				nil, // recorder, not needed for this test
 				kubeSystemInformers.Core().V1().Pods(),
 				agentInformers.Core().V1().Pods(),
 				controllerlib.WithInformer,
@@ -328,6 +334,7 @@ func TestCreaterControllerSync(t *testing.T) {
 									LastUpdateTime: metav1.NewTime(frozenNow),
 								},
 							}
+							expectedCredentialIssuer.Status.Phase = configv1alpha1.ErrorCredentialIssuerPhase
 							expectedGetAction := coretesting.NewRootGetAction(
 								credentialIssuerGVR,
 								credentialIssuerResourceName,
@@ -393,6 +400,7 @@ func TestCreaterControllerSync(t *testing.T) {
 									},
 								},
 								Status: configv1alpha1.CredentialIssuerStatus{
+									Phase: configv1alpha1.ErrorCredentialIssuerPhase,
 									Strategies: []configv1alpha1.CredentialIssuerStrategy{
 										{
 											Type:           configv1alpha1.KubeClusterSigningCertificateStrategyType,
@@ -464,6 +472,7 @@ func TestCreaterControllerSync(t *testing.T) {
 							LastUpdateTime: metav1.NewTime(frozenNow),
 						},
 					}
+					expectedCredentialIssuer.Status.Phase = configv1alpha1.ErrorCredentialIssuerPhase
 					expectedGetAction := coretesting.NewRootGetAction(
 						credentialIssuerGVR,
 						credentialIssuerResourceName,
@@ -546,6 +555,7 @@ func TestCreaterControllerSync(t *testing.T) {
 							},
 						},
 						Status: configv1alpha1.CredentialIssuerStatus{
+							Phase: configv1alpha1.ErrorCredentialIssuerPhase,
 							Strategies: []configv1alpha1.CredentialIssuerStrategy{
 								{
 									Type:           configv1alpha1.KubeClusterSigningCertificateStrategyType,
@@ -621,3 +631,101 @@ func TestCreaterControllerSync(t *testing.T) {
 		})
 	}, spec.Parallel(), spec.Report(report.Terminal{}))
 }
+
+func TestCreaterControllerSyncConcurrencyLimit(t *testing.T) {
+	const controllerManagerPodCount = 5
+
+	tests := []struct {
+		name                    string
+		maxConcurrentPodCreates int
+		wantMaxInFlight         int
+	}{
+		{
+			name:                    "unset",
+			maxConcurrentPodCreates: 0,
+			wantMaxInFlight:         1,
+		},
+		{
+			name:                    "limit of 1",
+			maxConcurrentPodCreates: 1,
+			wantMaxInFlight:         1,
+		},
+		{
+			name:                    "limit of 3",
+			maxConcurrentPodCreates: 3,
+			wantMaxInFlight:         3,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			kubeAPIClient := kubernetesfake.NewSimpleClientset()
+			kubeSystemInformerClient := kubernetesfake.NewSimpleClientset()
+			kubeSystemInformers := kubeinformers.NewSharedInformerFactory(kubeSystemInformerClient, 0)
+			agentInformers := kubeinformers.NewSharedInformerFactory(kubernetesfake.NewSimpleClientset(), 0)
+
+			exampleControllerManagerPod, _ := exampleControllerManagerAndAgentPods("kube-system", "agent-pod-namespace", "ignored", "ignored")
+			for i := 0; i < controllerManagerPodCount; i++ {
+				controllerManagerPod := exampleControllerManagerPod.DeepCopy()
+				controllerManagerPod.Name = fmt.Sprintf("some-controller-manager-name-%d", i)
+				controllerManagerPod.UID = types.UID(fmt.Sprintf("some-controller-manager-uid-%d", i))
+				require.NoError(t, kubeSystemInformerClient.Tracker().Add(controllerManagerPod))
+			}
+
+			// Count the creates which are in flight at the same time. Each create holds on for a little while so
+			// that any concurrent creates have a chance to overlap with it.
+			var inFlight, maxInFlight, creates int32
+			kubeAPIClient.PrependReactor("create", "pods", func(_ coretesting.Action) (bool, runtime.Object, error) {
+				current := atomic.AddInt32(&inFlight, 1)
+				defer atomic.AddInt32(&inFlight, -1)
+				atomic.AddInt32(&creates, 1)
+				for {
+					previousMax := atomic.LoadInt32(&maxInFlight)
+					if current <= previousMax || atomic.CompareAndSwapInt32(&maxInFlight, previousMax, current) {
+						break
+					}
+				}
+				time.Sleep(50 * time.Millisecond)
+				return false, nil, nil
+			})
+
+			subject := NewCreaterController(
+				&AgentPodConfig{
+					Namespace:               "agent-pod-namespace",
+					ContainerImage:          "some-agent-image",
+					PodNamePrefix:           "some-agent-name-",
+					MaxConcurrentPodCreates: tt.maxConcurrentPodCreates,
+				},
+				&CredentialIssuerLocationConfig{Name: "ci-resource-name"},
+				nil,
+				clock.NewFakeClock(time.Now()),
+				kubeAPIClient,
+				pinnipedfake.NewSimpleClientset(),
+				nil, // recorder, not needed for this test
+				kubeSystemInformers.Core().V1().Pods(),
+				agentInformers.Core().V1().Pods(),
+				controllerlib.WithInformer,
+				controllerlib.WithInitialEvent,
+			)
+			kubeSystemInformers.Start(ctx.Done())
+			agentInformers.Start(ctx.Done())
+			controllerlib.TestRunSynchronously(t, subject)
+
+			err := controllerlib.TestSync(t, subject, controllerlib.Context{Context: ctx, Name: subject.Name()})
+			require.NoError(t, err)
+			require.EqualValues(t, controllerManagerPodCount, atomic.LoadInt32(&creates))
+			if tt.wantMaxInFlight == 1 {
+				require.EqualValues(t, 1, atomic.LoadInt32(&maxInFlight), "creates should have been serialized")
+			} else {
+				require.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(tt.wantMaxInFlight))
+			}
+
+			pods, err := kubeAPIClient.CoreV1().Pods("agent-pod-namespace").List(ctx, metav1.ListOptions{})
+			require.NoError(t, err)
+			require.Len(t, pods.Items, controllerManagerPodCount)
+		})
+	}
+}
@@ -312,6 +312,23 @@ func TestDeleterControllerSync(t *testing.T) {
 					})
 				})
 
+				when("the agent pod is out of sync with the template via image pull policy", func() {
+					it.Before(func() {
+						updatedAgentPod := agentPod.DeepCopy()
+						updatedAgentPod.Spec.Containers[0].ImagePullPolicy = corev1.PullAlways
+						r.NoError(agentInformerClient.Tracker().Update(podsGVR, updatedAgentPod, updatedAgentPod.Namespace))
+						r.NoError(kubeAPIClient.Tracker().Update(podsGVR, updatedAgentPod, updatedAgentPod.Namespace))
+					})
+
+					it("deletes the agent pod", func() {
+						startInformersAndController()
+						err := controllerlib.TestSync(t, subject, *syncContext)
+
+						r.NoError(err)
+						requireAgentPodWasDeleted()
+					})
+				})
+
 				when("the agent pod is out of sync with the template via runAsUser", func() {
 					it.Before(func() {
 						updatedAgentPod := agentPod.DeepCopy()
@@ -14,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/errors"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/events"
 
 	configv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
 	pinnipedclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
@@ -54,6 +55,7 @@ func NewExecerController(
 	dynamicCertProvider dynamiccert.Private,
 	podCommandExecutor PodCommandExecutor,
 	pinnipedAPIClient pinnipedclientset.Interface,
+	recorder events.EventRecorder,
 	clock clock.Clock,
 	agentPodInformer corev1informers.PodInformer,
 	configMapInformer corev1informers.ConfigMapInformer,
@@ -74,6 +76,7 @@ func NewExecerController(
 				configMapInformer:              configMapInformer,
 			},
 		},
+		controllerlib.WithRecorder(recorder),
 		withInformer(
 			agentPodInformer,
 			pinnipedcontroller.SimpleFilter(isAgentPod, nil), // nil parent func is fine because each event is distinct
@@ -117,7 +120,9 @@ func (c *execerController) Sync(ctx controllerlib.Context) error {
 			c.credentialIssuerLocationConfig.Name,
 			c.credentialIssuerLabels,
 			c.pinnipedAPIClient,
+			ctx.Recorder,
 			strategyError(c.clock, err),
+			c.credentialIssuerLocationConfig.DisableKubeConfigInfoMirroring,
 		)
 		return newAggregate(err, strategyResultUpdateErr)
 	}
@@ -129,7 +134,9 @@ func (c *execerController) Sync(ctx controllerlib.Context) error {
 			c.credentialIssuerLocationConfig.Name,
 			c.credentialIssuerLabels,
 			c.pinnipedAPIClient,
+			ctx.Recorder,
 			strategyError(c.clock, err),
+			c.credentialIssuerLocationConfig.DisableKubeConfigInfoMirroring,
 		)
 		return newAggregate(err, strategyResultUpdateErr)
 	}
@@ -141,7 +148,9 @@ func (c *execerController) Sync(ctx controllerlib.Context) error {
 			c.credentialIssuerLocationConfig.Name,
 			c.credentialIssuerLabels,
 			c.pinnipedAPIClient,
+			ctx.Recorder,
 			strategyError(c.clock, err),
+			c.credentialIssuerLocationConfig.DisableKubeConfigInfoMirroring,
 		)
 		return newAggregate(err, strategyResultUpdateErr)
 	}
@@ -153,6 +162,7 @@ func (c *execerController) Sync(ctx controllerlib.Context) error {
 			c.credentialIssuerLocationConfig.Name,
 			c.credentialIssuerLabels,
 			c.pinnipedAPIClient,
+			ctx.Recorder,
 			configv1alpha1.CredentialIssuerStrategy{
 				Type:           configv1alpha1.KubeClusterSigningCertificateStrategyType,
 				Status:         configv1alpha1.ErrorStrategyStatus,
@@ -160,6 +170,7 @@ func (c *execerController) Sync(ctx controllerlib.Context) error {
 				Message:        err.Error(),
 				LastUpdateTime: metav1.NewTime(c.clock.Now()),
 			},
+			c.credentialIssuerLocationConfig.DisableKubeConfigInfoMirroring,
 		)
 		return newAggregate(err, strategyResultUpdateErr)
 	}
@@ -169,6 +180,7 @@ func (c *execerController) Sync(ctx controllerlib.Context) error {
 		c.credentialIssuerLocationConfig.Name,
 		c.credentialIssuerLabels,
 		c.pinnipedAPIClient,
+		ctx.Recorder,
 		configv1alpha1.CredentialIssuerStrategy{
 			Type:           configv1alpha1.KubeClusterSigningCertificateStrategyType,
 			Status:         configv1alpha1.SuccessStrategyStatus,
@@ -180,6 +192,7 @@ func (c *execerController) Sync(ctx controllerlib.Context) error {
 				TokenCredentialRequestAPIInfo: apiInfo,
 			},
 		},
+		c.credentialIssuerLocationConfig.DisableKubeConfigInfoMirroring,
 	)
 }
 
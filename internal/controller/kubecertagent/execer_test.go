@@ -54,6 +54,7 @@ func TestExecerControllerOptions(t *testing.T) {
 				nil, // dynamicCertProvider, not needed for this test
 				nil, // podCommandExecutor, not needed for this test
 				nil, // pinnipedAPIClient, not needed for this test
+				nil, // recorder, not needed for this test
 				nil, // clock, not needed for this test
 				agentPodsInformer,
 				configMapsInformer,
@@ -154,6 +155,7 @@ func TestManagerControllerSync(t *testing.T) {
 		var fakeExecutor *fakePodExecutor
 		var credentialIssuerLabels map[string]string
 		var discoveryURLOverride *string
+		var disableKubeConfigInfoMirroring bool
 		var dynamicCertProvider dynamiccert.Provider
 		var fakeCertPEM, fakeKeyPEM string
 		var credentialIssuerGVR schema.GroupVersionResource
@@ -167,13 +169,15 @@ func TestManagerControllerSync(t *testing.T) {
 			// Set this at the last second to allow for injection of server override.
 			subject = NewExecerController(
 				&CredentialIssuerLocationConfig{
-					Name: credentialIssuerResourceName,
+					Name:                           credentialIssuerResourceName,
+					DisableKubeConfigInfoMirroring: disableKubeConfigInfoMirroring,
 				},
 				credentialIssuerLabels,
 				discoveryURLOverride,
 				dynamicCertProvider,
 				fakeExecutor,
 				pinnipedAPIClient,
+				nil, // recorder, not needed for this test
 				clock.NewFakeClock(frozenNow),
 				kubeInformerFactory.Core().V1().Pods(),
 				kubeInformerFactory.Core().V1().ConfigMaps(),
@@ -343,6 +347,7 @@ func TestManagerControllerSync(t *testing.T) {
 								Name: credentialIssuerResourceName,
 							},
 							Status: configv1alpha1.CredentialIssuerStatus{
+								Phase: configv1alpha1.ErrorCredentialIssuerPhase,
 								Strategies: []configv1alpha1.CredentialIssuerStrategy{
 									{
 										Type:           configv1alpha1.KubeClusterSigningCertificateStrategyType,
@@ -501,6 +506,7 @@ func TestManagerControllerSync(t *testing.T) {
 								Server:                   "https://some-server",
 								CertificateAuthorityData: "c29tZS1jZXJ0aWZpY2F0ZS1hdXRob3JpdHktZGF0YQo=",
 							}
+							expectedCredentialIssuer.Status.Phase = configv1alpha1.ReadyCredentialIssuerPhase
 							expectedGetAction := coretesting.NewRootGetAction(credentialIssuerGVR, credentialIssuerResourceName)
 							expectedCreateAction := coretesting.NewRootUpdateSubresourceAction(credentialIssuerGVR, "status", expectedCredentialIssuer)
 							r.Equal([]coretesting.Action{expectedGetAction, expectedCreateAction}, pinnipedAPIClient.Actions())
@@ -551,6 +557,7 @@ func TestManagerControllerSync(t *testing.T) {
 									Labels: map[string]string{"foo": "bar"},
 								},
 								Status: configv1alpha1.CredentialIssuerStatus{
+									Phase: configv1alpha1.ReadyCredentialIssuerPhase,
 									Strategies: []configv1alpha1.CredentialIssuerStrategy{
 										{
 											Type:           configv1alpha1.KubeClusterSigningCertificateStrategyType,
@@ -579,6 +586,24 @@ func TestManagerControllerSync(t *testing.T) {
 							r.Equal([]coretesting.Action{expectedGetAction, expectedCreateAction, expectedUpdateAction}, pinnipedAPIClient.Actions())
 						})
 					})
+
+					when("mirroring into the deprecated kubeConfigInfo field is disabled", func() {
+						it.Before(func() {
+							disableKubeConfigInfoMirroring = true
+							startInformersAndController()
+						})
+
+						it("creates the CredentialIssuer without the deprecated kubeConfigInfo field", func() {
+							r.NoError(controllerlib.TestSync(t, subject, *syncContext))
+
+							actualCredentialIssuer, err := pinnipedAPIClient.ConfigV1alpha1().CredentialIssuers().Get(cancelContext, credentialIssuerResourceName, metav1.GetOptions{})
+							r.NoError(err)
+							r.Equal(configv1alpha1.ReadyCredentialIssuerPhase, actualCredentialIssuer.Status.Phase)
+							r.Len(actualCredentialIssuer.Status.Strategies, 1)
+							r.Equal(configv1alpha1.TokenCredentialRequestAPIFrontendType, actualCredentialIssuer.Status.Strategies[0].Frontend.Type)
+							r.Nil(actualCredentialIssuer.Status.KubeConfigInfo)
+						})
+					})
 				})
 			})
 
@@ -613,6 +638,7 @@ func TestManagerControllerSync(t *testing.T) {
 							Name: credentialIssuerResourceName,
 						},
 						Status: configv1alpha1.CredentialIssuerStatus{
+							Phase: configv1alpha1.ErrorCredentialIssuerPhase,
 							Strategies: []configv1alpha1.CredentialIssuerStrategy{
 								{
 									Type:           configv1alpha1.KubeClusterSigningCertificateStrategyType,
@@ -662,6 +688,7 @@ func TestManagerControllerSync(t *testing.T) {
 							Name: credentialIssuerResourceName,
 						},
 						Status: configv1alpha1.CredentialIssuerStatus{
+							Phase: configv1alpha1.ErrorCredentialIssuerPhase,
 							Strategies: []configv1alpha1.CredentialIssuerStrategy{
 								{
 									Type:           configv1alpha1.KubeClusterSigningCertificateStrategyType,
@@ -711,6 +738,7 @@ func TestManagerControllerSync(t *testing.T) {
 							Name: credentialIssuerResourceName,
 						},
 						Status: configv1alpha1.CredentialIssuerStatus{
+							Phase: configv1alpha1.ErrorCredentialIssuerPhase,
 							Strategies: []configv1alpha1.CredentialIssuerStrategy{
 								{
 									Type:           configv1alpha1.KubeClusterSigningCertificateStrategyType,
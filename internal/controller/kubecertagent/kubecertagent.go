@@ -59,6 +59,9 @@ type AgentPodConfig struct {
 	// The container image used for the agent pods.
 	ContainerImage string
 
+	// The image pull policy used for the agent pods' container. Defaults to IfNotPresent when empty.
+	ContainerImagePullPolicy corev1.PullPolicy
+
 	// The name prefix for each of the agent pods.
 	PodNamePrefix string
 
@@ -66,6 +69,9 @@ type AgentPodConfig struct {
 	// ImagePullSecrets on the kube-cert-agent pods.
 	ContainerImagePullSecrets []string
 
+	// The maximum number of agent pods which will be created at the same time. Defaults to 1 when zero.
+	MaxConcurrentPodCreates int
+
 	// Additional labels that should be added to every agent pod during creation.
 	AdditionalLabels map[string]string
 }
@@ -73,6 +79,10 @@ type AgentPodConfig struct {
 type CredentialIssuerLocationConfig struct {
 	// The resource name for the CredentialIssuer to be created/updated.
 	Name string
+
+	// Whether to skip mirroring the "TokenCredentialRequestAPI" frontend into the deprecated status.kubeConfigInfo
+	// field of the CredentialIssuer.
+	DisableKubeConfigInfoMirroring bool
 }
 
 func (c *AgentPodConfig) Labels() map[string]string {
@@ -89,12 +99,24 @@ func (c *AgentPodConfig) AgentSelector() labels.Selector {
 	return labels.SelectorFromSet(map[string]string{agentPodLabelKey: agentPodLabelValue})
 }
 
+func (c *AgentPodConfig) maxConcurrentPodCreates() int {
+	if c.MaxConcurrentPodCreates < 1 {
+		return 1
+	}
+	return c.MaxConcurrentPodCreates
+}
+
 func (c *AgentPodConfig) newAgentPod(controllerManagerPod *corev1.Pod) *corev1.Pod {
 	terminateImmediately := int64(0)
 	rootID := int64(0)
 	f := false
 	falsePtr := &f
 
+	imagePullPolicy := c.ContainerImagePullPolicy
+	if imagePullPolicy == "" {
+		imagePullPolicy = corev1.PullIfNotPresent
+	}
+
 	imagePullSecrets := []corev1.LocalObjectReference{}
 	for _, imagePullSecret := range c.ContainerImagePullSecrets {
 		imagePullSecrets = append(
@@ -122,7 +144,7 @@ func (c *AgentPodConfig) newAgentPod(controllerManagerPod *corev1.Pod) *corev1.P
 				{
 					Name:            "sleeper",
 					Image:           c.ContainerImage,
-					ImagePullPolicy: corev1.PullIfNotPresent,
+					ImagePullPolicy: imagePullPolicy,
 					Command:         []string{"/bin/sleep", "infinity"},
 					VolumeMounts:    controllerManagerPod.Spec.Containers[0].VolumeMounts,
 					Resources: corev1.ResourceRequirements{
@@ -180,6 +202,10 @@ func isAgentPodUpToDate(actualAgentPod, expectedAgentPod *corev1.Pod) bool {
 			actualAgentPod.Spec.Containers[0].Image,
 			expectedAgentPod.Spec.Containers[0].Image,
 		) &&
+		equality.Semantic.DeepEqual(
+			actualAgentPod.Spec.Containers[0].ImagePullPolicy,
+			expectedAgentPod.Spec.Containers[0].ImagePullPolicy,
+		) &&
 		equality.Semantic.DeepEqual(
 			actualAgentPod.Spec.Containers[0].Command,
 			expectedAgentPod.Spec.Containers[0].Command,
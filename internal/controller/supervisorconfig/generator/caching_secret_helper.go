@@ -0,0 +1,61 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// maxHandlesCacheEntries bounds the memory used by a cachingSecretHelper. Entries are keyed by UID, so entries for
+// deleted objects are never updated again; the cache is simply cleared when it grows past this size.
+const maxHandlesCacheEntries = 4096
+
+// NewCachingSecretHelper returns a SecretHelper which memoizes the results of the delegate's Handles method.
+// Results are keyed by the object's UID and resourceVersion, so any change to an object (such as a change to its
+// owner references) causes Handles to be recomputed. All other methods are passed through to the delegate.
+func NewCachingSecretHelper(delegate SecretHelper) SecretHelper {
+	return &cachingSecretHelper{
+		SecretHelper: delegate,
+		handles:      map[types.UID]handlesCacheEntry{},
+	}
+}
+
+type cachingSecretHelper struct {
+	SecretHelper
+
+	// mutex guards handles, since informer event handlers and controller workers may call Handles concurrently.
+	mutex   sync.Mutex
+	handles map[types.UID]handlesCacheEntry
+}
+
+type handlesCacheEntry struct {
+	resourceVersion string
+	handles         bool
+}
+
+// Handles implements SecretHelper.Handles().
+func (c *cachingSecretHelper) Handles(obj metav1.Object) bool {
+	uid, resourceVersion := obj.GetUID(), obj.GetResourceVersion()
+	if uid == "" || resourceVersion == "" {
+		// Objects which did not come from the API server cannot be safely cached.
+		return c.SecretHelper.Handles(obj)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if entry, ok := c.handles[uid]; ok && entry.resourceVersion == resourceVersion {
+		return entry.handles
+	}
+
+	handles := c.SecretHelper.Handles(obj)
+	if len(c.handles) >= maxHandlesCacheEntries {
+		c.handles = map[types.UID]handlesCacheEntry{}
+	}
+	c.handles[uid] = handlesCacheEntry{resourceVersion: resourceVersion, handles: handles}
+	return handles
+}
@@ -0,0 +1,130 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	configv1alpha1 "go.pinniped.dev/generated/latest/apis/supervisor/config/v1alpha1"
+	"go.pinniped.dev/internal/mocks/mocksecrethelper"
+)
+
+func newTestFederationDomainSecret(resourceVersion string, controlled bool) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "some-secret",
+			Namespace:       "some-namespace",
+			UID:             "some-secret-uid",
+			ResourceVersion: resourceVersion,
+		},
+		Type: FederationDomainTokenSigningKeyType,
+	}
+	if controlled {
+		parent := &configv1alpha1.FederationDomain{ObjectMeta: metav1.ObjectMeta{Name: "some-federation-domain", UID: "some-federation-domain-uid"}}
+		secret.OwnerReferences = []metav1.OwnerReference{
+			*metav1.NewControllerRef(parent, schema.GroupVersionKind{
+				Group:   configv1alpha1.SchemeGroupVersion.Group,
+				Version: configv1alpha1.SchemeGroupVersion.Version,
+				Kind:    federationDomainKind,
+			}),
+		}
+	}
+	return secret
+}
+
+func TestCachingSecretHelper(t *testing.T) {
+	t.Parallel()
+
+	t.Run("caches Handles by UID and resourceVersion", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		delegate := mocksecrethelper.NewMockSecretHelper(ctrl)
+		secretV1 := newTestFederationDomainSecret("1", true)
+		secretV2 := newTestFederationDomainSecret("2", true)
+		delegate.EXPECT().Handles(secretV1).Return(true).Times(1)
+		delegate.EXPECT().Handles(secretV2).Return(true).Times(1)
+
+		helper := NewCachingSecretHelper(delegate)
+		require.True(t, helper.Handles(secretV1))
+		require.True(t, helper.Handles(secretV1))
+		require.True(t, helper.Handles(secretV2))
+		require.True(t, helper.Handles(secretV2))
+	})
+
+	t.Run("does not cache objects without a UID or resourceVersion", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		delegate := mocksecrethelper.NewMockSecretHelper(ctrl)
+		noResourceVersion := newTestFederationDomainSecret("", true)
+		noUID := newTestFederationDomainSecret("1", true)
+		noUID.UID = ""
+		delegate.EXPECT().Handles(noResourceVersion).Return(true).Times(2)
+		delegate.EXPECT().Handles(noUID).Return(false).Times(2)
+
+		helper := NewCachingSecretHelper(delegate)
+		require.True(t, helper.Handles(noResourceVersion))
+		require.True(t, helper.Handles(noResourceVersion))
+		require.False(t, helper.Handles(noUID))
+		require.False(t, helper.Handles(noUID))
+	})
+
+	t.Run("cache hits do not mask ownership changes", func(t *testing.T) {
+		t.Parallel()
+
+		helper := NewCachingSecretHelper(NewSymmetricSecretHelper("some-prefix-", nil, strings.NewReader(""), SecretUsageTokenSigningKey, nil))
+
+		require.True(t, helper.Handles(newTestFederationDomainSecret("1", true)))
+		require.True(t, helper.Handles(newTestFederationDomainSecret("1", true)))
+
+		// The owner reference was removed, which bumped the resourceVersion.
+		require.False(t, helper.Handles(newTestFederationDomainSecret("2", false)))
+		require.False(t, helper.Handles(newTestFederationDomainSecret("2", false)))
+
+		// The owner reference was added back.
+		require.True(t, helper.Handles(newTestFederationDomainSecret("3", true)))
+	})
+
+	t.Run("passes other methods through to the delegate", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		delegate := mocksecrethelper.NewMockSecretHelper(ctrl)
+		delegate.EXPECT().NamePrefix().Return("some-prefix-")
+
+		require.Equal(t, "some-prefix-", NewCachingSecretHelper(delegate).NamePrefix())
+	})
+}
+
+func BenchmarkSecretHelperHandles(b *testing.B) {
+	secret := newTestFederationDomainSecret("1", true)
+	uncached := NewSymmetricSecretHelper("some-prefix-", nil, strings.NewReader(""), SecretUsageTokenSigningKey, nil)
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			uncached.Handles(secret)
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		cached := NewCachingSecretHelper(uncached)
+		for i := 0; i < b.N; i++ {
+			cached.Handles(secret)
+		}
+	})
+}
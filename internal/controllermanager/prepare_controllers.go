@@ -8,11 +8,14 @@ package controllermanager
 import (
 	"context"
 	"fmt"
+	"net"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/clock"
 	k8sinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/events"
 	"k8s.io/klog/v2/klogr"
 
 	pinnipedclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
@@ -80,6 +83,11 @@ type Config struct {
 	// ServingCertDuration is the validity period, in seconds, of the API serving certificate.
 	ServingCertDuration time.Duration
 
+	// ServingCertDNSNames and ServingCertIPAddresses are additional subject alternative names for the API serving
+	// certificate, on top of the DNS name of the API's Service.
+	ServingCertDNSNames    []string
+	ServingCertIPAddresses []net.IP
+
 	// ServingCertRenewBefore is the period of time, in seconds, that pinniped will wait before
 	// rotating the serving certificate. This period of time starts upon issuance of the serving
 	// certificate.
@@ -90,6 +98,10 @@ type Config struct {
 
 	// Labels are labels that should be added to any resources created by the controllers.
 	Labels map[string]string
+
+	// DisableKubeConfigInfoMirroring stops the controllers from mirroring the "TokenCredentialRequestAPI" frontend
+	// into the deprecated status.kubeConfigInfo field of the CredentialIssuer.
+	DisableKubeConfigInfoMirroring bool
 }
 
 // Prepare the controllers and their informers and return a function that will start them when called.
@@ -119,16 +131,24 @@ func PrepareControllers(c *Config) (func(ctx context.Context), error) {
 	// Create informers. Don't forget to make sure they get started in the function returned below.
 	informers := createInformers(c.ServerInstallationInfo.Namespace, client.Kubernetes, client.PinnipedConcierge)
 
+	// Create an event recorder for the controllers which report changes to the CredentialIssuer. Don't forget to make
+	// sure the broadcaster gets started in the function returned below.
+	eventBroadcaster := events.NewEventBroadcasterAdapter(client.Kubernetes)
+	recorder := eventBroadcaster.NewRecorder("pinniped-concierge")
+
 	// Configuration for the kubecertagent controllers created below.
 	agentPodConfig := &kubecertagent.AgentPodConfig{
 		Namespace:                 c.ServerInstallationInfo.Namespace,
 		ContainerImage:            *c.KubeCertAgentConfig.Image,
+		ContainerImagePullPolicy:  corev1.PullPolicy(*c.KubeCertAgentConfig.ImagePullPolicy),
 		PodNamePrefix:             *c.KubeCertAgentConfig.NamePrefix,
 		ContainerImagePullSecrets: c.KubeCertAgentConfig.ImagePullSecrets,
+		MaxConcurrentPodCreates:   *c.KubeCertAgentConfig.MaxConcurrentPodCreates,
 		AdditionalLabels:          c.Labels,
 	}
 	credentialIssuerLocationConfig := &kubecertagent.CredentialIssuerLocationConfig{
-		Name: c.NamesConfig.CredentialIssuer,
+		Name:                           c.NamesConfig.CredentialIssuer,
+		DisableKubeConfigInfoMirroring: c.DisableKubeConfigInfoMirroring,
 	}
 
 	// Create controller manager.
@@ -148,6 +168,8 @@ func PrepareControllers(c *Config) (func(ctx context.Context), error) {
 				c.ServingCertDuration,
 				"Pinniped CA",
 				c.NamesConfig.APIService,
+				c.ServingCertDNSNames,
+				c.ServingCertIPAddresses,
 			),
 			singletonWorker,
 		).
@@ -192,6 +214,7 @@ func PrepareControllers(c *Config) (func(ctx context.Context), error) {
 				controllerlib.WithInformer,
 				c.ServingCertRenewBefore,
 				apicerts.TLSCertificateChainSecretKey,
+				clock.RealClock{},
 			),
 			singletonWorker,
 		).
@@ -206,6 +229,7 @@ func PrepareControllers(c *Config) (func(ctx context.Context), error) {
 				clock.RealClock{},
 				client.Kubernetes,
 				client.PinnipedConcierge,
+				recorder,
 				informers.kubeSystemNamespaceK8s.Core().V1().Pods(),
 				informers.installationNamespaceK8s.Core().V1().Pods(),
 				controllerlib.WithInformer,
@@ -221,6 +245,7 @@ func PrepareControllers(c *Config) (func(ctx context.Context), error) {
 				clock.RealClock{},
 				client.Kubernetes,
 				client.PinnipedConcierge,
+				recorder,
 				informers.kubeSystemNamespaceK8s.Core().V1().Pods(),
 				informers.installationNamespaceK8s.Core().V1().Pods(),
 				controllerlib.WithInformer,
@@ -235,6 +260,7 @@ func PrepareControllers(c *Config) (func(ctx context.Context), error) {
 				c.DynamicSigningCertProvider,
 				kubecertagent.NewPodCommandExecutor(client.JSONConfig, client.Kubernetes),
 				client.PinnipedConcierge,
+				recorder,
 				clock.RealClock{},
 				informers.installationNamespaceK8s.Core().V1().Pods(),
 				informers.kubePublicNamespaceK8s.Core().V1().ConfigMaps(),
@@ -287,8 +313,10 @@ func PrepareControllers(c *Config) (func(ctx context.Context), error) {
 				c.ServerInstallationInfo.Namespace,
 				c.NamesConfig.ImpersonationConfigMap,
 				c.NamesConfig.CredentialIssuer,
+				c.DisableKubeConfigInfoMirroring,
 				client.Kubernetes,
 				client.PinnipedConcierge,
+				recorder,
 				informers.installationNamespaceK8s.Core().V1().ConfigMaps(),
 				informers.installationNamespaceK8s.Core().V1().Services(),
 				informers.installationNamespaceK8s.Core().V1().Secrets(),
@@ -317,6 +345,8 @@ func PrepareControllers(c *Config) (func(ctx context.Context), error) {
 				365*24*time.Hour, // 1 year hard coded value
 				"Pinniped Impersonation Proxy CA",
 				"", // optional, means do not give me a serving cert
+				nil,
+				nil,
 			),
 			singletonWorker,
 		).
@@ -329,12 +359,14 @@ func PrepareControllers(c *Config) (func(ctx context.Context), error) {
 				controllerlib.WithInformer,
 				c.ServingCertRenewBefore,
 				apicerts.CACertificateSecretKey,
+				clock.RealClock{},
 			),
 			singletonWorker,
 		)
 
 	// Return a function which starts the informers and controllers.
 	return func(ctx context.Context) {
+		eventBroadcaster.StartRecordingToSink(ctx.Done())
 		informers.startAndWaitForSync(ctx)
 		go controllerManager.Start(ctx)
 	}, nil
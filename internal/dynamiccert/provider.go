@@ -7,16 +7,26 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"sync"
+	"time"
 
 	"k8s.io/apiserver/pkg/server/dynamiccertificates"
 
+	"go.pinniped.dev/internal/certauthority"
 	"go.pinniped.dev/internal/plog"
 )
 
 type Provider interface {
 	Private
 	Public
+
+	// RotateToNewCA generates a brand new CA and sets it as the current content, notifying listeners once.
+	RotateToNewCA(commonName string, ttl time.Duration) error
+
+	// CurrentCAKeyContent returns the private key of the CA so that it can be used to issue new leaf certs.
+	// It returns false when the Provider was not constructed via NewCAIssuer or when no content is set.
+	CurrentCAKeyContent() ([]byte, bool)
 }
 
 type Private interface {
@@ -24,6 +34,13 @@ type Private interface {
 	SetCertKeyContent(certPEM, keyPEM []byte) error
 	UnsetCertKeyContent()
 
+	// SetCertKeyContentFromFiles reads the PEM encoded cert and key from the given paths and sets them exactly like
+	// SetCertKeyContent does. The current content is left alone when either file cannot be read or is invalid.
+	SetCertKeyContentFromFiles(certPath, keyPath string) error
+
+	// HasContent returns true when both the cert and key content are currently set.
+	HasContent() bool
+
 	notifier
 }
 
@@ -36,20 +53,33 @@ type Public interface {
 type notifier interface {
 	dynamiccertificates.Notifier
 	dynamiccertificates.ControllerRunner // we do not need this today, but it could grow and change in the future
+
+	// RemoveListener stops notifying the given listener of content changes. The listener is matched by equality
+	// against the values passed to AddListener, so it must be comparable (i.e. a pointer). It is a no-op when the
+	// listener is not registered. It is safe to call concurrently with AddListener and content updates.
+	RemoveListener(listener dynamiccertificates.Listener)
+
+	// Close stops notifying listeners of content changes and drops any registered listeners. It is safe to call
+	// more than once. Servers should call it at the start of shutdown, before they stop their listeners, so that
+	// the TLS config used by in-flight handshakes is not swapped out while the server drains. The current content
+	// remains readable after Close.
+	Close()
 }
 
 var _ Provider = &provider{}
 
 type provider struct {
 	// these fields are constant after struct initialization and thus do not need locking
-	name string
-	isCA bool
+	name     string
+	isCA     bool
+	isIssuer bool
 
 	// mutex guards all the fields below it
 	mutex     sync.RWMutex
 	certPEM   []byte
 	keyPEM    []byte
 	listeners []dynamiccertificates.Listener
+	closed    bool
 }
 
 // NewServingCert returns a Private that is go routine safe.
@@ -64,6 +94,12 @@ func NewCA(name string) Provider {
 	return &provider{name: name, isCA: true}
 }
 
+// NewCAIssuer returns a Provider that is go routine safe.
+// It can only hold key pairs that have IsCA=true, and unlike NewCA, it allows its private key to be read back.
+func NewCAIssuer(name string) Provider {
+	return &provider{name: name, isCA: true, isIssuer: true}
+}
+
 func (p *provider) Name() string {
 	return p.name
 }
@@ -75,6 +111,13 @@ func (p *provider) CurrentCertKeyContent() (cert []byte, key []byte) {
 	return p.certPEM, p.keyPEM
 }
 
+func (p *provider) HasContent() bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return len(p.certPEM) != 0 && len(p.keyPEM) != 0
+}
+
 func (p *provider) SetCertKeyContent(certPEM, keyPEM []byte) error {
 	// always make sure that we have valid PEM data, otherwise
 	// dynamiccertificates.NewUnionCAContentProvider.VerifyOptions will panic
@@ -102,6 +145,36 @@ func (p *provider) SetCertKeyContent(certPEM, keyPEM []byte) error {
 	return nil
 }
 
+func (p *provider) SetCertKeyContentFromFiles(certPath, keyPath string) error {
+	certPEM, err := ioutil.ReadFile(certPath)
+	if err != nil {
+		return fmt.Errorf("%s: could not read cert file: %w", p.name, err)
+	}
+	keyPEM, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return fmt.Errorf("%s: could not read key file: %w", p.name, err)
+	}
+
+	return p.SetCertKeyContent(certPEM, keyPEM)
+}
+
+func (p *provider) RotateToNewCA(commonName string, ttl time.Duration) error {
+	if !p.isCA {
+		return fmt.Errorf("%s: attempt to rotate a serving cert to a new CA", p.name)
+	}
+
+	ca, err := certauthority.New(commonName, ttl)
+	if err != nil {
+		return fmt.Errorf("%s: could not generate new CA: %w", p.name, err)
+	}
+	keyPEM, err := ca.PrivateKeyToPEM()
+	if err != nil {
+		return fmt.Errorf("%s: could not encode new CA private key: %w", p.name, err)
+	}
+
+	return p.SetCertKeyContent(ca.Bundle(), keyPEM)
+}
+
 func (p *provider) UnsetCertKeyContent() {
 	p.setCertKeyContent(nil, nil)
 }
@@ -128,6 +201,19 @@ func (p *provider) CurrentCABundleContent() []byte {
 	return ca
 }
 
+func (p *provider) CurrentCAKeyContent() ([]byte, bool) {
+	if !p.isCA {
+		panic("*provider from NewServingCert was cast into wrong CA interface")
+	}
+
+	if !p.isIssuer {
+		return nil, false
+	}
+
+	_, key := p.CurrentCertKeyContent()
+	return key, len(key) != 0
+}
+
 func (p *provider) VerifyOptions() (x509.VerifyOptions, bool) {
 	if !p.isCA {
 		panic("*provider from NewServingCert was cast into wrong CA interface")
@@ -141,9 +227,36 @@ func (p *provider) AddListener(listener dynamiccertificates.Listener) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
+	if p.closed {
+		return
+	}
+
 	p.listeners = append(p.listeners, listener)
 }
 
+func (p *provider) RemoveListener(listener dynamiccertificates.Listener) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	// build a new slice instead of removing in place so that we never mutate a backing array shared with append
+	listeners := make([]dynamiccertificates.Listener, 0, len(p.listeners))
+	for _, l := range p.listeners {
+		if l == listener {
+			continue
+		}
+		listeners = append(listeners, l)
+	}
+	p.listeners = listeners
+}
+
+func (p *provider) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.closed = true
+	p.listeners = nil
+}
+
 func (p *provider) RunOnce() error {
 	return nil // no-op, but we want to make sure to stay in sync with dynamiccertificates.ControllerRunner
 }
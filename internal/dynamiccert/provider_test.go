@@ -6,8 +6,12 @@ package dynamiccert
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"io/ioutil"
 	"net"
+	"path/filepath"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -18,6 +22,7 @@ import (
 	"k8s.io/apiserver/pkg/storage/names"
 
 	"go.pinniped.dev/internal/certauthority"
+	"go.pinniped.dev/internal/testutil"
 	"go.pinniped.dev/test/library"
 )
 
@@ -26,11 +31,11 @@ func TestProviderWithDynamicServingCertificateController(t *testing.T) {
 
 	tests := []struct {
 		name string
-		f    func(t *testing.T, ca Provider, certKey Private) (wantClientCASubjects [][]byte, wantCerts []tls.Certificate)
+		f    func(t *testing.T, ca Provider, certKey Private) (wantClientCAs *x509.CertPool, wantCerts []tls.Certificate)
 	}{
 		{
 			name: "no-op leave everything alone",
-			f: func(t *testing.T, ca Provider, certKey Private) ([][]byte, []tls.Certificate) {
+			f: func(t *testing.T, ca Provider, certKey Private) (*x509.CertPool, []tls.Certificate) {
 				pool := x509.NewCertPool()
 				ok := pool.AppendCertsFromPEM(ca.CurrentCABundleContent())
 				require.True(t, ok, "should have valid non-empty CA bundle")
@@ -39,12 +44,12 @@ func TestProviderWithDynamicServingCertificateController(t *testing.T) {
 				cert, err := tls.X509KeyPair(certPEM, keyPEM)
 				require.NoError(t, err)
 
-				return pool.Subjects(), []tls.Certificate{cert}
+				return pool, []tls.Certificate{cert}
 			},
 		},
 		{
 			name: "unset the CA",
-			f: func(t *testing.T, ca Provider, certKey Private) ([][]byte, []tls.Certificate) {
+			f: func(t *testing.T, ca Provider, certKey Private) (*x509.CertPool, []tls.Certificate) {
 				ca.UnsetCertKeyContent()
 
 				certPEM, keyPEM := certKey.CurrentCertKeyContent()
@@ -56,7 +61,7 @@ func TestProviderWithDynamicServingCertificateController(t *testing.T) {
 		},
 		{
 			name: "unset the serving cert - still serves the old content",
-			f: func(t *testing.T, ca Provider, certKey Private) ([][]byte, []tls.Certificate) {
+			f: func(t *testing.T, ca Provider, certKey Private) (*x509.CertPool, []tls.Certificate) {
 				pool := x509.NewCertPool()
 				ok := pool.AppendCertsFromPEM(ca.CurrentCABundleContent())
 				require.True(t, ok, "should have valid non-empty CA bundle")
@@ -67,30 +72,30 @@ func TestProviderWithDynamicServingCertificateController(t *testing.T) {
 
 				certKey.UnsetCertKeyContent()
 
-				return pool.Subjects(), []tls.Certificate{cert}
+				return pool, []tls.Certificate{cert}
 			},
 		},
 		{
 			name: "change to a new CA",
-			f: func(t *testing.T, ca Provider, certKey Private) ([][]byte, []tls.Certificate) {
+			f: func(t *testing.T, ca Provider, certKey Private) (*x509.CertPool, []tls.Certificate) {
 				// use unique names for all CAs to make sure the pool subjects are different
-				newCA, err := certauthority.New(names.SimpleNameGenerator.GenerateName("new-ca"), time.Hour)
-				require.NoError(t, err)
-				caKey, err := newCA.PrivateKeyToPEM()
-				require.NoError(t, err)
-				err = ca.SetCertKeyContent(newCA.Bundle(), caKey)
+				err := ca.RotateToNewCA(names.SimpleNameGenerator.GenerateName("new-ca"), time.Hour)
 				require.NoError(t, err)
 
+				pool := x509.NewCertPool()
+				ok := pool.AppendCertsFromPEM(ca.CurrentCABundleContent())
+				require.True(t, ok, "should have valid non-empty CA bundle")
+
 				certPEM, keyPEM := certKey.CurrentCertKeyContent()
 				cert, err := tls.X509KeyPair(certPEM, keyPEM)
 				require.NoError(t, err)
 
-				return newCA.Pool().Subjects(), []tls.Certificate{cert}
+				return pool, []tls.Certificate{cert}
 			},
 		},
 		{
 			name: "change to new serving cert",
-			f: func(t *testing.T, ca Provider, certKey Private) ([][]byte, []tls.Certificate) {
+			f: func(t *testing.T, ca Provider, certKey Private) (*x509.CertPool, []tls.Certificate) {
 				// use unique names for all CAs to make sure the pool subjects are different
 				newCA, err := certauthority.New(names.SimpleNameGenerator.GenerateName("new-ca"), time.Hour)
 				require.NoError(t, err)
@@ -108,12 +113,12 @@ func TestProviderWithDynamicServingCertificateController(t *testing.T) {
 				ok := pool.AppendCertsFromPEM(ca.CurrentCABundleContent())
 				require.True(t, ok, "should have valid non-empty CA bundle")
 
-				return pool.Subjects(), []tls.Certificate{cert}
+				return pool, []tls.Certificate{cert}
 			},
 		},
 		{
 			name: "change both CA and serving cert",
-			f: func(t *testing.T, ca Provider, certKey Private) ([][]byte, []tls.Certificate) {
+			f: func(t *testing.T, ca Provider, certKey Private) (*x509.CertPool, []tls.Certificate) {
 				// use unique names for all CAs to make sure the pool subjects are different
 				newCA, err := certauthority.New(names.SimpleNameGenerator.GenerateName("new-ca"), time.Hour)
 				require.NoError(t, err)
@@ -135,7 +140,7 @@ func TestProviderWithDynamicServingCertificateController(t *testing.T) {
 				err = ca.SetCertKeyContent(newOtherCA.Bundle(), caKey)
 				require.NoError(t, err)
 
-				return newOtherCA.Pool().Subjects(), []tls.Certificate{cert}
+				return newOtherCA.Pool(), []tls.Certificate{cert}
 			},
 		},
 	}
@@ -159,33 +164,14 @@ func TestProviderWithDynamicServingCertificateController(t *testing.T) {
 			err = certKeyContent.SetCertKeyContent(cert, key)
 			require.NoError(t, err)
 
-			tlsConfig := &tls.Config{
-				MinVersion: tls.VersionTLS12,
-				NextProtos: []string{"h2", "http/1.1"},
-				ClientAuth: tls.RequestClientCert,
-			}
-
-			dynamicCertificateController := dynamiccertificates.NewDynamicServingCertificateController(
-				tlsConfig,
-				caContent,
-				certKeyContent,
-				nil, // we do not care about SNI
-				nil, // we do not care about events
-			)
-
-			caContent.AddListener(dynamicCertificateController)
-			certKeyContent.AddListener(dynamicCertificateController)
-
-			err = dynamicCertificateController.RunOnce()
+			tlsConfig, run, err := NewServingTLSConfig(caContent, certKeyContent)
 			require.NoError(t, err)
 
 			stopCh := make(chan struct{})
 			defer close(stopCh)
-			go dynamicCertificateController.Run(1, stopCh)
-
-			tlsConfig.GetConfigForClient = dynamicCertificateController.GetConfigForClient
+			go run(stopCh)
 
-			wantClientCASubjects, wantCerts := tt.f(t, caContent, certKeyContent)
+			wantClientCAs, wantCerts := tt.f(t, caContent, certKeyContent)
 
 			var lastTLSConfig *tls.Config
 
@@ -198,14 +184,14 @@ func TestProviderWithDynamicServingCertificateController(t *testing.T) {
 
 				lastTLSConfig = actualTLSConfig
 
-				return reflect.DeepEqual(wantClientCASubjects, poolSubjects(actualTLSConfig.ClientCAs)) &&
+				return certauthority.PoolsEqual(wantClientCAs, actualTLSConfig.ClientCAs) &&
 					reflect.DeepEqual(wantCerts, actualTLSConfig.Certificates), nil
 			})
 
 			if err != nil && lastTLSConfig != nil {
 				// for debugging failures
 				t.Log("diff between client CAs:\n", cmp.Diff(
-					library.Sdump(wantClientCASubjects),
+					library.Sdump(poolSubjects(wantClientCAs)),
 					library.Sdump(poolSubjects(lastTLSConfig.ClientCAs)),
 				))
 				t.Log("diff between serving certs:\n", cmp.Diff(
@@ -218,6 +204,295 @@ func TestProviderWithDynamicServingCertificateController(t *testing.T) {
 	}
 }
 
+func TestRotateToNewCA(t *testing.T) {
+	t.Parallel()
+
+	ca, err := certauthority.New("old-ca", time.Hour)
+	require.NoError(t, err)
+	caKey, err := ca.PrivateKeyToPEM()
+	require.NoError(t, err)
+
+	caContent := NewCA("ca")
+	require.NoError(t, caContent.SetCertKeyContent(ca.Bundle(), caKey))
+
+	listener := &countingListener{}
+	caContent.AddListener(listener)
+
+	oldPool := x509.NewCertPool()
+	require.True(t, oldPool.AppendCertsFromPEM(caContent.CurrentCABundleContent()))
+
+	require.NoError(t, caContent.RotateToNewCA("new-ca", time.Hour))
+	require.Equal(t, 1, listener.count)
+
+	newPool := x509.NewCertPool()
+	require.True(t, newPool.AppendCertsFromPEM(caContent.CurrentCABundleContent()))
+	require.False(t, certauthority.PoolsEqual(oldPool, newPool))
+
+	// the new content must be a valid CA key pair which can be loaded back.
+	certPEM, keyPEM := caContent.CurrentCertKeyContent()
+	_, err = certauthority.Load(string(certPEM), string(keyPEM))
+	require.NoError(t, err)
+}
+
+func TestSetCertKeyContentFromFiles(t *testing.T) {
+	t.Parallel()
+
+	ca, err := certauthority.New("ca", time.Hour)
+	require.NoError(t, err)
+	caKey, err := ca.PrivateKeyToPEM()
+	require.NoError(t, err)
+	cert, key, err := ca.IssueServerCertPEM([]string{"example.com"}, nil, time.Hour)
+	require.NoError(t, err)
+
+	dir := testutil.TempDir(t)
+	writeFile := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, data, 0600))
+		return path
+	}
+	certPath := writeFile("tls.crt", cert)
+	keyPath := writeFile("tls.key", key)
+	caCertPath := writeFile("ca.crt", ca.Bundle())
+	caKeyPath := writeFile("ca.key", caKey)
+	missingPath := filepath.Join(dir, "missing")
+
+	certKeyContent := NewServingCert("cert-key")
+	listener := &countingListener{}
+	certKeyContent.AddListener(listener)
+
+	require.NoError(t, certKeyContent.SetCertKeyContentFromFiles(certPath, keyPath))
+	require.Equal(t, 1, listener.count)
+	gotCert, gotKey := certKeyContent.CurrentCertKeyContent()
+	require.Equal(t, cert, gotCert)
+	require.Equal(t, key, gotKey)
+
+	// failures leave the current content alone and do not notify listeners
+	err = certKeyContent.SetCertKeyContentFromFiles(missingPath, keyPath)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cert-key: could not read cert file: ")
+	err = certKeyContent.SetCertKeyContentFromFiles(certPath, missingPath)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cert-key: could not read key file: ")
+	require.EqualError(t, certKeyContent.SetCertKeyContentFromFiles(certPath, caKeyPath),
+		"cert-key: attempt to set invalid key pair: tls: private key does not match public key")
+	require.EqualError(t, certKeyContent.SetCertKeyContentFromFiles(caCertPath, caKeyPath),
+		"cert-key: attempt to set x509 cert with unexpected IsCA=true")
+	require.Equal(t, 1, listener.count)
+	gotCert, gotKey = certKeyContent.CurrentCertKeyContent()
+	require.Equal(t, cert, gotCert)
+	require.Equal(t, key, gotKey)
+}
+
+func TestRotateToNewCAOnServingCert(t *testing.T) {
+	t.Parallel()
+
+	certKeyContent := NewServingCert("cert-key").(*provider)
+	listener := &countingListener{}
+	certKeyContent.AddListener(listener)
+
+	require.EqualError(t, certKeyContent.RotateToNewCA("new-ca", time.Hour), "cert-key: attempt to rotate a serving cert to a new CA")
+	require.Zero(t, listener.count)
+}
+
+func TestClose(t *testing.T) {
+	t.Parallel()
+
+	ca, err := certauthority.New("ca", time.Hour)
+	require.NoError(t, err)
+	cert, key, err := ca.IssueServerCertPEM([]string{"example.com"}, nil, time.Hour)
+	require.NoError(t, err)
+
+	certKeyContent := NewServingCert("cert-key")
+	listener := &countingListener{}
+	certKeyContent.AddListener(listener)
+
+	require.NoError(t, certKeyContent.SetCertKeyContent(cert, key))
+	require.Equal(t, 1, listener.count)
+
+	certKeyContent.Close()
+	certKeyContent.Close() // closing twice is fine
+
+	lateListener := &countingListener{}
+	certKeyContent.AddListener(lateListener)
+
+	certKeyContent.UnsetCertKeyContent()
+	require.NoError(t, certKeyContent.SetCertKeyContent(cert, key))
+	require.Equal(t, 1, listener.count, "listener should not fire after Close")
+	require.Zero(t, lateListener.count, "listener added after Close should never fire")
+
+	// content is still served after Close
+	gotCert, gotKey := certKeyContent.CurrentCertKeyContent()
+	require.Equal(t, cert, gotCert)
+	require.Equal(t, key, gotKey)
+}
+
+func TestRemoveListener(t *testing.T) {
+	t.Parallel()
+
+	ca, err := certauthority.New("ca", time.Hour)
+	require.NoError(t, err)
+	cert, key, err := ca.IssueServerCertPEM([]string{"example.com"}, nil, time.Hour)
+	require.NoError(t, err)
+
+	certKeyContent := NewServingCert("cert-key")
+	removed := &countingListener{}
+	kept := &countingListener{}
+	certKeyContent.AddListener(removed)
+	certKeyContent.AddListener(kept)
+
+	require.NoError(t, certKeyContent.SetCertKeyContent(cert, key))
+	require.Equal(t, 1, removed.count)
+	require.Equal(t, 1, kept.count)
+
+	certKeyContent.RemoveListener(removed)
+	certKeyContent.RemoveListener(removed)             // removing twice is fine
+	certKeyContent.RemoveListener(&countingListener{}) // removing an unknown listener is fine
+
+	require.NoError(t, certKeyContent.SetCertKeyContent(cert, key))
+	require.Equal(t, 1, removed.count, "removed listener should not fire")
+	require.Equal(t, 2, kept.count)
+}
+
+func TestConcurrentListenerChanges(t *testing.T) {
+	t.Parallel()
+
+	ca, err := certauthority.New("ca", time.Hour)
+	require.NoError(t, err)
+	cert, key, err := ca.IssueServerCertPEM([]string{"example.com"}, nil, time.Hour)
+	require.NoError(t, err)
+
+	const (
+		workers    = 10
+		iterations = 100
+	)
+
+	certKeyContent := NewServingCert("cert-key")
+	persistent := &atomicCountingListener{}
+	certKeyContent.AddListener(persistent)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				listener := &atomicCountingListener{}
+				certKeyContent.AddListener(listener)
+				certKeyContent.RemoveListener(listener)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				if err := certKeyContent.SetCertKeyContent(cert, key); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, int64(workers*iterations), persistent.load(), "persistent listener should see every update")
+	require.Equal(t, []dynamiccertificates.Listener{persistent}, certKeyContent.(*provider).listeners,
+		"all temporary listeners should have been removed")
+
+	final := &atomicCountingListener{}
+	certKeyContent.AddListener(final)
+	require.NoError(t, certKeyContent.SetCertKeyContent(cert, key))
+	require.Equal(t, int64(1), final.load())
+	require.Equal(t, int64(workers*iterations+1), persistent.load())
+}
+
+func TestHasContent(t *testing.T) {
+	t.Parallel()
+
+	ca, err := certauthority.New("ca", time.Hour)
+	require.NoError(t, err)
+	caKey, err := ca.PrivateKeyToPEM()
+	require.NoError(t, err)
+
+	cert, key, err := ca.IssueServerCertPEM([]string{"example.com"}, nil, time.Hour)
+	require.NoError(t, err)
+
+	caContent := NewCA("ca")
+	require.False(t, caContent.HasContent())
+	require.NoError(t, caContent.SetCertKeyContent(ca.Bundle(), caKey))
+	require.True(t, caContent.HasContent())
+	caContent.UnsetCertKeyContent()
+	require.False(t, caContent.HasContent())
+	require.NoError(t, caContent.RotateToNewCA("new-ca", time.Hour))
+	require.True(t, caContent.HasContent())
+
+	servingContent := NewServingCert("serving-cert")
+	require.False(t, servingContent.HasContent())
+	require.Error(t, servingContent.SetCertKeyContent(ca.Bundle(), caKey)) // invalid content is never stored
+	require.False(t, servingContent.HasContent())
+	require.NoError(t, servingContent.SetCertKeyContent(cert, key))
+	require.True(t, servingContent.HasContent())
+	servingContent.UnsetCertKeyContent()
+	require.False(t, servingContent.HasContent())
+}
+
+func TestCurrentCAKeyContent(t *testing.T) {
+	t.Parallel()
+
+	ca, err := certauthority.New("ca", time.Hour)
+	require.NoError(t, err)
+	caKey, err := ca.PrivateKeyToPEM()
+	require.NoError(t, err)
+
+	t.Run("issuer mode", func(t *testing.T) {
+		t.Parallel()
+
+		caContent := NewCAIssuer("ca")
+		key, ok := caContent.CurrentCAKeyContent()
+		require.False(t, ok)
+		require.Nil(t, key)
+
+		require.NoError(t, caContent.SetCertKeyContent(ca.Bundle(), caKey))
+		key, ok = caContent.CurrentCAKeyContent()
+		require.True(t, ok)
+		require.Equal(t, caKey, key)
+
+		caContent.UnsetCertKeyContent()
+		key, ok = caContent.CurrentCAKeyContent()
+		require.False(t, ok)
+		require.Nil(t, key)
+	})
+
+	t.Run("bundle only mode", func(t *testing.T) {
+		t.Parallel()
+
+		caContent := NewCA("ca")
+		require.NoError(t, caContent.SetCertKeyContent(ca.Bundle(), caKey))
+		key, ok := caContent.CurrentCAKeyContent()
+		require.False(t, ok)
+		require.Nil(t, key)
+		require.Equal(t, ca.Bundle(), caContent.CurrentCABundleContent())
+	})
+}
+
+type countingListener struct {
+	count int
+}
+
+func (l *countingListener) Enqueue() {
+	l.count++
+}
+
+type atomicCountingListener struct {
+	count int64
+}
+
+func (l *atomicCountingListener) Enqueue() {
+	atomic.AddInt64(&l.count, 1)
+}
+
+func (l *atomicCountingListener) load() int64 {
+	return atomic.LoadInt64(&l.count)
+}
+
 func poolSubjects(pool *x509.CertPool) [][]byte {
 	if pool == nil {
 		return nil
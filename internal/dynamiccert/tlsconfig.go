@@ -0,0 +1,52 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package dynamiccert
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"k8s.io/apiserver/pkg/server/dynamiccertificates"
+)
+
+// NewServingTLSConfig returns a tls.Config which serves the current content of certKey and, when ca is not nil,
+// requests client certs signed by the current content of ca. Its GetConfigForClient picks up any later changes
+// to the content of either provider once the returned run func has been started (typically in a go routine),
+// which keeps running until the given stop channel is closed. An error is returned when certKey has no content.
+func NewServingTLSConfig(ca Provider, certKey Private) (*tls.Config, func(stop <-chan struct{}), error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		ClientAuth: tls.RequestClientCert,
+	}
+
+	var clientCA dynamiccertificates.CAContentProvider
+	if ca != nil {
+		clientCA = ca
+	}
+
+	dynamicCertificateController := dynamiccertificates.NewDynamicServingCertificateController(
+		tlsConfig,
+		clientCA,
+		certKey,
+		nil, // we do not care about SNI
+		nil, // we do not care about events
+	)
+
+	if ca != nil {
+		ca.AddListener(dynamicCertificateController)
+	}
+	certKey.AddListener(dynamicCertificateController)
+
+	if err := dynamicCertificateController.RunOnce(); err != nil {
+		return nil, nil, fmt.Errorf("%s: could not load initial serving cert: %w", certKey.Name(), err)
+	}
+
+	tlsConfig.GetConfigForClient = dynamicCertificateController.GetConfigForClient
+
+	run := func(stop <-chan struct{}) {
+		dynamicCertificateController.Run(1, stop)
+	}
+	return tlsConfig, run, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package dynamiccert
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"go.pinniped.dev/internal/certauthority"
+)
+
+func TestNewServingTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	ca, err := certauthority.New("serving-ca", time.Hour)
+	require.NoError(t, err)
+
+	certPEM, keyPEM, err := ca.IssueServerCertPEM(nil, []net.IP{net.ParseIP("127.0.0.1")}, time.Hour)
+	require.NoError(t, err)
+	certKeyContent := NewServingCert("cert-key")
+	require.NoError(t, certKeyContent.SetCertKeyContent(certPEM, keyPEM))
+
+	tlsConfig, run, err := NewServingTLSConfig(nil, certKeyContent)
+	require.NoError(t, err)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go run(stopCh)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return // the listener was closed
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}
+	}()
+
+	// servedCert returns the DER bytes of the leaf cert presented by the server.
+	servedCert := func() ([]byte, error) {
+		conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			RootCAs:    ca.Pool(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = conn.Close() }()
+		return conn.ConnectionState().PeerCertificates[0].Raw, nil
+	}
+
+	got, err := servedCert()
+	require.NoError(t, err)
+	require.Equal(t, derBytes(t, certPEM), got)
+
+	newCertPEM, newKeyPEM, err := ca.IssueServerCertPEM(nil, []net.IP{net.ParseIP("127.0.0.1")}, 2*time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, certKeyContent.SetCertKeyContent(newCertPEM, newKeyPEM))
+
+	// it will take some time for the controller to catch up
+	require.NoError(t, wait.PollImmediate(100*time.Millisecond, 30*time.Second, func() (bool, error) {
+		got, err := servedCert()
+		if err != nil {
+			return false, err
+		}
+		return string(got) == string(derBytes(t, newCertPEM)), nil
+	}))
+}
+
+func TestNewServingTLSConfigWithoutContent(t *testing.T) {
+	t.Parallel()
+
+	tlsConfig, run, err := NewServingTLSConfig(NewCA("ca"), NewServingCert("cert-key"))
+	require.EqualError(t, err, `cert-key: could not load initial serving cert: not loading an empty serving certificate from "cert-key"`)
+	require.Nil(t, tlsConfig)
+	require.Nil(t, run)
+}
+
+func derBytes(t *testing.T, certPEM []byte) []byte {
+	t.Helper()
+
+	block, _ := pem.Decode(certPEM)
+	require.NotNil(t, block)
+	return block.Bytes
+}
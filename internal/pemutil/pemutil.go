@@ -0,0 +1,44 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package pemutil contains helpers for working with PEM-encoded certificate bundles.
+package pemutil
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+// MergePEM concatenates the certificates from each of the PEM blobs into a single PEM bundle. Certificates are
+// deduplicated by their DER encoding and are emitted in the order in which they were first seen, so merging the
+// same inputs always produces the same output. It returns an error if any blob contains a PEM block which is not a
+// valid certificate.
+func MergePEM(blobs ...[]byte) ([]byte, error) {
+	var merged []byte
+	seen := map[[sha256.Size]byte]bool{}
+	for _, blob := range blobs {
+		for {
+			var block *pem.Block
+			block, blob = pem.Decode(blob)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				return nil, fmt.Errorf("unexpected PEM block type %q", block.Type)
+			}
+			if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+				return nil, fmt.Errorf("could not parse certificate: %w", err)
+			}
+			fingerprint := sha256.Sum256(block.Bytes)
+			if seen[fingerprint] {
+				continue
+			}
+			seen[fingerprint] = true
+			// Re-encode only the DER bytes so that any PEM headers in the input are not carried along.
+			merged = append(merged, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: block.Bytes})...)
+		}
+	}
+	return merged, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package pemutil
+
+import (
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go.pinniped.dev/internal/certauthority"
+)
+
+func TestMergePEM(t *testing.T) {
+	ca1, err := certauthority.New("Test CA 1", time.Hour)
+	require.NoError(t, err)
+	ca2, err := certauthority.New("Test CA 2", time.Hour)
+	require.NoError(t, err)
+	ca3, err := certauthority.New("Test CA 3", time.Hour)
+	require.NoError(t, err)
+	keyPEM, err := ca1.PrivateKeyToPEM()
+	require.NoError(t, err)
+
+	concat := func(blobs ...[]byte) []byte {
+		var out []byte
+		for _, blob := range blobs {
+			out = append(out, blob...)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		blobs   [][]byte
+		want    []byte
+		wantErr string
+	}{
+		{
+			name: "no input",
+			want: nil,
+		},
+		{
+			name:  "empty blobs",
+			blobs: [][]byte{nil, {}},
+			want:  nil,
+		},
+		{
+			name:  "single blob",
+			blobs: [][]byte{ca1.Bundle()},
+			want:  ca1.Bundle(),
+		},
+		{
+			name:  "distinct blobs keep their input order",
+			blobs: [][]byte{ca2.Bundle(), ca1.Bundle(), ca3.Bundle()},
+			want:  concat(ca2.Bundle(), ca1.Bundle(), ca3.Bundle()),
+		},
+		{
+			name:  "duplicates across blobs are dropped",
+			blobs: [][]byte{concat(ca1.Bundle(), ca2.Bundle()), concat(ca2.Bundle(), ca3.Bundle()), ca1.Bundle()},
+			want:  concat(ca1.Bundle(), ca2.Bundle(), ca3.Bundle()),
+		},
+		{
+			name:  "duplicates within a blob are dropped",
+			blobs: [][]byte{concat(ca1.Bundle(), ca1.Bundle(), ca2.Bundle())},
+			want:  concat(ca1.Bundle(), ca2.Bundle()),
+		},
+		{
+			name: "PEM headers and surrounding text are not carried along",
+			blobs: [][]byte{concat(
+				[]byte("some leading text\n"),
+				pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Headers: map[string]string{"Some": "Header"}, Bytes: ca1.Certificates()[0].Raw}),
+			)},
+			want: ca1.Bundle(),
+		},
+		{
+			name:    "non-certificate PEM block",
+			blobs:   [][]byte{ca1.Bundle(), concat(ca2.Bundle(), keyPEM)},
+			wantErr: `unexpected PEM block type "EC PRIVATE KEY"`,
+		},
+		{
+			name:    "invalid certificate",
+			blobs:   [][]byte{pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("not a certificate")})},
+			wantErr: "could not parse certificate: ", // the rest of the message depends on the Go version
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePEM(tt.blobs...)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				require.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, string(tt.want), string(got))
+
+			// Merging the result again is stable.
+			again, err := MergePEM(got)
+			require.NoError(t, err)
+			require.Equal(t, string(got), string(again))
+		})
+	}
+}
@@ -22,6 +22,7 @@ import (
 
 	loginapi "go.pinniped.dev/generated/latest/apis/concierge/login"
 	"go.pinniped.dev/internal/issuer"
+	"go.pinniped.dev/internal/registry/tablecolumns"
 )
 
 // clientCertificateTTL is the TTL for short-lived client certificates returned by this API.
@@ -31,11 +32,11 @@ type TokenCredentialRequestAuthenticator interface {
 	AuthenticateTokenCredentialRequest(ctx context.Context, req *loginapi.TokenCredentialRequest) (user.Info, error)
 }
 
-func NewREST(authenticator TokenCredentialRequestAuthenticator, issuer issuer.ClientCertIssuer, resource schema.GroupResource) *REST {
+func NewREST(authenticator TokenCredentialRequestAuthenticator, issuer issuer.ClientCertIssuer, resource schema.GroupResource, tableColumns []string) *REST {
 	return &REST{
 		authenticator:  authenticator,
 		issuer:         issuer,
-		tableConvertor: rest.NewDefaultTableConvertor(resource),
+		tableConvertor: tablecolumns.NewTableConvertor(resource, tableColumns),
 	}
 }
 
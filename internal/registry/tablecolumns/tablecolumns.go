@@ -0,0 +1,89 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tablecolumns provides a rest.TableConvertor which only renders a configured subset of the
+// default Table columns.
+package tablecolumns
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/registry/rest"
+)
+
+const (
+	// Name is the name of the default Table column which shows the name of the object.
+	Name = "Name"
+
+	// CreatedAt is the name of the default Table column which shows the creation timestamp of the object.
+	CreatedAt = "Created At"
+)
+
+// defaultColumns are the columns rendered by rest.NewDefaultTableConvertor, in order.
+//nolint: gochecknoglobals
+var defaultColumns = []string{Name, CreatedAt}
+
+// Supported returns true when column is the name of one of the default Table columns.
+func Supported(column string) bool {
+	for _, c := range defaultColumns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// NewTableConvertor returns a rest.TableConvertor for the resource which renders only the named columns, in the
+// order that they appear in the default Table. When columns is nil, all of the default columns are rendered.
+func NewTableConvertor(resource schema.GroupResource, columns []string) rest.TableConvertor {
+	delegate := rest.NewDefaultTableConvertor(resource)
+	if columns == nil {
+		return delegate
+	}
+
+	enabled := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		enabled[column] = true
+	}
+	keep := make([]int, 0, len(defaultColumns))
+	for i, column := range defaultColumns {
+		if enabled[column] {
+			keep = append(keep, i)
+		}
+	}
+	return &filteringTableConvertor{delegate: delegate, keep: keep}
+}
+
+type filteringTableConvertor struct {
+	delegate rest.TableConvertor
+	keep     []int // indexes of the default columns to render
+}
+
+func (c *filteringTableConvertor) ConvertToTable(ctx context.Context, obj runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	table, err := c.delegate.ConvertToTable(ctx, obj, tableOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Column definitions are absent when the client asked for no headers, but the rows still have every cell.
+	if table.ColumnDefinitions != nil {
+		columnDefinitions := make([]metav1.TableColumnDefinition, 0, len(c.keep))
+		for _, i := range c.keep {
+			columnDefinitions = append(columnDefinitions, table.ColumnDefinitions[i])
+		}
+		table.ColumnDefinitions = columnDefinitions
+	}
+
+	for r := range table.Rows {
+		cells := make([]interface{}, 0, len(c.keep))
+		for _, i := range c.keep {
+			cells = append(cells, table.Rows[r].Cells[i])
+		}
+		table.Rows[r].Cells = cells
+	}
+
+	return table, nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package tablecolumns
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	identityapi "go.pinniped.dev/generated/latest/apis/concierge/identity"
+)
+
+func TestNewTableConvertor(t *testing.T) {
+	createdAt := metav1.Now()
+	list := &identityapi.WhoAmIRequestList{
+		Items: []identityapi.WhoAmIRequest{
+			{ObjectMeta: metav1.ObjectMeta{Name: "some-name", CreationTimestamp: createdAt}},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		columns      []string
+		tableOptions runtime.Object
+		wantColumns  []string
+		wantCells    []interface{}
+	}{
+		{
+			name:        "nil columns shows all default columns",
+			columns:     nil,
+			wantColumns: []string{"Name", "Created At"},
+			wantCells:   []interface{}{"some-name", createdAt.Time.UTC().Format(time.RFC3339)},
+		},
+		{
+			name:        "only the name column",
+			columns:     []string{Name},
+			wantColumns: []string{"Name"},
+			wantCells:   []interface{}{"some-name"},
+		},
+		{
+			name:        "columns are rendered in their default order",
+			columns:     []string{CreatedAt, Name},
+			wantColumns: []string{"Name", "Created At"},
+			wantCells:   []interface{}{"some-name", createdAt.Time.UTC().Format(time.RFC3339)},
+		},
+		{
+			name:         "no headers",
+			columns:      []string{CreatedAt},
+			tableOptions: &metav1.TableOptions{NoHeaders: true},
+			wantColumns:  nil,
+			wantCells:    []interface{}{createdAt.Time.UTC().Format(time.RFC3339)},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			convertor := NewTableConvertor(schema.GroupResource{Group: "bears", Resource: "panda"}, tt.columns)
+
+			table, err := convertor.ConvertToTable(context.Background(), list, tt.tableOptions)
+			require.NoError(t, err)
+
+			var gotColumns []string
+			for _, definition := range table.ColumnDefinitions {
+				gotColumns = append(gotColumns, definition.Name)
+			}
+			require.Equal(t, tt.wantColumns, gotColumns)
+			require.Len(t, table.Rows, 1)
+			require.Equal(t, tt.wantCells, table.Rows[0].Cells)
+		})
+	}
+}
+
+func TestNewTableConvertorError(t *testing.T) {
+	convertor := NewTableConvertor(schema.GroupResource{Group: "bears", Resource: "panda"}, []string{Name})
+	_, err := convertor.ConvertToTable(context.Background(), &metav1.APIGroup{}, nil)
+	require.EqualError(t, err, "the resource panda.bears does not support being converted to a Table")
+}
+
+func TestSupported(t *testing.T) {
+	require.True(t, Supported("Name"))
+	require.True(t, Supported("Created At"))
+	require.False(t, Supported("Age"))
+	require.False(t, Supported(""))
+}
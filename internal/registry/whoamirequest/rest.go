@@ -19,11 +19,12 @@ import (
 
 	identityapi "go.pinniped.dev/generated/latest/apis/concierge/identity"
 	identityapivalidation "go.pinniped.dev/generated/latest/apis/concierge/identity/validation"
+	"go.pinniped.dev/internal/registry/tablecolumns"
 )
 
-func NewREST(resource schema.GroupResource) *REST {
+func NewREST(resource schema.GroupResource, tableColumns []string) *REST {
 	return &REST{
-		tableConvertor: rest.NewDefaultTableConvertor(resource),
+		tableConvertor: tablecolumns.NewTableConvertor(resource, tableColumns),
 	}
 }
 
@@ -21,7 +21,7 @@ import (
 )
 
 func TestNew(t *testing.T) {
-	r := NewREST(schema.GroupResource{Group: "bears", Resource: "panda"})
+	r := NewREST(schema.GroupResource{Group: "bears", Resource: "panda"}, nil)
 	require.NotNil(t, r)
 	require.False(t, r.NamespaceScoped())
 	require.Equal(t, []string{"pinniped"}, r.Categories())
@@ -51,6 +51,19 @@ func TestNew(t *testing.T) {
 	require.Error(t, err, "the resource panda.bears does not support being converted to a Table")
 }
 
+func TestConvertToTableWithConfiguredColumns(t *testing.T) {
+	r := NewREST(schema.GroupResource{Group: "bears", Resource: "panda"}, []string{"Name"})
+
+	table, err := r.ConvertToTable(context.Background(), &identityapi.WhoAmIRequestList{
+		Items: []identityapi.WhoAmIRequest{{ObjectMeta: metav1.ObjectMeta{Name: "some-name"}}},
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, table.ColumnDefinitions, 1)
+	require.Equal(t, "Name", table.ColumnDefinitions[0].Name)
+	require.Len(t, table.Rows, 1)
+	require.Equal(t, []interface{}{"some-name"}, table.Rows[0].Cells)
+}
+
 func TestCreate(t *testing.T) {
 	type args struct {
 		ctx              context.Context
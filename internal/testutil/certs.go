@@ -172,3 +172,11 @@ func CreateCertificate(notBefore, notAfter time.Time) ([]byte, []byte, error) {
 	})
 	return certPEM, privateKeyPEM, nil
 }
+
+// CreateExpiredCertificate creates a certificate which expired an hour ago, using CreateCertificate.
+func CreateExpiredCertificate() ([]byte, []byte, error) {
+	return CreateCertificate(
+		time.Now().Add(-24*time.Hour), // notBefore
+		time.Now().Add(-time.Hour),    // notAfter
+	)
+}
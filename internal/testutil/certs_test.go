@@ -0,0 +1,49 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package testutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateCertificate(t *testing.T) {
+	notBefore := time.Now().Add(-time.Hour).Truncate(time.Second)
+	notAfter := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	certPEM, keyPEM, err := CreateCertificate(notBefore, notAfter)
+	require.NoError(t, err)
+	_, err = tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	cert := parseCertificatePEM(t, certPEM)
+	require.True(t, cert.IsCA)
+	require.True(t, notBefore.Equal(cert.NotBefore), "expected NotBefore %s, got %s", notBefore, cert.NotBefore)
+	require.True(t, notAfter.Equal(cert.NotAfter), "expected NotAfter %s, got %s", notAfter, cert.NotAfter)
+}
+
+func TestCreateExpiredCertificate(t *testing.T) {
+	certPEM, keyPEM, err := CreateExpiredCertificate()
+	require.NoError(t, err)
+	_, err = tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	cert := parseCertificatePEM(t, certPEM)
+	require.True(t, cert.NotBefore.Before(cert.NotAfter))
+	require.True(t, cert.NotAfter.Before(time.Now()), "expected the cert to already be expired, but NotAfter is %s", cert.NotAfter)
+}
+
+func parseCertificatePEM(t *testing.T, certPEM []byte) *x509.Certificate {
+	t.Helper()
+	block, _ := pem.Decode(certPEM)
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	return cert
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package testutil
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+
+	authenticationv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/authentication/v1alpha1"
+	conciergeclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
+	authenticationv1alpha1client "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned/typed/authentication/v1alpha1"
+)
+
+// ContextAwareConciergeClientset wraps a (typically fake) Concierge clientset so that its JWTAuthenticator methods
+// return the context's error instead of calling through when the context is already cancelled or past its deadline.
+// The generated fake clientsets accept a context but otherwise ignore it, so this is useful for tests which need to
+// assert on cancellation behavior.
+func ContextAwareConciergeClientset(clientset conciergeclientset.Interface) conciergeclientset.Interface {
+	return &contextAwareConciergeClientset{Interface: clientset}
+}
+
+type contextAwareConciergeClientset struct {
+	conciergeclientset.Interface
+}
+
+func (c *contextAwareConciergeClientset) AuthenticationV1alpha1() authenticationv1alpha1client.AuthenticationV1alpha1Interface {
+	return &contextAwareAuthenticationV1alpha1{AuthenticationV1alpha1Interface: c.Interface.AuthenticationV1alpha1()}
+}
+
+type contextAwareAuthenticationV1alpha1 struct {
+	authenticationv1alpha1client.AuthenticationV1alpha1Interface
+}
+
+func (c *contextAwareAuthenticationV1alpha1) JWTAuthenticators() authenticationv1alpha1client.JWTAuthenticatorInterface {
+	return &contextAwareJWTAuthenticators{delegate: c.AuthenticationV1alpha1Interface.JWTAuthenticators()}
+}
+
+type contextAwareJWTAuthenticators struct {
+	delegate authenticationv1alpha1client.JWTAuthenticatorInterface
+}
+
+var _ authenticationv1alpha1client.JWTAuthenticatorInterface = (*contextAwareJWTAuthenticators)(nil)
+
+func (c *contextAwareJWTAuthenticators) Create(ctx context.Context, jwtAuthenticator *authenticationv1alpha1.JWTAuthenticator, opts metav1.CreateOptions) (*authenticationv1alpha1.JWTAuthenticator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.delegate.Create(ctx, jwtAuthenticator, opts)
+}
+
+func (c *contextAwareJWTAuthenticators) Update(ctx context.Context, jwtAuthenticator *authenticationv1alpha1.JWTAuthenticator, opts metav1.UpdateOptions) (*authenticationv1alpha1.JWTAuthenticator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.delegate.Update(ctx, jwtAuthenticator, opts)
+}
+
+func (c *contextAwareJWTAuthenticators) UpdateStatus(ctx context.Context, jwtAuthenticator *authenticationv1alpha1.JWTAuthenticator, opts metav1.UpdateOptions) (*authenticationv1alpha1.JWTAuthenticator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.delegate.UpdateStatus(ctx, jwtAuthenticator, opts)
+}
+
+func (c *contextAwareJWTAuthenticators) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.delegate.Delete(ctx, name, opts)
+}
+
+func (c *contextAwareJWTAuthenticators) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.delegate.DeleteCollection(ctx, opts, listOpts)
+}
+
+func (c *contextAwareJWTAuthenticators) Get(ctx context.Context, name string, opts metav1.GetOptions) (*authenticationv1alpha1.JWTAuthenticator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.delegate.Get(ctx, name, opts)
+}
+
+func (c *contextAwareJWTAuthenticators) List(ctx context.Context, opts metav1.ListOptions) (*authenticationv1alpha1.JWTAuthenticatorList, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.delegate.List(ctx, opts)
+}
+
+func (c *contextAwareJWTAuthenticators) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.delegate.Watch(ctx, opts)
+}
+
+func (c *contextAwareJWTAuthenticators) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*authenticationv1alpha1.JWTAuthenticator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.delegate.Patch(ctx, name, pt, data, opts, subresources...)
+}
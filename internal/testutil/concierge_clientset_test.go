@@ -0,0 +1,60 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package testutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	authenticationv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/authentication/v1alpha1"
+	conciergefake "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned/fake"
+)
+
+func TestContextAwareConciergeClientset(t *testing.T) {
+	existing := &authenticationv1alpha1.JWTAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "existing-authenticator"}}
+	newAuthenticator := &authenticationv1alpha1.JWTAuthenticator{ObjectMeta: metav1.ObjectMeta{Name: "new-authenticator"}}
+
+	t.Run("active context", func(t *testing.T) {
+		jwtAuthenticators := ContextAwareConciergeClientset(conciergefake.NewSimpleClientset(existing)).
+			AuthenticationV1alpha1().JWTAuthenticators()
+		ctx := context.Background()
+
+		got, err := jwtAuthenticators.Get(ctx, existing.Name, metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, existing, got)
+
+		list, err := jwtAuthenticators.List(ctx, metav1.ListOptions{})
+		require.NoError(t, err)
+		require.Len(t, list.Items, 1)
+
+		created, err := jwtAuthenticators.Create(ctx, newAuthenticator, metav1.CreateOptions{})
+		require.NoError(t, err)
+		require.Equal(t, newAuthenticator, created)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		fake := conciergefake.NewSimpleClientset(existing)
+		jwtAuthenticators := ContextAwareConciergeClientset(fake).AuthenticationV1alpha1().JWTAuthenticators()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		got, err := jwtAuthenticators.Get(ctx, existing.Name, metav1.GetOptions{})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Nil(t, got)
+
+		list, err := jwtAuthenticators.List(ctx, metav1.ListOptions{})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Nil(t, list)
+
+		created, err := jwtAuthenticators.Create(ctx, newAuthenticator, metav1.CreateOptions{})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Nil(t, created)
+
+		// None of the calls should have reached the fake.
+		require.Empty(t, fake.Actions())
+	})
+}
@@ -0,0 +1,91 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package testutil
+
+import (
+	"context"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	configv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
+	conciergeclientset "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned"
+	conciergefake "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned/fake"
+	configv1alpha1client "go.pinniped.dev/generated/latest/client/concierge/clientset/versioned/typed/config/v1alpha1"
+)
+
+// CredentialIssuerRecorder records each CredentialIssuer status update which was successfully applied through
+// the client returned by RecordingCredentialIssuerClient, in the order in which the updates were applied.
+type CredentialIssuerRecorder struct {
+	lock    sync.Mutex
+	updates []configv1alpha1.CredentialIssuer
+}
+
+// StatusUpdates returns a copy of each CredentialIssuer as it was returned by a successful UpdateStatus call.
+func (r *CredentialIssuerRecorder) StatusUpdates() []configv1alpha1.CredentialIssuer {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	result := make([]configv1alpha1.CredentialIssuer, 0, len(r.updates))
+	for i := range r.updates {
+		result = append(result, *r.updates[i].DeepCopy())
+	}
+	return result
+}
+
+// Statuses is a convenience wrapper around StatusUpdates which returns only the recorded statuses.
+func (r *CredentialIssuerRecorder) Statuses() []configv1alpha1.CredentialIssuerStatus {
+	updates := r.StatusUpdates()
+	result := make([]configv1alpha1.CredentialIssuerStatus, 0, len(updates))
+	for _, update := range updates {
+		result = append(result, update.Status)
+	}
+	return result
+}
+
+func (r *CredentialIssuerRecorder) record(credentialIssuer *configv1alpha1.CredentialIssuer) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.updates = append(r.updates, *credentialIssuer.DeepCopy())
+}
+
+// RecordingCredentialIssuerClient returns an in-memory Concierge clientset, pre-populated with the given objects,
+// along with a recorder of the CredentialIssuer status updates applied through that clientset. This allows tests to
+// assert on the order in which status updates were applied without needing to install their own reactors.
+func RecordingCredentialIssuerClient(objects ...runtime.Object) (conciergeclientset.Interface, *CredentialIssuerRecorder) {
+	recorder := &CredentialIssuerRecorder{}
+	return &recordingConciergeClientset{Interface: conciergefake.NewSimpleClientset(objects...), recorder: recorder}, recorder
+}
+
+type recordingConciergeClientset struct {
+	conciergeclientset.Interface
+	recorder *CredentialIssuerRecorder
+}
+
+func (c *recordingConciergeClientset) ConfigV1alpha1() configv1alpha1client.ConfigV1alpha1Interface {
+	return &recordingConfigV1alpha1{ConfigV1alpha1Interface: c.Interface.ConfigV1alpha1(), recorder: c.recorder}
+}
+
+type recordingConfigV1alpha1 struct {
+	configv1alpha1client.ConfigV1alpha1Interface
+	recorder *CredentialIssuerRecorder
+}
+
+func (c *recordingConfigV1alpha1) CredentialIssuers() configv1alpha1client.CredentialIssuerInterface {
+	return &recordingCredentialIssuers{CredentialIssuerInterface: c.ConfigV1alpha1Interface.CredentialIssuers(), recorder: c.recorder}
+}
+
+type recordingCredentialIssuers struct {
+	configv1alpha1client.CredentialIssuerInterface
+	recorder *CredentialIssuerRecorder
+}
+
+func (c *recordingCredentialIssuers) UpdateStatus(ctx context.Context, credentialIssuer *configv1alpha1.CredentialIssuer, opts metav1.UpdateOptions) (*configv1alpha1.CredentialIssuer, error) {
+	updated, err := c.CredentialIssuerInterface.UpdateStatus(ctx, credentialIssuer, opts)
+	if err != nil {
+		return nil, err
+	}
+	c.recorder.record(updated)
+	return updated, nil
+}
@@ -0,0 +1,35 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package testutil
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	authenticationv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/authentication/v1alpha1"
+)
+
+// NewJWTAuthenticator returns a JWTAuthenticator with the given name and spec. When caPEM is not empty, it is
+// base64 encoded into the spec's TLS configuration, so it must contain at least one PEM encoded certificate.
+func NewJWTAuthenticator(name, issuer, audience string, caPEM []byte) *authenticationv1alpha1.JWTAuthenticator {
+	authenticator := &authenticationv1alpha1.JWTAuthenticator{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: authenticationv1alpha1.JWTAuthenticatorSpec{
+			Issuer:   issuer,
+			Audience: audience,
+		},
+	}
+	if len(caPEM) != 0 {
+		if !x509.NewCertPool().AppendCertsFromPEM(caPEM) {
+			panic(fmt.Sprintf("JWTAuthenticator %q: caPEM does not contain any PEM encoded certificates", name))
+		}
+		authenticator.Spec.TLS = &authenticationv1alpha1.TLSSpec{
+			CertificateAuthorityData: base64.StdEncoding.EncodeToString(caPEM),
+		}
+	}
+	return authenticator
+}
@@ -0,0 +1,40 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package testutil
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go.pinniped.dev/internal/certauthority"
+)
+
+func TestNewJWTAuthenticator(t *testing.T) {
+	ca, err := certauthority.New("Test CA", 1*time.Hour)
+	require.NoError(t, err)
+
+	authenticator := NewJWTAuthenticator("test-authenticator", "https://example.com/issuer", "test-audience", ca.Bundle())
+	require.Equal(t, "test-authenticator", authenticator.Name)
+	require.Equal(t, "https://example.com/issuer", authenticator.Spec.Issuer)
+	require.Equal(t, "test-audience", authenticator.Spec.Audience)
+	require.NotNil(t, authenticator.Spec.TLS)
+
+	decoded, err := base64.StdEncoding.DecodeString(authenticator.Spec.TLS.CertificateAuthorityData)
+	require.NoError(t, err)
+	require.Equal(t, ca.Bundle(), decoded)
+}
+
+func TestNewJWTAuthenticatorWithoutCA(t *testing.T) {
+	authenticator := NewJWTAuthenticator("test-authenticator", "https://example.com/issuer", "test-audience", nil)
+	require.Nil(t, authenticator.Spec.TLS)
+}
+
+func TestNewJWTAuthenticatorInvalidCA(t *testing.T) {
+	require.PanicsWithValue(t, `JWTAuthenticator "test-authenticator": caPEM does not contain any PEM encoded certificates`, func() {
+		NewJWTAuthenticator("test-authenticator", "https://example.com/issuer", "test-audience", []byte("not a certificate"))
+	})
+}
@@ -56,6 +56,9 @@ type handlerState struct {
 
 	requestedAudience string
 
+	upstreamIdentityProviderName string
+	upstreamIdentityProviderType string
+
 	httpClient *http.Client
 
 	// Parameters of the localhost listener.
@@ -112,6 +115,19 @@ func WithListenPort(port uint16) Option {
 	}
 }
 
+// WithRedirectURIPath specifies the path portion of the localhost redirect_uri, which is also the path on which the
+// authorization code callback will be handled. This is useful for authorization servers which require an exact
+// redirect URI to be registered. By default, the path is "/callback".
+func WithRedirectURIPath(path string) Option {
+	return func(h *handlerState) error {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("invalid redirect URI path %q: must begin with a \"/\"", path)
+		}
+		h.callbackPath = path
+		return nil
+	}
+}
+
 // WithScopes sets the OAuth2 scopes to request during login. If not specified, it defaults to
 // "offline_access openid email profile".
 func WithScopes(scopes []string) Option {
@@ -160,13 +176,27 @@ func WithClient(httpClient *http.Client) Option {
 }
 
 // WithRequestAudience causes the login flow to perform an additional token exchange using the RFC8693 flow.
+// It may only be passed once, since the Supervisor issues each exchanged token for a single audience.
 func WithRequestAudience(audience string) Option {
 	return func(h *handlerState) error {
+		if h.requestedAudience != "" {
+			return fmt.Errorf("only one requested audience is supported, but got %q and %q", h.requestedAudience, audience)
+		}
 		h.requestedAudience = audience
 		return nil
 	}
 }
 
+// WithUpstreamIdentityProvider causes the specified name and type to be sent as custom query parameters to the
+// Supervisor's authorize endpoint, which selects an upstream identity provider when a FederationDomain has several.
+func WithUpstreamIdentityProvider(upstreamName, upstreamType string) Option {
+	return func(h *handlerState) error {
+		h.upstreamIdentityProviderName = upstreamName
+		h.upstreamIdentityProviderType = upstreamType
+		return nil
+	}
+}
+
 // nopCache is a SessionCache that doesn't actually do anything.
 type nopCache struct{}
 
@@ -297,13 +327,19 @@ func (h *handlerState) baseLogin() (*oidctypes.Token, error) {
 	defer shutdown()
 
 	// Open the authorize URL in the users browser.
-	authorizeURL := h.oauth2Config.AuthCodeURL(
-		h.state.String(),
+	authorizeOptions := []oauth2.AuthCodeOption{
 		oauth2.AccessTypeOffline,
 		h.nonce.Param(),
 		h.pkce.Challenge(),
 		h.pkce.Method(),
-	)
+	}
+	if h.upstreamIdentityProviderName != "" {
+		authorizeOptions = append(authorizeOptions, oauth2.SetAuthURLParam("pinniped_idp_name", h.upstreamIdentityProviderName))
+	}
+	if h.upstreamIdentityProviderType != "" {
+		authorizeOptions = append(authorizeOptions, oauth2.SetAuthURLParam("pinniped_idp_type", h.upstreamIdentityProviderType))
+	}
+	authorizeURL := h.oauth2Config.AuthCodeURL(h.state.String(), authorizeOptions...)
 	if err := h.openURL(authorizeURL); err != nil {
 		return nil, fmt.Errorf("could not open browser: %w", err)
 	}
@@ -292,6 +292,35 @@ func TestLogin(t *testing.T) {
 			},
 			wantToken: &testToken,
 		},
+		{
+			name: "invalid redirect URI path",
+			opt: func(t *testing.T) Option {
+				return WithRedirectURIPath("not/absolute")
+			},
+			wantErr: `invalid redirect URI path "not/absolute": must begin with a "/"`,
+		},
+		{
+			name:     "session cache hit with valid token and custom redirect URI path",
+			issuer:   "test-issuer",
+			clientID: "test-client-id",
+			opt: func(t *testing.T) Option {
+				return func(h *handlerState) error {
+					cache := &mockSessionCache{t: t, getReturnsToken: &testToken}
+					t.Cleanup(func() {
+						require.Equal(t, []SessionCacheKey{{
+							Issuer:      "test-issuer",
+							ClientID:    "test-client-id",
+							Scopes:      []string{"test-scope"},
+							RedirectURI: "http://localhost:0/some/custom/path",
+						}}, cache.sawGetKeys)
+						require.Empty(t, cache.sawPutTokens)
+					})
+					require.NoError(t, WithRedirectURIPath("/some/custom/path")(h))
+					return WithSessionCache(cache)(h)
+				}
+			},
+			wantToken: &testToken,
+		},
 		{
 			name: "discovery failure",
 			opt: func(t *testing.T) Option {
@@ -512,6 +541,50 @@ func TestLogin(t *testing.T) {
 			issuer:    successServer.URL,
 			wantToken: &testToken,
 		},
+		{
+			name:     "callback returns success with upstream identity provider selected",
+			clientID: "test-client-id",
+			opt: func(t *testing.T) Option {
+				return func(h *handlerState) error {
+					h.generateState = func() (state.State, error) { return "test-state", nil }
+					h.generatePKCE = func() (pkce.Code, error) { return "test-pkce", nil }
+					h.generateNonce = func() (nonce.Nonce, error) { return "test-nonce", nil }
+
+					require.NoError(t, WithUpstreamIdentityProvider("some-upstream-name", "ldap")(h))
+					require.NoError(t, WithClient(&http.Client{Timeout: 10 * time.Second})(h))
+
+					h.openURL = func(actualURL string) error {
+						parsedActualURL, err := url.Parse(actualURL)
+						require.NoError(t, err)
+						actualParams := parsedActualURL.Query()
+
+						require.Contains(t, actualParams.Get("redirect_uri"), "http://127.0.0.1:")
+						actualParams.Del("redirect_uri")
+
+						require.Equal(t, url.Values{
+							"code_challenge":        []string{"VVaezYqum7reIhoavCHD1n2d-piN3r_mywoYj7fCR7g"},
+							"code_challenge_method": []string{"S256"},
+							"response_type":         []string{"code"},
+							"scope":                 []string{"test-scope"},
+							"nonce":                 []string{"test-nonce"},
+							"state":                 []string{"test-state"},
+							"access_type":           []string{"offline"},
+							"client_id":             []string{"test-client-id"},
+							"pinniped_idp_name":     []string{"some-upstream-name"},
+							"pinniped_idp_type":     []string{"ldap"},
+						}, actualParams)
+
+						go func() {
+							h.callbacks <- callbackResult{token: &testToken}
+						}()
+						return nil
+					}
+					return nil
+				}
+			},
+			issuer:    successServer.URL,
+			wantToken: &testToken,
+		},
 		{
 			name:     "with requested audience, session cache hit with valid token, but discovery fails",
 			clientID: "test-client-id",
@@ -771,6 +844,18 @@ func TestLogin(t *testing.T) {
 			},
 			wantToken: &testExchangedToken,
 		},
+		{
+			name:     "with multiple requested audiences",
+			issuer:   successServer.URL,
+			clientID: "test-client-id",
+			opt: func(t *testing.T) Option {
+				return func(h *handlerState) error {
+					require.NoError(t, WithRequestAudience("test-audience")(h))
+					return WithRequestAudience("test-other-audience")(h)
+				}
+			},
+			wantErr: `only one requested audience is supported, but got "test-audience" and "test-other-audience"`,
+		},
 		{
 			name:     "with requested audience, session cache hit with valid refresh token, and token exchange request succeeds",
 			issuer:   successServer.URL,
@@ -5,6 +5,8 @@ package integration
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"os/exec"
 	"strings"
 	"sync"
@@ -17,6 +19,30 @@ import (
 	"go.pinniped.dev/test/library"
 )
 
+const (
+	// kubectlRetryWindow is the total amount of time runTestKubectlCommand will spend retrying kubectl.
+	kubectlRetryWindow = 120 * time.Second
+
+	// kubectlMaxAttemptTimeout bounds a single kubectl invocation, so that a hung kubectl gets killed and retried
+	// instead of consuming the entire retry window.
+	kubectlMaxAttemptTimeout = 30 * time.Second
+
+	// kubectlMinAttemptTimeout is the least amount of time that any single kubectl invocation will be given.
+	kubectlMinAttemptTimeout = time.Second
+)
+
+// kubectlAttemptTimeout returns the timeout for the next kubectl attempt, given the remaining retry budget.
+func kubectlAttemptTimeout(remaining time.Duration) time.Duration {
+	switch {
+	case remaining > kubectlMaxAttemptTimeout:
+		return kubectlMaxAttemptTimeout
+	case remaining < kubectlMinAttemptTimeout:
+		return kubectlMinAttemptTimeout
+	default:
+		return remaining
+	}
+}
+
 func runTestKubectlCommand(t *testing.T, args ...string) (string, string) {
 	t.Helper()
 
@@ -24,6 +50,7 @@ func runTestKubectlCommand(t *testing.T, args ...string) (string, string) {
 	var stdOut, stdErr bytes.Buffer
 	var err error
 	start := time.Now()
+	deadline := start.Add(kubectlRetryWindow)
 	attempts := 0
 	if !assert.Eventually(t, func() bool {
 		lock.Lock()
@@ -31,13 +58,19 @@ func runTestKubectlCommand(t *testing.T, args ...string) (string, string) {
 		attempts++
 		stdOut.Reset()
 		stdErr.Reset()
-		cmd := exec.Command("kubectl", args...)
+		timeout := kubectlAttemptTimeout(time.Until(deadline))
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, "kubectl", args...)
 		cmd.Stdout = &stdOut
 		cmd.Stderr = &stdErr
 		err = cmd.Run()
+		if ctx.Err() != nil {
+			err = fmt.Errorf("%w (kubectl was killed after %s)", err, timeout)
+		}
 		return err == nil
 	},
-		120*time.Second,
+		kubectlRetryWindow,
 		200*time.Millisecond,
 	) {
 		lock.Lock()
@@ -55,6 +88,26 @@ func runTestKubectlCommand(t *testing.T, args ...string) (string, string) {
 	}
 	return stdOut.String(), stdErr.String()
 }
+
+func TestKubectlAttemptTimeout(t *testing.T) {
+	tests := []struct {
+		name      string
+		remaining time.Duration
+		want      time.Duration
+	}{
+		{name: "full budget remaining", remaining: kubectlRetryWindow, want: kubectlMaxAttemptTimeout},
+		{name: "less than one attempt remaining", remaining: 10 * time.Second, want: 10 * time.Second},
+		{name: "almost no budget remaining", remaining: time.Millisecond, want: kubectlMinAttemptTimeout},
+		{name: "budget exhausted", remaining: -5 * time.Second, want: kubectlMinAttemptTimeout},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, kubectlAttemptTimeout(tt.remaining))
+		})
+	}
+}
+
 func TestGetPinnipedCategory(t *testing.T) {
 	env := library.IntegrationEnv(t)
 	dotSuffix := "." + env.APIGroupSuffix
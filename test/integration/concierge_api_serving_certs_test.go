@@ -4,17 +4,18 @@
 package integration
 
 import (
-	"bytes"
 	"context"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
 	loginv1alpha1 "go.pinniped.dev/generated/latest/apis/concierge/login/v1alpha1"
+	"go.pinniped.dev/internal/controller/apicerts"
 	"go.pinniped.dev/internal/testutil"
 	"go.pinniped.dev/test/library"
 )
@@ -58,7 +59,7 @@ func TestAPIServingCertificateAutoCreationAndRotation(t *testing.T) {
 					return err
 				}
 
-				secret.Data["tlsCertificateChain"], _, err = createExpiredCertificate()
+				secret.Data[apicerts.TLSCertificateChainSecretKey], _, err = testutil.CreateExpiredCertificate()
 				if err != nil {
 					return err
 				}
@@ -75,7 +76,6 @@ func TestAPIServingCertificateAutoCreationAndRotation(t *testing.T) {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
 			kubeClient := library.NewKubernetesClientset(t)
-			aggregatedClient := library.NewAggregatedClientset(t)
 			conciergeClient := library.NewConciergeClientset(t)
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 			defer cancel()
@@ -86,12 +86,23 @@ func TestAPIServingCertificateAutoCreationAndRotation(t *testing.T) {
 			// TokenCredentialRequest API.
 			testWebhook := library.CreateTestWebhookAuthenticator(ctx, t)
 
+			// The Secret should always be owned by the Concierge Deployment, so that it gets garbage collected
+			// when the Concierge is uninstalled.
+			deployment, err := kubeClient.AppsV1().Deployments(env.ConciergeNamespace).Get(ctx, env.ConciergeAppName, metav1.GetOptions{})
+			require.NoError(t, err)
+			wantOwnerRefs := []metav1.OwnerReference{{
+				APIVersion: appsv1.SchemeGroupVersion.String(),
+				Kind:       "Deployment",
+				Name:       deployment.Name,
+				UID:        deployment.UID,
+			}}
+
 			// Get the initial auto-generated version of the Secret.
 			secret, err := kubeClient.CoreV1().Secrets(env.ConciergeNamespace).Get(ctx, defaultServingCertResourceName, metav1.GetOptions{})
 			require.NoError(t, err)
-			initialCACert := secret.Data["caCertificate"]
-			initialPrivateKey := secret.Data["tlsPrivateKey"]
-			initialCertChain := secret.Data["tlsCertificateChain"]
+			initialCACert := secret.Data[apicerts.CACertificateSecretKey]
+			initialPrivateKey := secret.Data[apicerts.TLSPrivateKeySecretKey]
+			initialCertChain := secret.Data[apicerts.TLSCertificateChainSecretKey]
 			require.NotEmpty(t, initialCACert)
 			require.NotEmpty(t, initialPrivateKey)
 			require.NotEmpty(t, initialCertChain)
@@ -99,11 +110,11 @@ func TestAPIServingCertificateAutoCreationAndRotation(t *testing.T) {
 				require.Equalf(t, v, secret.Labels[k], "expected secret to have label %s: %s", k, v)
 			}
 			require.Equal(t, env.ConciergeAppName, secret.Labels["app"])
+			require.Equal(t, wantOwnerRefs, secret.OwnerReferences)
 
 			// Check that the APIService has the same CA.
-			apiService, err := aggregatedClient.ApiregistrationV1().APIServices().Get(ctx, apiServiceName, metav1.GetOptions{})
-			require.NoError(t, err)
-			require.Equal(t, initialCACert, apiService.Spec.CABundle)
+			require.Equal(t, initialCACert, library.RequireAPIServiceCAMatchesSecret(t,
+				apiServiceName, env.ConciergeNamespace, defaultServingCertResourceName, 10*time.Second, 250*time.Millisecond))
 
 			// Force rotation to happen.
 			require.NoError(t, test.forceRotation(ctx, kubeClient, env.ConciergeNamespace))
@@ -115,9 +126,9 @@ func TestAPIServingCertificateAutoCreationAndRotation(t *testing.T) {
 			}
 			assert.Eventually(t, secretIsRegenerated, 10*time.Second, 250*time.Millisecond)
 			require.NoError(t, err) // prints out the error and stops the test in case of failure
-			regeneratedCACert := secret.Data["caCertificate"]
-			regeneratedPrivateKey := secret.Data["tlsPrivateKey"]
-			regeneratedCertChain := secret.Data["tlsCertificateChain"]
+			regeneratedCACert := secret.Data[apicerts.CACertificateSecretKey]
+			regeneratedPrivateKey := secret.Data[apicerts.TLSPrivateKeySecretKey]
+			regeneratedCertChain := secret.Data[apicerts.TLSCertificateChainSecretKey]
 			require.NotEmpty(t, regeneratedCACert)
 			require.NotEmpty(t, regeneratedPrivateKey)
 			require.NotEmpty(t, regeneratedCertChain)
@@ -128,21 +139,12 @@ func TestAPIServingCertificateAutoCreationAndRotation(t *testing.T) {
 				require.Equalf(t, v, secret.Labels[k], "expected secret to have label `%s: %s`", k, v)
 			}
 			require.Equal(t, env.ConciergeAppName, secret.Labels["app"])
+			require.Equal(t, wantOwnerRefs, secret.OwnerReferences, "rotation should preserve the owner reference")
 
 			// Expect that the APIService was also updated with the new CA.
-			require.Eventually(t, func() bool {
-				apiService, err := aggregatedClient.ApiregistrationV1().APIServices().Get(ctx, apiServiceName, metav1.GetOptions{})
-				if err != nil {
-					t.Logf("get for APIService %q returned error %v", apiServiceName, err)
-					return false
-				}
-				if !bytes.Equal(regeneratedCACert, apiService.Spec.CABundle) {
-					t.Logf("CA bundle in APIService %q does not yet have the expected value", apiServiceName)
-					return false
-				}
-				t.Logf("found that APIService %q was updated to expected CA certificate", apiServiceName)
-				return true
-			}, 10*time.Second, 250*time.Millisecond, "never saw CA certificate rotate to expected value")
+			require.Equal(t, regeneratedCACert, library.RequireAPIServiceCAMatchesSecret(t,
+				apiServiceName, env.ConciergeNamespace, defaultServingCertResourceName, 10*time.Second, 250*time.Millisecond),
+				"never saw CA certificate rotate to expected value")
 
 			// Check that we can still make requests to the aggregated API through the kube API server,
 			// because the kube API server uses these certs when proxying requests to the aggregated API server,
@@ -171,10 +173,3 @@ func TestAPIServingCertificateAutoCreationAndRotation(t *testing.T) {
 		})
 	}
 }
-
-func createExpiredCertificate() ([]byte, []byte, error) {
-	return testutil.CreateCertificate(
-		time.Now().Add(-24*time.Hour), // notBefore
-		time.Now().Add(-time.Hour),    // notAfter
-	)
-}
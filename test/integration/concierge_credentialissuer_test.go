@@ -119,3 +119,32 @@ func TestCredentialIssuer(t *testing.T) {
 		}
 	})
 }
+
+func TestCredentialIssuerImpersonationProxyStrategy(t *testing.T) {
+	library.IntegrationEnv(t).WithCapability(library.ImpersonationProxyIsAvailable)
+	client := library.NewConciergeClientset(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	credentialIssuers, err := client.ConfigV1alpha1().CredentialIssuers().List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, credentialIssuers.Items, 1)
+
+	// The capability means that there is a successful ImpersonationProxy strategy, which should advertise its frontend.
+	var found bool
+	for _, strategy := range credentialIssuers.Items[0].Status.Strategies {
+		if strategy.Type != configv1alpha1.ImpersonationProxyStrategyType || strategy.Status != configv1alpha1.SuccessStrategyStatus {
+			continue
+		}
+		found = true
+		require.Equal(t, configv1alpha1.ListeningStrategyReason, strategy.Reason)
+		require.NotNil(t, strategy.Frontend)
+		require.Equal(t, configv1alpha1.ImpersonationProxyFrontendType, strategy.Frontend.Type)
+		require.NotNil(t, strategy.Frontend.ImpersonationProxyInfo)
+		require.NotEmpty(t, strategy.Frontend.ImpersonationProxyInfo.Endpoint)
+		_, err := base64.StdEncoding.DecodeString(strategy.Frontend.ImpersonationProxyInfo.CertificateAuthorityData)
+		require.NoError(t, err)
+	}
+	require.True(t, found, "expected a successful ImpersonationProxy strategy")
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package library
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	aggregatorclient "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset"
+)
+
+// apiServiceCACertificateSecretKey is the key of the CA certificate in the serving certificate Secret, i.e.
+// apicerts.CACertificateSecretKey. It is repeated here because importing apicerts would create an import cycle
+// with the unit tests of the packages that apicerts imports.
+const apiServiceCACertificateSecretKey = "caCertificate"
+
+// RequireAPIServiceCAMatchesSecret waits until the spec.caBundle of the named APIService is equal to the CA
+// certificate stored in the named serving certificate Secret, and returns that CA certificate. Both objects are
+// re-read on every tick, since the Secret and the APIService are updated by separate controllers.
+func RequireAPIServiceCAMatchesSecret(t *testing.T, apiServiceName, namespace, secretName string, waitFor, tick time.Duration) []byte {
+	t.Helper()
+	return requireAPIServiceCAMatchesSecret(t, NewKubernetesClientset(t), NewAggregatedClientset(t), apiServiceName, namespace, secretName, waitFor, tick)
+}
+
+func requireAPIServiceCAMatchesSecret(
+	t *testing.T,
+	kubeClient kubernetes.Interface,
+	aggregatedClient aggregatorclient.Interface,
+	apiServiceName, namespace, secretName string,
+	waitFor, tick time.Duration,
+) []byte {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), waitFor)
+	defer cancel()
+
+	var caCert []byte
+	require.Eventuallyf(t, func() bool {
+		secret, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+		if err != nil {
+			t.Logf("get for Secret %s/%s returned error %v", namespace, secretName, err)
+			return false
+		}
+		apiService, err := aggregatedClient.ApiregistrationV1().APIServices().Get(ctx, apiServiceName, metav1.GetOptions{})
+		if err != nil {
+			t.Logf("get for APIService %q returned error %v", apiServiceName, err)
+			return false
+		}
+		secretCA := secret.Data[apiServiceCACertificateSecretKey]
+		if len(secretCA) == 0 || !bytes.Equal(secretCA, apiService.Spec.CABundle) {
+			t.Logf("CA bundle in APIService %q does not yet match Secret %s/%s", apiServiceName, namespace, secretName)
+			return false
+		}
+		caCert = secretCA
+		return true
+	}, waitFor, tick, "CA bundle in APIService %q never matched Secret %s/%s", apiServiceName, namespace, secretName)
+	return caCert
+}
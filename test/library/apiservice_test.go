@@ -0,0 +1,56 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package library
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	aggregatorfake "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset/fake"
+)
+
+func TestRequireAPIServiceCAMatchesSecret(t *testing.T) {
+	secret := func(ca string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test-namespace", Name: "test-secret"},
+			Data:       map[string][]byte{apiServiceCACertificateSecretKey: []byte(ca)},
+		}
+	}
+	apiService := func(ca string) *apiregistrationv1.APIService {
+		return &apiregistrationv1.APIService{
+			ObjectMeta: metav1.ObjectMeta{Name: "v1alpha1.login.concierge.pinniped.dev"},
+			Spec:       apiregistrationv1.APIServiceSpec{CABundle: []byte(ca)},
+		}
+	}
+
+	t.Run("already matching", func(t *testing.T) {
+		kubeClient := kubernetesfake.NewSimpleClientset(secret("test-ca"))
+		aggregatedClient := aggregatorfake.NewSimpleClientset(apiService("test-ca"))
+
+		got := requireAPIServiceCAMatchesSecret(t, kubeClient, aggregatedClient,
+			"v1alpha1.login.concierge.pinniped.dev", "test-namespace", "test-secret", time.Second, 10*time.Millisecond)
+		require.Equal(t, []byte("test-ca"), got)
+	})
+
+	t.Run("eventually matching after the APIService is updated", func(t *testing.T) {
+		kubeClient := kubernetesfake.NewSimpleClientset(secret("new-ca"))
+		aggregatedClient := aggregatorfake.NewSimpleClientset(apiService("old-ca"))
+
+		time.AfterFunc(100*time.Millisecond, func() {
+			_, err := aggregatedClient.ApiregistrationV1().APIServices().Update(context.Background(), apiService("new-ca"), metav1.UpdateOptions{})
+			assert.NoError(t, err)
+		})
+
+		got := requireAPIServiceCAMatchesSecret(t, kubeClient, aggregatedClient,
+			"v1alpha1.login.concierge.pinniped.dev", "test-namespace", "test-secret", 5*time.Second, 10*time.Millisecond)
+		require.Equal(t, []byte("new-ca"), got)
+	})
+}
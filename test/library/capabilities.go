@@ -0,0 +1,43 @@
+// Copyright 2021 the Pinniped contributors. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package library
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	conciergev1alpha "go.pinniped.dev/generated/latest/apis/concierge/config/v1alpha1"
+)
+
+// detectableCapabilities maps the capabilities which may be omitted from the capabilities YAML to a function which
+// detects them by inspecting the cluster. A value in the capabilities YAML always takes precedence over detection.
+//nolint: gochecknoglobals
+var detectableCapabilities = map[Capability]func(t *testing.T) bool{
+	ImpersonationProxyIsAvailable: impersonationProxyIsAvailable,
+}
+
+// impersonationProxyIsAvailable returns true when any CredentialIssuer has a successful ImpersonationProxy strategy.
+func impersonationProxyIsAvailable(t *testing.T) bool {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	credentialIssuers, err := NewConciergeClientset(t).ConfigV1alpha1().CredentialIssuers().List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+
+	for _, credentialIssuer := range credentialIssuers.Items {
+		for _, strategy := range credentialIssuer.Status.Strategies {
+			if strategy.Type == conciergev1alpha.ImpersonationProxyStrategyType &&
+				strategy.Status == conciergev1alpha.SuccessStrategyStatus {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -22,6 +22,7 @@ const (
 	ClusterSigningKeyIsAvailable     Capability = "clusterSigningKeyIsAvailable"
 	AnonymousAuthenticationSupported Capability = "anonymousAuthenticationSupported"
 	HasExternalLoadBalancerProvider  Capability = "hasExternalLoadBalancerProvider"
+	ImpersonationProxyIsAvailable    Capability = "impersonationProxyIsAvailable"
 )
 
 // TestEnv captures all the external parameters consumed by our integration tests.
@@ -207,7 +208,15 @@ func loadEnvVars(t *testing.T, result *TestEnv) {
 func (e *TestEnv) HasCapability(cap Capability) bool {
 	e.t.Helper()
 	isCapable, capabilityWasDescribed := e.Capabilities[cap]
-	require.Truef(e.t, capabilityWasDescribed, "the %q capability of the test environment was not described", cap)
+	if !capabilityWasDescribed {
+		detect, detectable := detectableCapabilities[cap]
+		require.Truef(e.t, detectable, "the %q capability of the test environment was not described", cap)
+		isCapable = detect(e.t)
+		if e.Capabilities == nil {
+			e.Capabilities = map[Capability]bool{}
+		}
+		e.Capabilities[cap] = isCapable
+	}
 	return isCapable
 }
 